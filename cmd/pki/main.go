@@ -0,0 +1,274 @@
+// Command pki manages the development certificate authority and the certificates it issues.
+//
+//	pki init    [-dir certs]                               create the CA plus default server and client certs
+//	pki server  -service NAME [-host HOST]... [-dir certs]  issue a server certificate for a service
+//	pki client  -name NAME [-spiffe-id ID] [-dir certs]     issue a client certificate for mTLS
+//	pki rotate  [-dir certs] [-renew-before 720h]          reissue certificates that expire soon
+//	pki status  [-dir certs]                               report certificate expiry
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/pki"
+)
+
+type hostList []string
+
+func (h *hostList) String() string     { return strings.Join(*h, ",") }
+func (h *hostList) Set(v string) error { *h = append(*h, v); return nil }
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	switch cmd, args := os.Args[1], os.Args[2:]; cmd {
+	case "init":
+		err = runInit(args)
+	case "server":
+		err = runServer(args)
+	case "client":
+		err = runClient(args)
+	case "rotate":
+		err = runRotate(args)
+	case "status":
+		err = runStatus(args)
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pki: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: pki <init|server|client|rotate|status> [flags]")
+	os.Exit(2)
+}
+
+func loadCA(dir string) (*pki.CA, error) {
+	ca, created, err := pki.EnsureCA(filepath.Join(dir, "ca-cert.pem"), filepath.Join(dir, "ca-key.pem"), "GoMicroserviceFramework Development CA")
+	if err != nil {
+		return nil, err
+	}
+	if created {
+		fmt.Printf("Created CA %s\n", filepath.Join(dir, "ca-cert.pem"))
+	}
+	return ca, nil
+}
+
+// runInit creates the files previously produced by generate-certs.sh: a CA, a shared server
+// certificate for every service under services/ and a client certificate
+func runInit(args []string) error {
+	fs := flag.NewFlagSet("init", flag.ExitOnError)
+	dir := fs.String("dir", "certs", "Output directory")
+	servicesDir := fs.String("services-dir", "services", "Directory whose subdirectories name the services")
+	trustDomain := fs.String("trust-domain", pki.DefaultTrustDomain, "SPIFFE trust domain of the client certificate")
+	fs.Parse(args)
+
+	ca, err := loadCA(*dir)
+	if err != nil {
+		return err
+	}
+
+	services, err := listServices(*servicesDir)
+	if err != nil {
+		return err
+	}
+	written, err := ca.EnsureServer(filepath.Join(*dir, "server-cert.pem"), filepath.Join(*dir, "server-key.pem"), pki.ServerRequest{
+		CommonName: "localhost",
+		Hosts:      pki.ServiceHosts(services...),
+	}, 0)
+	if err != nil {
+		return err
+	}
+	report(written, filepath.Join(*dir, "server-cert.pem"))
+
+	written, err = ca.EnsureClient(filepath.Join(*dir, "client-cert.pem"), filepath.Join(*dir, "client-key.pem"), pki.ClientRequest{
+		CommonName: "client",
+		SPIFFEID:   pki.SPIFFEID(*trustDomain, "client"),
+	}, 0)
+	if err != nil {
+		return err
+	}
+	report(written, filepath.Join(*dir, "client-cert.pem"))
+	return nil
+}
+
+func runServer(args []string) error {
+	fs := flag.NewFlagSet("server", flag.ExitOnError)
+	dir := fs.String("dir", "certs", "Output directory")
+	service := fs.String("service", "", "Service name, used as the docker-compose hostname and file name")
+	validity := fs.Duration("validity", pki.DefaultValidity, "Certificate lifetime")
+	var hosts hostList
+	fs.Var(&hosts, "host", "Additional DNS name or IP address (repeatable)")
+	fs.Parse(args)
+
+	if *service == "" {
+		return fmt.Errorf("-service is required")
+	}
+	ca, err := loadCA(*dir)
+	if err != nil {
+		return err
+	}
+
+	cert, err := ca.IssueServer(pki.ServerRequest{
+		CommonName: *service,
+		Hosts:      append(pki.ServiceHosts(*service), hosts...),
+		Validity:   *validity,
+	})
+	if err != nil {
+		return err
+	}
+	certFile := filepath.Join(*dir, *service+"-cert.pem")
+	if err := cert.Save(certFile, filepath.Join(*dir, *service+"-key.pem")); err != nil {
+		return err
+	}
+	report(true, certFile)
+	return nil
+}
+
+func runClient(args []string) error {
+	fs := flag.NewFlagSet("client", flag.ExitOnError)
+	dir := fs.String("dir", "certs", "Output directory")
+	name := fs.String("name", "", "Client name, used as the common name and file name")
+	spiffeID := fs.String("spiffe-id", "", "SPIFFE ID (default: spiffe://<trust-domain>/<name>)")
+	trustDomain := fs.String("trust-domain", pki.DefaultTrustDomain, "SPIFFE trust domain")
+	validity := fs.Duration("validity", pki.DefaultValidity, "Certificate lifetime")
+	fs.Parse(args)
+
+	if *name == "" {
+		return fmt.Errorf("-name is required")
+	}
+	if *spiffeID == "" {
+		*spiffeID = pki.SPIFFEID(*trustDomain, *name)
+	}
+	ca, err := loadCA(*dir)
+	if err != nil {
+		return err
+	}
+
+	cert, err := ca.IssueClient(pki.ClientRequest{CommonName: *name, SPIFFEID: *spiffeID, Validity: *validity})
+	if err != nil {
+		return err
+	}
+	certFile := filepath.Join(*dir, *name+"-client-cert.pem")
+	if err := cert.Save(certFile, filepath.Join(*dir, *name+"-client-key.pem")); err != nil {
+		return err
+	}
+	report(true, certFile)
+	return nil
+}
+
+func runRotate(args []string) error {
+	fs := flag.NewFlagSet("rotate", flag.ExitOnError)
+	dir := fs.String("dir", "certs", "Certificate directory")
+	renewBefore := fs.Duration("renew-before", pki.DefaultRenewBefore, "Reissue certificates expiring within this duration")
+	validity := fs.Duration("validity", pki.DefaultValidity, "Lifetime of reissued certificates")
+	force := fs.Bool("force", false, "Reissue all certificates regardless of expiry")
+	fs.Parse(args)
+
+	ca, err := loadCA(*dir)
+	if err != nil {
+		return err
+	}
+
+	certFiles, err := leafCertificates(*dir)
+	if err != nil {
+		return err
+	}
+	for _, certFile := range certFiles {
+		expiry, err := pki.Expiry(certFile)
+		if err != nil {
+			return err
+		}
+		if !*force && time.Until(expiry) >= *renewBefore {
+			continue
+		}
+		keyFile := strings.TrimSuffix(certFile, "-cert.pem") + "-key.pem"
+		if err := ca.Renew(certFile, keyFile, *validity); err != nil {
+			return fmt.Errorf("failed to renew %s: %w", certFile, err)
+		}
+		fmt.Printf("Renewed %s\n", certFile)
+	}
+	return nil
+}
+
+func runStatus(args []string) error {
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	dir := fs.String("dir", "certs", "Certificate directory")
+	fs.Parse(args)
+
+	certFiles, err := filepath.Glob(filepath.Join(*dir, "*-cert.pem"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(certFiles)
+	for _, certFile := range certFiles {
+		cert, err := pki.ReadCertificate(certFile)
+		if err != nil {
+			return err
+		}
+		remaining := time.Until(cert.NotAfter)
+		state := "ok"
+		switch {
+		case remaining <= 0:
+			state = "EXPIRED"
+		case remaining < pki.DefaultRenewBefore:
+			state = "renew soon"
+		}
+		fmt.Printf("%-40s %-40s expires %s (%d days, %s)\n",
+			filepath.Base(certFile), cert.Subject.CommonName, cert.NotAfter.Format(time.RFC3339), int(remaining.Hours()/24), state)
+	}
+	return nil
+}
+
+func report(written bool, certFile string) {
+	if written {
+		fmt.Printf("Issued %s\n", certFile)
+	} else {
+		fmt.Printf("Kept %s (still valid)\n", certFile)
+	}
+}
+
+// leafCertificates lists the certificates in dir other than the CA
+func leafCertificates(dir string) ([]string, error) {
+	certFiles, err := filepath.Glob(filepath.Join(dir, "*-cert.pem"))
+	if err != nil {
+		return nil, err
+	}
+	var leaves []string
+	for _, certFile := range certFiles {
+		if filepath.Base(certFile) != "ca-cert.pem" {
+			leaves = append(leaves, certFile)
+		}
+	}
+	sort.Strings(leaves)
+	return leaves, nil
+}
+
+func listServices(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var services []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			services = append(services, entry.Name())
+		}
+	}
+	return services, nil
+}
@@ -0,0 +1,64 @@
+package admin
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Set at link time by scripts/build:
+//
+//	-ldflags "-X github.com/LucasPluta/GoMicroserviceFramework/pkg/admin.Version=1.4.0 ..."
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+// BuildInfo describes the running binary
+type BuildInfo struct {
+	Version   string            `json:"version"`
+	Commit    string            `json:"commit,omitempty"`
+	BuildTime string            `json:"build_time,omitempty"`
+	GoVersion string            `json:"go_version"`
+	Path      string            `json:"path,omitempty"`   // Main package
+	Module    string            `json:"module,omitempty"` // Main module version
+	Settings  map[string]string `json:"settings,omitempty"`
+	Deps      map[string]string `json:"deps,omitempty"`
+}
+
+// ReadBuildInfo combines the link-time variables with the information embedded by the Go
+// toolchain. The commit falls back to the VCS revision recorded by go build.
+func ReadBuildInfo() BuildInfo {
+	info := BuildInfo{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+	}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+
+	info.Path = bi.Path
+	info.Module = bi.Main.Version
+	info.Settings = map[string]string{}
+	for _, s := range bi.Settings {
+		info.Settings[s.Key] = s.Value
+	}
+	if info.Commit == "" {
+		info.Commit = info.Settings["vcs.revision"]
+	}
+	if info.BuildTime == "" {
+		info.BuildTime = info.Settings["vcs.time"]
+	}
+	info.Deps = make(map[string]string, len(bi.Deps))
+	for _, dep := range bi.Deps {
+		version := dep.Version
+		if dep.Replace != nil {
+			version = dep.Replace.Path + " " + dep.Replace.Version
+		}
+		info.Deps[dep.Path] = version
+	}
+	return info
+}
@@ -0,0 +1,105 @@
+package admin
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/metrics"
+)
+
+var drainingMetric = metrics.Int("draining")
+
+// Drainer takes an instance out of rotation for maintenance without stopping it: calls in
+// flight and new calls still succeed, but health checks fail and hooks (e.g. marking the
+// registry instance NOT_SERVING) steer clients to other instances.
+type Drainer struct {
+	mu       sync.Mutex
+	draining bool
+	hooks    []func(ctx context.Context, draining bool) error
+}
+
+// NewDrainer creates a drainer in the serving state
+func NewDrainer() *Drainer {
+	return &Drainer{}
+}
+
+// OnChange registers a hook called when the instance starts or stops draining
+func (d *Drainer) OnChange(hook func(ctx context.Context, draining bool) error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.hooks = append(d.hooks, hook)
+}
+
+// Draining reports whether the instance is draining
+func (d *Drainer) Draining() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.draining
+}
+
+// Set starts or stops draining and runs the hooks. The state changes even if a hook
+// fails; the first error is returned.
+func (d *Drainer) Set(ctx context.Context, draining bool) error {
+	d.mu.Lock()
+	if d.draining == draining {
+		d.mu.Unlock()
+		return nil
+	}
+	d.draining = draining
+	hooks := append([]func(context.Context, bool) error(nil), d.hooks...)
+	d.mu.Unlock()
+
+	if draining {
+		drainingMetric.Set(1)
+	} else {
+		drainingMetric.Set(0)
+	}
+	slog.WarnContext(ctx, "Drain state changed", "draining", draining)
+
+	var first error
+	for _, hook := range hooks {
+		if err := hook(ctx, draining); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Handler serves the drain switch: GET returns {"draining": false}, POST drains and
+// DELETE resumes
+func (d *Drainer) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			err = d.Set(r.Context(), true)
+		case http.MethodDelete:
+			err = d.Set(r.Context(), false)
+		default:
+			w.Header().Set("Allow", "GET, POST, DELETE")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		resp := map[string]any{"draining": d.Draining()}
+		if err != nil {
+			resp["error"] = err.Error()
+			writeJSON(w, http.StatusInternalServerError, resp)
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	})
+}
+
+// HealthHandler answers health checks: 200 "ok", or 503 "draining" while draining
+func (d *Drainer) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if d.Draining() {
+			http.Error(w, "draining", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+}
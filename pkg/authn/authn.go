@@ -0,0 +1,175 @@
+package authn
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	grpcpkg "github.com/LucasPluta/GoMicroserviceFramework/pkg/grpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ErrMissingToken is returned when a protected method is called without a bearer token
+var ErrMissingToken = errors.New("missing bearer token")
+
+// Config holds token validation settings
+type Config struct {
+	Issuer        string        // Required iss claim (empty skips the check)
+	Audience      string        // Required entry in the aud claim (empty skips the check)
+	ClockSkew     time.Duration // Leeway for exp and nbf checks
+	PublicMethods []string      // Method patterns callable without a token, e.g. "/grpc.health.v1.Health/*"
+}
+
+// Authenticator validates bearer JWTs against a key set
+type Authenticator struct {
+	keys *KeySet
+	cfg  Config
+	now  func() time.Time
+}
+
+// NewAuthenticator creates an authenticator using the given key set
+func NewAuthenticator(keys *KeySet, cfg Config) *Authenticator {
+	return &Authenticator{
+		keys: keys,
+		cfg:  cfg,
+		now:  time.Now,
+	}
+}
+
+// Verify validates a raw JWT and returns its claims
+func (a *Authenticator) Verify(ctx context.Context, token string) (*Claims, error) {
+	return a.verifyToken(ctx, token)
+}
+
+// IsPublic reports whether a fully-qualified method may be called without a token
+func (a *Authenticator) IsPublic(method string) bool {
+	for _, pattern := range a.cfg.PublicMethods {
+		if pattern == "*" {
+			return true
+		}
+		if ok, err := path.Match(pattern, method); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// authenticate verifies the bearer token of a call to method and returns a context carrying its claims
+func (a *Authenticator) authenticate(ctx context.Context, method, authorization string) (context.Context, error) {
+	public := a.IsPublic(method)
+
+	token, ok := bearerToken(authorization)
+	if !ok {
+		if public {
+			return ctx, nil
+		}
+		return nil, ErrMissingToken
+	}
+
+	claims, err := a.verifyToken(ctx, token)
+	if err != nil {
+		if public {
+			// An unusable token on a public method is treated as anonymous
+			return ctx, nil
+		}
+		slog.WarnContext(ctx, "Rejected token", "method", method, "error", err)
+		return nil, err
+	}
+
+	return NewContext(ctx, claims), nil
+}
+
+// UnaryServerInterceptor authenticates unary calls from the authorization metadata
+func (a *Authenticator) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := a.authenticate(ctx, info.FullMethod, authorizationFromMetadata(ctx))
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor authenticates streaming calls from the authorization metadata
+func (a *Authenticator) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.authenticate(ss.Context(), info.FullMethod, authorizationFromMetadata(ss.Context()))
+		if err != nil {
+			return status.Error(codes.Unauthenticated, err.Error())
+		}
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// ServerOptions returns the interceptors as server options for grpc.NewServer
+func (a *Authenticator) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(a.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(a.StreamServerInterceptor()),
+	}
+}
+
+// Middleware authenticates Connect requests from the Authorization header.
+// Connect procedures are served at their fully-qualified method path.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, err := a.authenticate(r.Context(), r.URL.Path, r.Header.Get("Authorization"))
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			grpcpkg.WriteError(w, r, status.Error(codes.Unauthenticated, err.Error()))
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func authorizationFromMetadata(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func bearerToken(authorization string) (string, bool) {
+	scheme, token, ok := strings.Cut(authorization, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+type claimsKey struct{}
+
+// NewContext returns a context carrying verified claims
+func NewContext(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// FromContext returns the verified claims of the caller, if authenticated
+func FromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*Claims)
+	return claims, ok && claims != nil
+}
+
+// contextStream overrides the context of a server stream
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,365 @@
+package authn
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRefreshInterval = time.Hour
+	// The set is fetched at most this often, whether it is stale or a token has an
+	// unknown kid, so a flood of tokens with bogus kids can't hammer the identity provider
+	minRefetchInterval = 30 * time.Second
+	// fetchTimeout bounds a fetch, which runs apart from the requests waiting for it
+	fetchTimeout = 10 * time.Second
+)
+
+// ErrUnknownKey is returned when no key in the set matches a token's key ID
+var ErrUnknownKey = errors.New("no matching signing key")
+
+// KeySet is a cached JSON Web Key Set loaded from a URL or a local file
+type KeySet struct {
+	load            func(ctx context.Context) ([]byte, error)
+	source          string
+	refreshInterval time.Duration
+
+	mu          sync.RWMutex
+	keys        map[string]signingKey
+	fetchedAt   time.Time
+	lastAttempt time.Time
+	fetching    chan struct{} // Closed when the fetch in flight ends; nil if none
+}
+
+// NewKeySetFromURL creates a key set fetched over HTTP and refreshed every refreshInterval
+// (default: 1h) or earlier when a token references an unknown key ID
+func NewKeySetFromURL(ctx context.Context, url string, refreshInterval time.Duration) (*KeySet, error) {
+	client := &http.Client{Timeout: fetchTimeout}
+	ks := &KeySet{
+		source:          url,
+		refreshInterval: refreshInterval,
+		load: func(ctx context.Context) ([]byte, error) {
+			return httpGet(ctx, client, url)
+		},
+	}
+	if err := ks.refresh(ctx); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// NewKeySetFromIssuer discovers the JWKS URL from the issuer's OpenID configuration
+func NewKeySetFromIssuer(ctx context.Context, issuer string, refreshInterval time.Duration) (*KeySet, error) {
+	client := &http.Client{Timeout: fetchTimeout}
+	data, err := httpGet(ctx, client, strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration")
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover OpenID configuration: %w", err)
+	}
+
+	var discovery struct {
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := json.Unmarshal(data, &discovery); err != nil || discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("OpenID configuration of %s has no jwks_uri", issuer)
+	}
+
+	return NewKeySetFromURL(ctx, discovery.JWKSURI, refreshInterval)
+}
+
+// NewKeySetFromFile creates a key set read from a local JWKS file, re-read on refresh.
+// This is enough for tests and offline development.
+func NewKeySetFromFile(path string) (*KeySet, error) {
+	ks := &KeySet{
+		source:          path,
+		refreshInterval: defaultRefreshInterval,
+		load: func(context.Context) ([]byte, error) {
+			return os.ReadFile(path)
+		},
+	}
+	if err := ks.refresh(context.Background()); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// Key returns the public key with the given key ID. A stale set is refreshed in the
+// background while the cached keys keep being served; an unknown key ID (for example after
+// a key rotation) waits for a refetch. Fetches are rate limited and shared by all callers.
+func (ks *KeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	key, err := ks.signingKey(ctx, kid)
+	if err != nil {
+		return nil, err
+	}
+	return key.key, nil
+}
+
+// signingKey returns the key with the given key ID and the algorithms it may verify, as Key does
+func (ks *KeySet) signingKey(ctx context.Context, kid string) (signingKey, error) {
+	ks.mu.RLock()
+	key, ok := ks.lookup(kid)
+	stale := time.Since(ks.fetchedAt) > ks.interval()
+	ks.mu.RUnlock()
+
+	if ok {
+		if stale {
+			ks.startFetch()
+		}
+		return key, nil
+	}
+
+	if done := ks.startFetch(); done != nil {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return signingKey{}, ctx.Err()
+		}
+		ks.mu.RLock()
+		key, ok = ks.lookup(kid)
+		ks.mu.RUnlock()
+	}
+
+	if !ok {
+		return signingKey{}, fmt.Errorf("%w: kid %q", ErrUnknownKey, kid)
+	}
+	return key, nil
+}
+
+// startFetch refreshes the set in the background and returns a channel closed when the
+// fetch ends. It joins the fetch in flight, if any, and returns nil if the last attempt
+// was too recent to fetch again.
+func (ks *KeySet) startFetch() <-chan struct{} {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if ks.fetching != nil {
+		return ks.fetching
+	}
+	if time.Since(ks.lastAttempt) < minRefetchInterval {
+		return nil
+	}
+
+	done := make(chan struct{})
+	ks.fetching = done
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+		defer cancel()
+		if err := ks.refresh(ctx); err != nil {
+			// Keep serving the cached keys if the provider is temporarily unreachable
+			log.Printf("Failed to refresh JWKS from %s: %v", ks.source, err)
+		}
+
+		ks.mu.Lock()
+		ks.fetching = nil
+		ks.mu.Unlock()
+		close(done)
+	}()
+	return done
+}
+
+// lookup finds a key by ID; tokens without a kid match a single-key set. Callers hold mu.
+func (ks *KeySet) lookup(kid string) (signingKey, bool) {
+	if kid == "" && len(ks.keys) == 1 {
+		for _, key := range ks.keys {
+			return key, true
+		}
+	}
+	key, ok := ks.keys[kid]
+	return key, ok
+}
+
+func (ks *KeySet) interval() time.Duration {
+	if ks.refreshInterval > 0 {
+		return ks.refreshInterval
+	}
+	return defaultRefreshInterval
+}
+
+func (ks *KeySet) refresh(ctx context.Context) error {
+	ks.mu.Lock()
+	ks.lastAttempt = time.Now()
+	ks.mu.Unlock()
+
+	data, err := ks.load(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load JWKS from %s: %w", ks.source, err)
+	}
+
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse JWKS from %s: %w", ks.source, err)
+	}
+
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.fetchedAt = time.Now()
+	ks.mu.Unlock()
+	return nil
+}
+
+// signingKey is a public key with the algorithms it may verify, so a token can't pick an
+// algorithm its key was not meant for
+type signingKey struct {
+	key  crypto.PublicKey
+	algs []string
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func parseJWKS(data []byte) (map[string]signingKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]signingKey{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err == nil {
+			var algs []string
+			if algs, err = k.algorithms(); err == nil {
+				keys[k.Kid] = signingKey{key: key, algs: algs}
+			}
+		}
+		if err != nil {
+			// Skip key types we don't support rather than rejecting the whole set
+			log.Printf("Skipping JWK %q: %v", k.Kid, err)
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no usable signing keys")
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("point is not on curve %s", k.Crv)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// algorithms returns the algorithms a key may verify: those of its type and curve, narrowed
+// to its alg member when present. RSA keys without alg verify only RS256/384/512: PSS
+// signatures need a key that declares a PS algorithm.
+func (k jwk) algorithms() ([]string, error) {
+	var algs []string
+	switch k.Kty {
+	case "RSA":
+		algs = []string{"RS256", "RS384", "RS512"}
+		if strings.HasPrefix(k.Alg, "PS") {
+			algs = []string{"PS256", "PS384", "PS512"}
+		}
+	case "EC":
+		algs = []string{map[string]string{"P-256": "ES256", "P-384": "ES384", "P-521": "ES512"}[k.Crv]}
+	case "OKP":
+		algs = []string{"EdDSA"}
+	}
+	if k.Alg == "" {
+		return algs, nil
+	}
+	if !slices.Contains(algs, k.Alg) {
+		return nil, fmt.Errorf("algorithm %q does not fit the %s key", k.Alg, k.Kty)
+	}
+	return []string{k.Alg}, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64url value: %w", err)
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func httpGet(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
@@ -0,0 +1,153 @@
+package authn
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// writeJWKS writes a JWKS file with a new Ed25519 key for each kid
+func writeJWKS(t *testing.T, path string, kids ...string) {
+	t.Helper()
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	for _, kid := range kids {
+		pub, _, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatalf("GenerateKey: %v", err)
+		}
+		set.Keys = append(set.Keys, jwk{Kty: "OKP", Crv: "Ed25519", Kid: kid, X: base64.RawURLEncoding.EncodeToString(pub)})
+	}
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+}
+
+// newFileKeySet returns a key set read from a JWKS file with the given kids whose last
+// fetch is old enough to fetch again, and a counter of its loads
+func newFileKeySet(t *testing.T, kids ...string) (*KeySet, string, *atomic.Int32) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, kids...)
+	ks, err := NewKeySetFromFile(path)
+	if err != nil {
+		t.Fatalf("NewKeySetFromFile: %v", err)
+	}
+
+	var loads atomic.Int32
+	load := ks.load
+	ks.load = func(ctx context.Context) ([]byte, error) {
+		loads.Add(1)
+		return load(ctx)
+	}
+	ks.lastAttempt = time.Now().Add(-minRefetchInterval)
+	return ks, path, &loads
+}
+
+func TestKeySetUnknownKidRefetchesOnce(t *testing.T) {
+	ks, path, loads := newFileKeySet(t, "k1")
+	writeJWKS(t, path, "k1", "k2") // Rotation
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := ks.Key(context.Background(), "k2"); err != nil {
+				t.Errorf("Key(k2): %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := loads.Load(); n != 1 {
+		t.Errorf("loaded the set %d times, want 1", n)
+	}
+
+	// Bogus kids don't refetch again within minRefetchInterval
+	for i := 0; i < 5; i++ {
+		if _, err := ks.Key(context.Background(), "bogus"); !errors.Is(err, ErrUnknownKey) {
+			t.Errorf("Key(bogus) = %v, want ErrUnknownKey", err)
+		}
+	}
+	if n := loads.Load(); n != 1 {
+		t.Errorf("loaded the set %d times, want 1", n)
+	}
+}
+
+func TestKeySetStaleRefreshesInBackground(t *testing.T) {
+	ks, _, loads := newFileKeySet(t, "k1")
+	ks.fetchedAt = time.Now().Add(-2 * ks.interval())
+
+	release := make(chan struct{})
+	load := ks.load
+	ks.load = func(ctx context.Context) ([]byte, error) {
+		<-release
+		return load(ctx)
+	}
+
+	// Cached keys are served while the refresh blocks, and the refresh is shared
+	for i := 0; i < 10; i++ {
+		if _, err := ks.Key(context.Background(), "k1"); err != nil {
+			t.Fatalf("Key(k1): %v", err)
+		}
+	}
+	close(release)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		ks.mu.RLock()
+		fresh := time.Since(ks.fetchedAt) < ks.interval()
+		ks.mu.RUnlock()
+		if fresh {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("stale key set was not refreshed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := loads.Load(); n != 1 {
+		t.Errorf("loaded the set %d times, want 1", n)
+	}
+
+	// A set that is stale again is not refetched within minRefetchInterval
+	ks.fetchedAt = time.Now().Add(-2 * ks.interval())
+	for i := 0; i < 5; i++ {
+		if _, err := ks.Key(context.Background(), "k1"); err != nil {
+			t.Fatalf("Key(k1): %v", err)
+		}
+	}
+	if n := loads.Load(); n != 1 {
+		t.Errorf("loaded the set %d times within minRefetchInterval, want 1", n)
+	}
+}
+
+func TestKeySetUnknownKidHonorsContext(t *testing.T) {
+	ks, _, _ := newFileKeySet(t, "k1")
+	release := make(chan struct{})
+	defer close(release)
+	ks.load = func(ctx context.Context) ([]byte, error) {
+		<-release
+		return nil, errors.New("unreachable")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := ks.Key(ctx, "k2"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Key(k2) = %v, want DeadlineExceeded", err)
+	}
+}
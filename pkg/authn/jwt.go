@@ -0,0 +1,224 @@
+package authn
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Claims are the typed claims of a verified token
+type Claims struct {
+	Issuer    string
+	Subject   string
+	Audience  []string
+	ExpiresAt time.Time
+	NotBefore time.Time
+	IssuedAt  time.Time
+	Email     string
+	Scopes    []string
+	Roles     []string
+	Tenant    string
+	Raw       map[string]interface{} // All claims, for provider-specific fields
+}
+
+type rawClaims struct {
+	Issuer    string          `json:"iss"`
+	Subject   string          `json:"sub"`
+	Audience  json.RawMessage `json:"aud"`
+	ExpiresAt *float64        `json:"exp"`
+	NotBefore *float64        `json:"nbf"`
+	IssuedAt  *float64        `json:"iat"`
+	Email     string          `json:"email"`
+	Scope     string          `json:"scope"`
+	Roles     []string        `json:"roles"`
+	Tenant    string          `json:"tenant"`
+}
+
+var (
+	ErrMalformedToken   = errors.New("malformed token")
+	ErrInvalidSignature = errors.New("invalid token signature")
+	ErrTokenExpired     = errors.New("token expired")
+	ErrTokenNotYetValid = errors.New("token not yet valid")
+	ErrInvalidIssuer    = errors.New("invalid token issuer")
+	ErrInvalidAudience  = errors.New("invalid token audience")
+)
+
+// verifyToken checks the signature of a compact JWS and validates its registered claims
+func (a *Authenticator) verifyToken(ctx context.Context, token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformedToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	key, err := a.keys.signingKey(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(key.algs, header.Alg) {
+		return nil, fmt.Errorf("%w: algorithm %q is not allowed for key %q", ErrInvalidSignature, header.Alg, header.Kid)
+	}
+	if err := verifySignature(header.Alg, key.key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, err
+	}
+
+	var raw rawClaims
+	if err := decodeSegment(parts[1], &raw); err != nil {
+		return nil, ErrMalformedToken
+	}
+	var all map[string]interface{}
+	if err := decodeSegment(parts[1], &all); err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	claims := &Claims{
+		Issuer:  raw.Issuer,
+		Subject: raw.Subject,
+		Email:   raw.Email,
+		Roles:   raw.Roles,
+		Tenant:  raw.Tenant,
+		Raw:     all,
+	}
+	if raw.Scope != "" {
+		claims.Scopes = strings.Fields(raw.Scope)
+	}
+	if claims.Audience, err = parseAudience(raw.Audience); err != nil {
+		return nil, ErrMalformedToken
+	}
+	claims.ExpiresAt = unixTime(raw.ExpiresAt)
+	claims.NotBefore = unixTime(raw.NotBefore)
+	claims.IssuedAt = unixTime(raw.IssuedAt)
+
+	if err := a.validateClaims(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (a *Authenticator) validateClaims(c *Claims) error {
+	now := a.now()
+	skew := a.cfg.ClockSkew
+
+	if c.ExpiresAt.IsZero() {
+		return fmt.Errorf("%w: missing exp", ErrMalformedToken)
+	}
+	if now.After(c.ExpiresAt.Add(skew)) {
+		return ErrTokenExpired
+	}
+	if !c.NotBefore.IsZero() && now.Add(skew).Before(c.NotBefore) {
+		return ErrTokenNotYetValid
+	}
+	if a.cfg.Issuer != "" && c.Issuer != a.cfg.Issuer {
+		return ErrInvalidIssuer
+	}
+	if a.cfg.Audience != "" && !slices.Contains(c.Audience, a.cfg.Audience) {
+		return ErrInvalidAudience
+	}
+	return nil
+}
+
+func verifySignature(alg string, key crypto.PublicKey, signed, signature []byte) error {
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "PS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "PS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512", "PS512", "ES512":
+		hash = crypto.SHA512
+	case "EdDSA":
+		edKey, ok := key.(ed25519.PublicKey)
+		if !ok || !ed25519.Verify(edKey, signed, signature) {
+			return ErrInvalidSignature
+		}
+		return nil
+	default:
+		// Rejects "none" and HMAC algorithms, which must never be accepted with public keys
+		return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidSignature, alg)
+	}
+
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch alg[:2] {
+	case "RS":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok || rsa.VerifyPKCS1v15(rsaKey, hash, digest, signature) != nil {
+			return ErrInvalidSignature
+		}
+	case "PS":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok || rsa.VerifyPSS(rsaKey, hash, digest, signature, nil) != nil {
+			return ErrInvalidSignature
+		}
+	case "ES":
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return ErrInvalidSignature
+		}
+		size := (ecKey.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return ErrInvalidSignature
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(ecKey, digest, r, s) {
+			return ErrInvalidSignature
+		}
+	}
+	return nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// parseAudience accepts both the single-string and array forms of aud
+func parseAudience(raw json.RawMessage) ([]string, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		return []string{single}, nil
+	}
+	var many []string
+	if err := json.Unmarshal(raw, &many); err != nil {
+		return nil, err
+	}
+	return many, nil
+}
+
+func unixTime(v *float64) time.Time {
+	if v == nil {
+		return time.Time{}
+	}
+	return time.Unix(int64(*v), 0)
+}
@@ -0,0 +1,234 @@
+package authn
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testKeys are the private keys behind the JWKS file written by newTestAuthenticator
+type testKeys struct {
+	rsa     *rsa.PrivateKey // "rsa": no alg, so RS* only
+	pss     *rsa.PrivateKey // "pss": alg PS256
+	ec      *ecdsa.PrivateKey
+	ed      ed25519.PrivateKey
+	hmacKey []byte // The DER of the RSA public key, as an attacker would use it
+}
+
+var testNow = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestAuthenticator(t *testing.T) (*Authenticator, *testKeys) {
+	t.Helper()
+	keys := &testKeys{}
+	var err error
+	if keys.rsa, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	if keys.pss, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	if keys.ec, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	var edPub ed25519.PublicKey
+	if edPub, keys.ed, err = ed25519.GenerateKey(rand.Reader); err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	if keys.hmacKey, err = x509.MarshalPKIXPublicKey(&keys.rsa.PublicKey); err != nil {
+		t.Fatalf("MarshalPKIXPublicKey: %v", err)
+	}
+
+	b64 := base64.RawURLEncoding.EncodeToString
+	rsaJWK := func(kid, alg string, key *rsa.PublicKey) jwk {
+		return jwk{Kty: "RSA", Kid: kid, Alg: alg, N: b64(key.N.Bytes()), E: b64(big.NewInt(int64(key.E)).Bytes())}
+	}
+	set := struct {
+		Keys []jwk `json:"keys"`
+	}{Keys: []jwk{
+		rsaJWK("rsa", "", &keys.rsa.PublicKey),
+		rsaJWK("pss", "PS256", &keys.pss.PublicKey),
+		{Kty: "EC", Kid: "ec", Crv: "P-256", X: b64(keys.ec.X.FillBytes(make([]byte, 32))), Y: b64(keys.ec.Y.FillBytes(make([]byte, 32)))},
+		{Kty: "OKP", Kid: "ed", Crv: "Ed25519", X: b64(edPub)},
+	}}
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	ks, err := NewKeySetFromFile(path)
+	if err != nil {
+		t.Fatalf("NewKeySetFromFile: %v", err)
+	}
+
+	a := NewAuthenticator(ks, Config{Issuer: "https://issuer.test", Audience: "api", ClockSkew: time.Minute})
+	a.now = func() time.Time { return testNow }
+	return a, keys
+}
+
+// sign builds a compact JWS; key is the private key for alg, or the secret for HS256
+func sign(t *testing.T, alg, kid string, key any, claims map[string]any) string {
+	t.Helper()
+	header, err := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	switch alg {
+	case "none":
+	case "HS256":
+		mac := hmac.New(sha256.New, key.([]byte))
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	case "RS256":
+		signature, err = rsa.SignPKCS1v15(rand.Reader, key.(*rsa.PrivateKey), crypto.SHA256, digest[:])
+	case "PS256":
+		signature, err = rsa.SignPSS(rand.Reader, key.(*rsa.PrivateKey), crypto.SHA256, digest[:], nil)
+	case "ES256":
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, key.(*ecdsa.PrivateKey), digest[:])
+		if err == nil {
+			signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+		}
+	case "EdDSA":
+		signature = ed25519.Sign(key.(ed25519.PrivateKey), []byte(signed))
+	default:
+		t.Fatalf("sign: unsupported algorithm %s", alg)
+	}
+	if err != nil {
+		t.Fatalf("sign %s: %v", alg, err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// claims returns valid claims with the given overrides; a nil value removes a claim
+func claims(overrides map[string]any) map[string]any {
+	c := map[string]any{
+		"iss": "https://issuer.test",
+		"sub": "user-1",
+		"aud": "api",
+		"exp": testNow.Add(time.Hour).Unix(),
+		"iat": testNow.Unix(),
+	}
+	for k, v := range overrides {
+		if v == nil {
+			delete(c, k)
+		} else {
+			c[k] = v
+		}
+	}
+	return c
+}
+
+func TestVerifyToken(t *testing.T) {
+	a, keys := newTestAuthenticator(t)
+	at := func(d time.Duration) int64 { return testNow.Add(d).Unix() }
+
+	tests := []struct {
+		name    string
+		alg     string
+		kid     string
+		key     any
+		claims  map[string]any
+		tamper  bool
+		wantErr error
+	}{
+		{name: "RS256", alg: "RS256", kid: "rsa", key: keys.rsa, claims: claims(nil)},
+		{name: "PS256 with a PS256 key", alg: "PS256", kid: "pss", key: keys.pss, claims: claims(nil)},
+		{name: "ES256", alg: "ES256", kid: "ec", key: keys.ec, claims: claims(nil)},
+		{name: "EdDSA", alg: "EdDSA", kid: "ed", key: keys.ed, claims: claims(nil)},
+		{name: "audience array", alg: "EdDSA", kid: "ed", key: keys.ed, claims: claims(map[string]any{"aud": []string{"other", "api"}})},
+
+		{name: "expired within skew", alg: "EdDSA", kid: "ed", key: keys.ed, claims: claims(map[string]any{"exp": at(-30 * time.Second)})},
+		{name: "expired beyond skew", alg: "EdDSA", kid: "ed", key: keys.ed, claims: claims(map[string]any{"exp": at(-2 * time.Minute)}), wantErr: ErrTokenExpired},
+		{name: "not yet valid within skew", alg: "EdDSA", kid: "ed", key: keys.ed, claims: claims(map[string]any{"nbf": at(30 * time.Second)})},
+		{name: "not yet valid beyond skew", alg: "EdDSA", kid: "ed", key: keys.ed, claims: claims(map[string]any{"nbf": at(2 * time.Minute)}), wantErr: ErrTokenNotYetValid},
+		{name: "missing exp", alg: "EdDSA", kid: "ed", key: keys.ed, claims: claims(map[string]any{"exp": nil}), wantErr: ErrMalformedToken},
+		{name: "wrong issuer", alg: "EdDSA", kid: "ed", key: keys.ed, claims: claims(map[string]any{"iss": "https://evil.test"}), wantErr: ErrInvalidIssuer},
+		{name: "wrong audience", alg: "EdDSA", kid: "ed", key: keys.ed, claims: claims(map[string]any{"aud": "other"}), wantErr: ErrInvalidAudience},
+
+		{name: "alg none", alg: "none", kid: "rsa", claims: claims(nil), wantErr: ErrInvalidSignature},
+		{name: "HS256 keyed with the public key", alg: "HS256", kid: "rsa", key: keys.hmacKey, claims: claims(nil), wantErr: ErrInvalidSignature},
+		{name: "bad signature", alg: "RS256", kid: "rsa", key: keys.rsa, claims: claims(nil), tamper: true, wantErr: ErrInvalidSignature},
+		{name: "signed by another key", alg: "RS256", kid: "rsa", key: keys.pss, claims: claims(nil), wantErr: ErrInvalidSignature},
+		{name: "PS256 with an RSA key without alg", alg: "PS256", kid: "rsa", key: keys.rsa, claims: claims(nil), wantErr: ErrInvalidSignature},
+		{name: "RS256 with a PS256 key", alg: "RS256", kid: "pss", key: keys.pss, claims: claims(nil), wantErr: ErrInvalidSignature},
+		{name: "EdDSA header with an EC key", alg: "EdDSA", kid: "ec", key: keys.ed, claims: claims(nil), wantErr: ErrInvalidSignature},
+		{name: "unknown kid", alg: "EdDSA", kid: "nope", key: keys.ed, claims: claims(nil), wantErr: ErrUnknownKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := sign(t, tt.alg, tt.kid, tt.key, tt.claims)
+			if tt.tamper {
+				// Swap the payload for one granting more, keeping the signature
+				forged := sign(t, tt.alg, tt.kid, tt.key, claims(map[string]any{"sub": "admin"}))
+				token = forged[:strings.LastIndex(forged, ".")] + token[strings.LastIndex(token, "."):]
+			}
+			got, err := a.Verify(context.Background(), token)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("Verify: %v", err)
+				}
+				if got.Subject != "user-1" {
+					t.Errorf("Subject = %q, want user-1", got.Subject)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJWKAlgorithms(t *testing.T) {
+	tests := []struct {
+		key     jwk
+		want    []string
+		wantErr bool
+	}{
+		{key: jwk{Kty: "RSA"}, want: []string{"RS256", "RS384", "RS512"}},
+		{key: jwk{Kty: "RSA", Alg: "RS384"}, want: []string{"RS384"}},
+		{key: jwk{Kty: "RSA", Alg: "PS512"}, want: []string{"PS512"}},
+		{key: jwk{Kty: "RSA", Alg: "ES256"}, wantErr: true},
+		{key: jwk{Kty: "EC", Crv: "P-384"}, want: []string{"ES384"}},
+		{key: jwk{Kty: "EC", Crv: "P-256", Alg: "ES512"}, wantErr: true},
+		{key: jwk{Kty: "OKP", Crv: "Ed25519", Alg: "EdDSA"}, want: []string{"EdDSA"}},
+	}
+	for _, tt := range tests {
+		got, err := tt.key.algorithms()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("algorithms(%+v) error = %v, wantErr %v", tt.key, err, tt.wantErr)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("algorithms(%+v) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package authz
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/authn"
+	grpcpkg "github.com/LucasPluta/GoMicroserviceFramework/pkg/grpc"
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/authz/authzpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Config holds the role model and the exemptions from deny-by-default
+type Config struct {
+	// Roles maps a role to the permissions it grants; permissions may be
+	// patterns such as "users.*" or "*"
+	Roles map[string][]string `json:"roles"`
+
+	// Unannotated lists method patterns allowed without an (authz.rule) option,
+	// such as "/grpc.reflection.v1.ServerReflection/*". Every other method
+	// without a rule is denied, as is any method without a registered descriptor.
+	Unannotated []string `json:"unannotated"`
+}
+
+// LoadConfig reads a JSON role configuration file
+func LoadConfig(file string) (Config, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read authorization config: %w", err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("failed to parse authorization config %s: %w", file, err)
+	}
+	return cfg, nil
+}
+
+const reasonUnauthenticated = "not authenticated"
+
+// Decision is the outcome of an authorization check
+type Decision struct {
+	Allowed bool
+	Reason  string
+}
+
+// Authorizer enforces the (authz.rule) method options against the caller's roles
+type Authorizer struct {
+	cfg   Config
+	rules sync.Map // full method -> *authzpb.MethodRule (nil when unannotated)
+}
+
+// NewAuthorizer creates an authorizer with the given role model
+func NewAuthorizer(cfg Config) *Authorizer {
+	return &Authorizer{cfg: cfg}
+}
+
+// Decide checks whether the caller in ctx may call the fully-qualified method and logs the decision
+func (a *Authorizer) Decide(ctx context.Context, method string) Decision {
+	claims, authenticated := authn.FromContext(ctx)
+	decision := a.decide(method, claims, authenticated)
+
+	subject, roles := "-", "-"
+	if authenticated {
+		subject = claims.Subject
+		roles = strings.Join(claims.Roles, ",")
+	}
+	verdict := "deny"
+	if decision.Allowed {
+		verdict = "allow"
+	}
+	slog.InfoContext(ctx, "Authorization decision", "decision", verdict, "method", method, "subject", subject, "roles", roles, "reason", decision.Reason)
+
+	return decision
+}
+
+func (a *Authorizer) decide(method string, claims *authn.Claims, authenticated bool) Decision {
+	rule, err := a.rule(method)
+	if err != nil {
+		return Decision{Reason: err.Error()}
+	}
+
+	if rule == nil {
+		if matchAny(a.cfg.Unannotated, method) {
+			return Decision{Allowed: true, Reason: "exempt from annotation"}
+		}
+		return Decision{Reason: "method has no authorization rule"}
+	}
+
+	if rule.Public {
+		return Decision{Allowed: true, Reason: "public method"}
+	}
+	if !authenticated {
+		return Decision{Reason: reasonUnauthenticated}
+	}
+
+	for _, permission := range rule.Permissions {
+		if !a.granted(claims.Roles, permission) {
+			return Decision{Reason: "missing permission " + permission}
+		}
+	}
+	return Decision{Allowed: true, Reason: "permissions granted"}
+}
+
+// Permitted reports whether the caller in ctx holds a permission, for checks finer than a
+// method, such as filtering the events streamed to a subscriber
+func (a *Authorizer) Permitted(ctx context.Context, permission string) bool {
+	claims, authenticated := authn.FromContext(ctx)
+	return authenticated && a.granted(claims.Roles, permission)
+}
+
+// granted reports whether any of the roles grants the permission
+func (a *Authorizer) granted(roles []string, permission string) bool {
+	for _, role := range roles {
+		if matchAny(a.cfg.Roles[role], permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// rule returns the (authz.rule) option of a method from the registered descriptors.
+// Only registered methods are cached: request paths are client-controlled, and any
+// other path is denied without being stored.
+func (a *Authorizer) rule(method string) (*authzpb.MethodRule, error) {
+	if cached, ok := a.rules.Load(method); ok {
+		return cached.(*authzpb.MethodRule), nil
+	}
+
+	service, name, ok := strings.Cut(strings.TrimPrefix(method, "/"), "/")
+	if !ok {
+		return nil, fmt.Errorf("malformed method %q", method)
+	}
+	desc, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil, fmt.Errorf("unknown method %q", method)
+	}
+	sd, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("unknown method %q", method)
+	}
+	md := sd.Methods().ByName(protoreflect.Name(name))
+	if md == nil {
+		return nil, fmt.Errorf("unknown method %q", method)
+	}
+
+	var rule *authzpb.MethodRule
+	if opts, ok := md.Options().(*descriptorpb.MethodOptions); ok && proto.HasExtension(opts, authzpb.E_Rule) {
+		rule = proto.GetExtension(opts, authzpb.E_Rule).(*authzpb.MethodRule)
+	}
+	a.rules.Store(method, rule)
+	return rule, nil
+}
+
+// PublicMethods lists the registered methods annotated as public, for use as
+// authn.Config.PublicMethods so they can be called without a token
+func PublicMethods() []string {
+	var methods []string
+	protoregistry.GlobalFiles.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		services := fd.Services()
+		for i := 0; i < services.Len(); i++ {
+			sd := services.Get(i)
+			for j := 0; j < sd.Methods().Len(); j++ {
+				md := sd.Methods().Get(j)
+				opts, ok := md.Options().(*descriptorpb.MethodOptions)
+				if !ok || !proto.HasExtension(opts, authzpb.E_Rule) {
+					continue
+				}
+				if proto.GetExtension(opts, authzpb.E_Rule).(*authzpb.MethodRule).GetPublic() {
+					methods = append(methods, fmt.Sprintf("/%s/%s", sd.FullName(), md.Name()))
+				}
+			}
+		}
+		return true
+	})
+	return methods
+}
+
+// UnaryServerInterceptor authorizes unary calls. It must run after authentication.
+func (a *Authorizer) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := a.check(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor authorizes streaming calls. It must run after authentication.
+func (a *Authorizer) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := a.check(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// ServerOptions returns the interceptors as server options for grpc.NewServer
+func (a *Authorizer) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(a.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(a.StreamServerInterceptor()),
+	}
+}
+
+// Middleware authorizes Connect requests. It must wrap the handler inside the authentication middleware.
+func (a *Authorizer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := a.check(r.Context(), r.URL.Path); err != nil {
+			grpcpkg.WriteError(w, r, err)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (a *Authorizer) check(ctx context.Context, method string) error {
+	decision := a.Decide(ctx, method)
+	if decision.Allowed {
+		return nil
+	}
+	if decision.Reason == reasonUnauthenticated {
+		return status.Error(codes.Unauthenticated, "authentication required")
+	}
+	return status.Errorf(codes.PermissionDenied, "permission denied: %s", decision.Reason)
+}
+
+func matchAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if pattern == "*" {
+			return true
+		}
+		if ok, err := path.Match(pattern, value); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
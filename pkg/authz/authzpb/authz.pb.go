@@ -0,0 +1,163 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        v6.31.1
+// source: pkg/authz/authzpb/authz.proto
+
+package authzpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	descriptorpb "google.golang.org/protobuf/types/descriptorpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// MethodRule declares who may call an RPC
+type MethodRule struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Callable without authentication
+	Public bool `protobuf:"varint,1,opt,name=public,proto3" json:"public,omitempty"`
+	// Permissions the caller's roles must grant (all of them)
+	Permissions   []string `protobuf:"bytes,2,rep,name=permissions,proto3" json:"permissions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MethodRule) Reset() {
+	*x = MethodRule{}
+	mi := &file_pkg_authz_authzpb_authz_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MethodRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MethodRule) ProtoMessage() {}
+
+func (x *MethodRule) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_authz_authzpb_authz_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MethodRule.ProtoReflect.Descriptor instead.
+func (*MethodRule) Descriptor() ([]byte, []int) {
+	return file_pkg_authz_authzpb_authz_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *MethodRule) GetPublic() bool {
+	if x != nil {
+		return x.Public
+	}
+	return false
+}
+
+func (x *MethodRule) GetPermissions() []string {
+	if x != nil {
+		return x.Permissions
+	}
+	return nil
+}
+
+var file_pkg_authz_authzpb_authz_proto_extTypes = []protoimpl.ExtensionInfo{
+	{
+		ExtendedType:  (*descriptorpb.MethodOptions)(nil),
+		ExtensionType: (*MethodRule)(nil),
+		Field:         50100,
+		Name:          "authz.rule",
+		Tag:           "bytes,50100,opt,name=rule",
+		Filename:      "pkg/authz/authzpb/authz.proto",
+	},
+}
+
+// Extension fields to descriptorpb.MethodOptions.
+var (
+	// Authorization rule for the method, e.g.
+	//   rpc GetUser(GetUserRequest) returns (User) {
+	//     option (authz.rule) = { permissions: "users.read" };
+	//   }
+	//
+	// optional authz.MethodRule rule = 50100;
+	E_Rule = &file_pkg_authz_authzpb_authz_proto_extTypes[0]
+)
+
+var File_pkg_authz_authzpb_authz_proto protoreflect.FileDescriptor
+
+const file_pkg_authz_authzpb_authz_proto_rawDesc = "" +
+	"\n" +
+	"\x1dpkg/authz/authzpb/authz.proto\x12\x05authz\x1a google/protobuf/descriptor.proto\"F\n" +
+	"\n" +
+	"MethodRule\x12\x16\n" +
+	"\x06public\x18\x01 \x01(\bR\x06public\x12 \n" +
+	"\vpermissions\x18\x02 \x03(\tR\vpermissions:G\n" +
+	"\x04rule\x12\x1e.google.protobuf.MethodOptions\x18\xb4\x87\x03 \x01(\v2\x11.authz.MethodRuleR\x04ruleBAZ?github.com/LucasPluta/GoMicroserviceFramework/pkg/authz/authzpbb\x06proto3"
+
+var (
+	file_pkg_authz_authzpb_authz_proto_rawDescOnce sync.Once
+	file_pkg_authz_authzpb_authz_proto_rawDescData []byte
+)
+
+func file_pkg_authz_authzpb_authz_proto_rawDescGZIP() []byte {
+	file_pkg_authz_authzpb_authz_proto_rawDescOnce.Do(func() {
+		file_pkg_authz_authzpb_authz_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pkg_authz_authzpb_authz_proto_rawDesc), len(file_pkg_authz_authzpb_authz_proto_rawDesc)))
+	})
+	return file_pkg_authz_authzpb_authz_proto_rawDescData
+}
+
+var file_pkg_authz_authzpb_authz_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_pkg_authz_authzpb_authz_proto_goTypes = []any{
+	(*MethodRule)(nil),                 // 0: authz.MethodRule
+	(*descriptorpb.MethodOptions)(nil), // 1: google.protobuf.MethodOptions
+}
+var file_pkg_authz_authzpb_authz_proto_depIdxs = []int32{
+	1, // 0: authz.rule:extendee -> google.protobuf.MethodOptions
+	0, // 1: authz.rule:type_name -> authz.MethodRule
+	2, // [2:2] is the sub-list for method output_type
+	2, // [2:2] is the sub-list for method input_type
+	1, // [1:2] is the sub-list for extension type_name
+	0, // [0:1] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_pkg_authz_authzpb_authz_proto_init() }
+func file_pkg_authz_authzpb_authz_proto_init() {
+	if File_pkg_authz_authzpb_authz_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pkg_authz_authzpb_authz_proto_rawDesc), len(file_pkg_authz_authzpb_authz_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 1,
+			NumServices:   0,
+		},
+		GoTypes:           file_pkg_authz_authzpb_authz_proto_goTypes,
+		DependencyIndexes: file_pkg_authz_authzpb_authz_proto_depIdxs,
+		MessageInfos:      file_pkg_authz_authzpb_authz_proto_msgTypes,
+		ExtensionInfos:    file_pkg_authz_authzpb_authz_proto_extTypes,
+	}.Build()
+	File_pkg_authz_authzpb_authz_proto = out.File
+	file_pkg_authz_authzpb_authz_proto_goTypes = nil
+	file_pkg_authz_authzpb_authz_proto_depIdxs = nil
+}
@@ -0,0 +1,217 @@
+package deadline
+
+import (
+	"context"
+	"errors"
+	"path"
+	"strconv"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/metrics"
+	natslib "github.com/nats-io/nats.go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// NATSHeader carries the remaining budget of a request, in milliseconds, on NATS messages.
+// A relative timeout is used rather than an absolute deadline to tolerate clock skew.
+const NATSHeader = "Timeout-Ms"
+
+var (
+	exceededMetric = metrics.Map("rpc_deadline_exceeded_total")
+	cappedMetric   = metrics.Map("rpc_deadline_capped_total")
+)
+
+// Limits are the deadline bounds of a method
+type Limits struct {
+	Default time.Duration // Applied when the client sent no deadline (0: none)
+	Max     time.Duration // Upper bound on the client's deadline (0: unbounded)
+}
+
+// Config holds the server-side deadline policy
+type Config struct {
+	Limits
+	// Methods overrides the limits per method; keys are full method names or
+	// patterns such as "/users.UserService/*". The longest matching key wins.
+	Methods map[string]Limits
+}
+
+func (c *Config) limits(method string) Limits {
+	if l, ok := c.Methods[method]; ok {
+		return l
+	}
+	best, found := "", false
+	for pattern := range c.Methods {
+		if ok, err := path.Match(pattern, method); err == nil && ok && len(pattern) > len(best) {
+			best, found = pattern, true
+		}
+	}
+	if found {
+		return c.Methods[best]
+	}
+	return c.Limits
+}
+
+// apply bounds the context of a call to method
+func (c *Config) apply(ctx context.Context, method string) (context.Context, context.CancelFunc) {
+	limits := c.limits(method)
+
+	var timeout time.Duration
+	if deadline, ok := ctx.Deadline(); !ok {
+		timeout = limits.Default
+		if limits.Max > 0 && (timeout == 0 || timeout > limits.Max) {
+			timeout = limits.Max
+		}
+	} else if limits.Max > 0 && time.Until(deadline) > limits.Max {
+		cappedMetric.Add(method, 1)
+		timeout = limits.Max
+	}
+
+	if timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// result maps the error of a call whose deadline passed to DeadlineExceeded, whatever
+// the handler returned (e.g. an Internal error from a failed send), and counts it
+func result(ctx context.Context, method string, err error) error {
+	if err == nil || !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	exceededMetric.Add(method, 1)
+	if status.Code(err) == codes.DeadlineExceeded {
+		return err
+	}
+	return status.Error(codes.DeadlineExceeded, "deadline exceeded")
+}
+
+// UnaryServerInterceptor applies the deadline policy to unary calls
+func UnaryServerInterceptor(cfg Config) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, cancel := cfg.apply(ctx, info.FullMethod)
+		defer cancel()
+
+		resp, err := handler(ctx, req)
+		return resp, result(ctx, info.FullMethod, err)
+	}
+}
+
+// StreamServerInterceptor applies the deadline policy to streaming calls
+func StreamServerInterceptor(cfg Config) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, cancel := cfg.apply(ss.Context(), info.FullMethod)
+		defer cancel()
+
+		err := handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+		return result(ctx, info.FullMethod, err)
+	}
+}
+
+// ServerOptions returns the interceptors as server options for grpc.NewServer.
+// They should run first so the other interceptors work within the deadline.
+func ServerOptions(cfg Config) []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(UnaryServerInterceptor(cfg)),
+		grpc.ChainStreamInterceptor(StreamServerInterceptor(cfg)),
+	}
+}
+
+// connectInterceptor applies the deadline policy to Connect handlers
+type connectInterceptor struct {
+	cfg Config
+}
+
+// ConnectInterceptor is the Connect handler counterpart of the server interceptors,
+// installed with connect.WithInterceptors
+func ConnectInterceptor(cfg Config) connect.Interceptor {
+	return &connectInterceptor{cfg: cfg}
+}
+
+func (i *connectInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if req.Spec().IsClient {
+			return next(ctx, req)
+		}
+		procedure := req.Spec().Procedure
+		ctx, cancel := i.cfg.apply(ctx, procedure)
+		defer cancel()
+
+		resp, err := next(ctx, req)
+		return resp, connectResult(ctx, procedure, err)
+	}
+}
+
+func (i *connectInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (i *connectInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		procedure := conn.Spec().Procedure
+		ctx, cancel := i.cfg.apply(ctx, procedure)
+		defer cancel()
+
+		return connectResult(ctx, procedure, next(ctx, conn))
+	}
+}
+
+func connectResult(ctx context.Context, procedure string, err error) error {
+	if err = result(ctx, procedure, err); status.Code(err) == codes.DeadlineExceeded {
+		return connect.NewError(connect.CodeDeadlineExceeded, errors.New("deadline exceeded"))
+	}
+	return err
+}
+
+// Remaining returns the time left before the deadline of ctx
+func Remaining(ctx context.Context) (time.Duration, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+	return time.Until(deadline), true
+}
+
+// NewNATSMsg creates a message carrying the remaining budget of ctx, so subscribers
+// can bound their work with FromNATS
+func NewNATSMsg(ctx context.Context, subject string, data []byte) *natslib.Msg {
+	msg := natslib.NewMsg(subject)
+	msg.Data = data
+	if remaining, ok := Remaining(ctx); ok {
+		msg.Header.Set(NATSHeader, strconv.FormatInt(max(remaining.Milliseconds(), 1), 10))
+	}
+	return msg
+}
+
+// FromNATS returns a context bounded by the budget carried in msg, if any
+func FromNATS(ctx context.Context, msg *natslib.Msg) (context.Context, context.CancelFunc) {
+	if ms, err := strconv.ParseInt(msg.Header.Get(NATSHeader), 10, 64); err == nil && ms > 0 {
+		return context.WithTimeout(ctx, time.Duration(ms)*time.Millisecond)
+	}
+	return context.WithCancel(ctx)
+}
+
+// Sleep waits for d or until ctx is done, returning the context's error in that case.
+// Handlers should use it instead of time.Sleep so cancelled calls stop promptly.
+func Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// contextStream overrides the context of a server stream
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,142 @@
+package errors
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"connectrpc.com/connect"
+	"github.com/lib/pq"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// domainError is a sentinel error of a service domain that maps to a code
+type domainError struct {
+	code codes.Code
+	text string
+}
+
+func (e *domainError) Error() string { return e.text }
+
+// Domain creates a sentinel error that maps to code. The service layer wraps it, keeping
+// gRPC out of it, and the message of the wrapping error is sent to the client:
+//
+//	var ErrOutOfStock = errors.Domain(codes.FailedPrecondition, "out of stock")
+//	return fmt.Errorf("item %s: %w", sku, ErrOutOfStock) // FailedPrecondition "item 42: out of stock"
+func Domain(code codes.Code, text string) error {
+	return &domainError{code: code, text: text}
+}
+
+// Common domain errors
+var (
+	ErrNotFound           = Domain(codes.NotFound, "not found")
+	ErrAlreadyExists      = Domain(codes.AlreadyExists, "already exists")
+	ErrInvalid            = Domain(codes.InvalidArgument, "invalid")
+	ErrFailedPrecondition = Domain(codes.FailedPrecondition, "failed precondition")
+	ErrConflict           = Domain(codes.Aborted, "conflict")
+	ErrPermissionDenied   = Domain(codes.PermissionDenied, "permission denied")
+	ErrUnauthenticated    = Domain(codes.Unauthenticated, "unauthenticated")
+	ErrUnavailable        = Domain(codes.Unavailable, "unavailable")
+)
+
+// postgresDomain is the ErrorInfo domain of the reasons of Postgres errors
+const postgresDomain = "postgresql"
+
+// Convert returns err as a status error with the right code. Status errors, *Error and
+// Connect errors keep their code and details; wrapped domain errors, sql.ErrNoRows,
+// Postgres errors and context errors are mapped; anything else is Internal, with a
+// generic message so that internals don't leak to clients.
+func Convert(err error) error {
+	if err == nil {
+		return nil
+	}
+	return Status(err).Err()
+}
+
+// Status returns the status Convert sends for err; nil is OK
+func Status(err error) *status.Status {
+	if err == nil {
+		return status.New(codes.OK, "")
+	}
+
+	var e *Error
+	if errors.As(err, &e) {
+		return e.GRPCStatus()
+	}
+	if st, ok := status.FromError(err); ok {
+		return st
+	}
+	var connectErr *connect.Error
+	if errors.As(err, &connectErr) {
+		return connectStatus(connectErr)
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err)
+	}
+	var domain *domainError
+	if errors.As(err, &domain) {
+		return status.New(domain.code, err.Error())
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return status.New(codes.NotFound, "not found")
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return postgresError(pqErr).GRPCStatus()
+	}
+	return status.New(codes.Internal, "internal error")
+}
+
+// connectStatus converts a Connect error, such as one returned by a Connect client, with
+// its details
+func connectStatus(connectErr *connect.Error) *status.Status {
+	e := status.New(codes.Code(connectErr.Code()), connectErr.Message()).Proto()
+	for _, detail := range connectErr.Details() {
+		e.Details = append(e.Details, &anypb.Any{TypeUrl: "type.googleapis.com/" + detail.Type(), Value: detail.Bytes()})
+	}
+	return status.FromProto(e)
+}
+
+// postgresError maps the SQLSTATE classes of Postgres errors. Values in the server's
+// message or detail may be sensitive (a duplicate email), so only the names of the
+// constraint, table and column reach the client.
+func postgresError(err *pq.Error) *Error {
+	metadata := map[string]string{}
+	for key, value := range map[string]string{"constraint": err.Constraint, "table": err.Table, "column": err.Column} {
+		if value != "" {
+			metadata[key] = value
+		}
+	}
+	reason := strings.ToUpper(err.Code.Name())
+
+	switch code := string(err.Code); {
+	case code == "23505": // unique_violation
+		return Wrap(err, codes.AlreadyExists, "already exists").WithReason(reason, postgresDomain, metadata)
+	case code == "23503": // foreign_key_violation
+		return Wrap(err, codes.FailedPrecondition, "referenced resource does not exist or is still referenced").
+			WithReason(reason, postgresDomain, metadata)
+	case code == "23502": // not_null_violation
+		e := Wrap(err, codes.InvalidArgument, err.Column+": must not be empty").WithReason(reason, postgresDomain, metadata)
+		return e.WithField(err.Column, "must not be empty")
+	case code == "23514": // check_violation
+		return Wrap(err, codes.InvalidArgument, "violates constraint "+err.Constraint).WithReason(reason, postgresDomain, metadata)
+	case strings.HasPrefix(code, "22"): // data_exception: values out of range, too long or malformed
+		e := Wrap(err, codes.InvalidArgument, "invalid value").WithReason(reason, postgresDomain, metadata)
+		if err.Column != "" {
+			e.WithField(err.Column, "invalid value")
+		}
+		return e
+	case code == "40001", code == "40P01": // serialization_failure, deadlock_detected
+		return Wrap(err, codes.Aborted, "transaction conflict, retry").WithReason(reason, postgresDomain, metadata)
+	case code == "57014": // query_canceled, by statement_timeout
+		return Wrap(err, codes.DeadlineExceeded, "query timed out").WithReason(reason, postgresDomain, metadata)
+	case strings.HasPrefix(code, "08"), strings.HasPrefix(code, "53"), strings.HasPrefix(code, "57P"):
+		// connection_exception, insufficient_resources, operator_intervention (shutdown)
+		return Wrap(err, codes.Unavailable, "database unavailable").WithReason(reason, postgresDomain, metadata)
+	default:
+		return Wrap(err, codes.Internal, "internal error")
+	}
+}
@@ -0,0 +1,133 @@
+package errors
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/lib/pq"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var errOutOfStock = Domain(codes.FailedPrecondition, "out of stock")
+
+func TestStatus(t *testing.T) {
+	connectErr := connect.NewError(connect.CodeResourceExhausted, errors.New("quota"))
+	if detail, err := connect.NewErrorDetail(&errdetails.ErrorInfo{Reason: "QUOTA", Domain: "billing"}); err == nil {
+		connectErr.AddDetail(detail)
+	}
+
+	tests := []struct {
+		name       string
+		err        error
+		wantCode   codes.Code
+		wantMsg    string
+		wantReason string // ErrorInfo reason, "" for none
+	}{
+		{"nil", nil, codes.OK, "", ""},
+		{"status", status.Error(codes.NotFound, "no user"), codes.NotFound, "no user", ""},
+		{"Error", New(codes.FailedPrecondition, "locked").WithReason("LOCKED", "test", nil), codes.FailedPrecondition, "locked", "LOCKED"},
+		{"wrapped Error", fmt.Errorf("handler: %w", New(codes.Aborted, "retry")), codes.Aborted, "retry", ""},
+		{"Connect error", fmt.Errorf("call: %w", connectErr), codes.ResourceExhausted, "quota", "QUOTA"},
+		{"domain", fmt.Errorf("item 42: %w", errOutOfStock), codes.FailedPrecondition, "item 42: out of stock", ""},
+		{"common domain", ErrNotFound, codes.NotFound, "not found", ""},
+		{"sql.ErrNoRows", fmt.Errorf("get user: %w", sql.ErrNoRows), codes.NotFound, "not found", ""},
+		{"context canceled", fmt.Errorf("query: %w", context.Canceled), codes.Canceled, "query: context canceled", ""},
+		{"deadline exceeded", fmt.Errorf("query: %w", context.DeadlineExceeded), codes.DeadlineExceeded, "query: context deadline exceeded", ""},
+		{"other", errors.New("dial tcp 10.0.0.3:5432: refused"), codes.Internal, "internal error", ""},
+	}
+	for _, tt := range tests {
+		st := Status(tt.err)
+		if st.Code() != tt.wantCode || st.Message() != tt.wantMsg {
+			t.Errorf("%s: Status = %s %q, want %s %q", tt.name, st.Code(), st.Message(), tt.wantCode, tt.wantMsg)
+		}
+		var reason string
+		if info := Info(tt.err); info != nil {
+			reason = info.Reason
+		}
+		if reason != tt.wantReason {
+			t.Errorf("%s: reason = %q, want %q", tt.name, reason, tt.wantReason)
+		}
+	}
+
+	if Convert(nil) != nil {
+		t.Error("Convert(nil) is not nil")
+	}
+}
+
+func TestPostgresErrors(t *testing.T) {
+	tests := []struct {
+		code         pq.ErrorCode
+		column       string
+		wantCode     codes.Code
+		wantReason   string // "" for no ErrorInfo
+		wantViolated string // Field of the BadRequest violation, "" for none
+	}{
+		{"23505", "", codes.AlreadyExists, "UNIQUE_VIOLATION", ""},
+		{"23503", "", codes.FailedPrecondition, "FOREIGN_KEY_VIOLATION", ""},
+		{"23502", "email", codes.InvalidArgument, "NOT_NULL_VIOLATION", "email"},
+		{"23514", "", codes.InvalidArgument, "CHECK_VIOLATION", ""},
+		{"22001", "name", codes.InvalidArgument, "STRING_DATA_RIGHT_TRUNCATION", "name"},
+		{"22P02", "", codes.InvalidArgument, "INVALID_TEXT_REPRESENTATION", ""},
+		{"40001", "", codes.Aborted, "SERIALIZATION_FAILURE", ""},
+		{"40P01", "", codes.Aborted, "DEADLOCK_DETECTED", ""},
+		{"57014", "", codes.DeadlineExceeded, "QUERY_CANCELED", ""},
+		{"08006", "", codes.Unavailable, "CONNECTION_FAILURE", ""},
+		{"53300", "", codes.Unavailable, "TOO_MANY_CONNECTIONS", ""},
+		{"57P01", "", codes.Unavailable, "ADMIN_SHUTDOWN", ""},
+		{"42P01", "", codes.Internal, "", ""},
+	}
+	for _, tt := range tests {
+		pqErr := &pq.Error{
+			Code:       tt.code,
+			Message:    "duplicate key value violates unique constraint",
+			Detail:     "Key (email)=(alice@example.com) already exists.",
+			Table:      "users",
+			Column:     tt.column,
+			Constraint: "users_email_key",
+		}
+		err := fmt.Errorf("insert user: %w", pqErr)
+		st := Status(err)
+		if st.Code() != tt.wantCode {
+			t.Errorf("%s: code = %s, want %s", tt.code, st.Code(), tt.wantCode)
+		}
+		if !errors.Is(postgresError(pqErr), pqErr) {
+			t.Errorf("%s: the Postgres error is not kept as the cause", tt.code)
+		}
+		for _, leaked := range []string{pqErr.Message, pqErr.Detail} {
+			if st.Message() == leaked || st.Message() == "" {
+				t.Errorf("%s: message %q leaks the server's message or is empty", tt.code, st.Message())
+			}
+		}
+
+		info := Info(err)
+		switch {
+		case tt.wantReason == "" && info != nil:
+			t.Errorf("%s: unexpected ErrorInfo %v", tt.code, info)
+		case tt.wantReason != "" && (info == nil || info.Reason != tt.wantReason || info.Domain != postgresDomain):
+			t.Errorf("%s: ErrorInfo = %v, want reason %s", tt.code, info, tt.wantReason)
+		case info != nil:
+			want := map[string]string{"constraint": "users_email_key", "table": "users"}
+			if tt.column != "" {
+				want["column"] = tt.column
+			}
+			if !maps.Equal(info.Metadata, want) {
+				t.Errorf("%s: metadata = %v, want %v", tt.code, info.Metadata, want)
+			}
+		}
+
+		var violated string
+		if fields := FieldViolations(err); len(fields) > 0 {
+			violated = fields[0].Field
+		}
+		if violated != tt.wantViolated {
+			t.Errorf("%s: violated field = %q, want %q", tt.code, violated, tt.wantViolated)
+		}
+	}
+}
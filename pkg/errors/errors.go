@@ -0,0 +1,219 @@
+// Package errors builds gRPC errors carrying google.rpc error details (field violations,
+// a machine-readable reason, a retry delay and localized messages) and maps the errors
+// services run into (domain errors, sql.ErrNoRows, Postgres constraint violations, context
+// errors) to the right codes.
+//
+// The details travel in the google.rpc.Status of the call, so gRPC clients read them from
+// grpc-status-details-bin and Connect and browser clients from the error's "details":
+//
+//	var v errors.Violations
+//	if req.Email == "" {
+//		v.Add("email", "must not be empty")
+//	}
+//	if err := v.Err(); err != nil {
+//		return nil, err
+//	}
+//	return nil, errors.New(codes.FailedPrecondition, "account is locked").
+//		WithReason("ACCOUNT_LOCKED", "user-service", map[string]string{"user_id": id})
+package errors
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Error is a gRPC error with details. The With methods add details and return the error,
+// so an error is built in one expression. It implements GRPCStatus, so it can be returned
+// from handlers as is.
+type Error struct {
+	code       codes.Code
+	message    string
+	cause      error
+	badRequest *errdetails.BadRequest
+	info       *errdetails.ErrorInfo
+	retry      *errdetails.RetryInfo
+	localized  []*errdetails.LocalizedMessage
+}
+
+// New creates an error with a code and a message for the client
+func New(code codes.Code, message string) *Error {
+	return &Error{code: code, message: message}
+}
+
+// Newf creates an error with a formatted message
+func Newf(code codes.Code, format string, args ...any) *Error {
+	return New(code, fmt.Sprintf(format, args...))
+}
+
+// Wrap creates an error for the client around its cause. The cause is kept for errors.Is
+// and logs but not sent to the client.
+func Wrap(cause error, code codes.Code, message string) *Error {
+	return &Error{code: code, message: message, cause: cause}
+}
+
+// InvalidArgument creates an InvalidArgument error for a single invalid field
+func InvalidArgument(field, description string) *Error {
+	return New(codes.InvalidArgument, field+": "+description).WithField(field, description)
+}
+
+// WithField adds a field violation (google.rpc.BadRequest). field is the path of the
+// field in the request, e.g. "user.emails[1]".
+func (e *Error) WithField(field, description string) *Error {
+	if e.badRequest == nil {
+		e.badRequest = &errdetails.BadRequest{}
+	}
+	e.badRequest.FieldViolations = append(e.badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+		Field:       field,
+		Description: description,
+	})
+	return e
+}
+
+// WithReason sets the machine-readable cause of the error (google.rpc.ErrorInfo): a
+// constant UPPER_SNAKE_CASE reason, the domain that defines it (usually the service name)
+// and metadata about the failure
+func (e *Error) WithReason(reason, domain string, metadata map[string]string) *Error {
+	e.info = &errdetails.ErrorInfo{Reason: reason, Domain: domain, Metadata: metadata}
+	return e
+}
+
+// WithRetryDelay tells clients how long to wait before retrying (google.rpc.RetryInfo)
+func (e *Error) WithRetryDelay(delay time.Duration) *Error {
+	e.retry = &errdetails.RetryInfo{RetryDelay: durationpb.New(delay)}
+	return e
+}
+
+// WithLocalizedMessage adds a message for end users in a BCP-47 locale such as "en-US"
+// (google.rpc.LocalizedMessage)
+func (e *Error) WithLocalizedMessage(locale, message string) *Error {
+	e.localized = append(e.localized, &errdetails.LocalizedMessage{Locale: locale, Message: message})
+	return e
+}
+
+// Error returns the message and the cause, if any
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return e.message + ": " + e.cause.Error()
+	}
+	return e.message
+}
+
+// Unwrap returns the cause
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Code returns the gRPC code
+func (e *Error) Code() codes.Code {
+	return e.code
+}
+
+// GRPCStatus returns the status sent to the client, with the details
+func (e *Error) GRPCStatus() *status.Status {
+	st := &spb.Status{Code: int32(e.code), Message: e.message}
+	var details []proto.Message
+	if e.badRequest != nil {
+		details = append(details, e.badRequest)
+	}
+	if e.info != nil {
+		details = append(details, e.info)
+	}
+	if e.retry != nil {
+		details = append(details, e.retry)
+	}
+	for _, localized := range e.localized {
+		details = append(details, localized)
+	}
+	for _, detail := range details {
+		if a, err := anypb.New(detail); err == nil {
+			st.Details = append(st.Details, a)
+		}
+	}
+	return status.FromProto(st)
+}
+
+// Violations collects the field violations of a request, so that a client learns about
+// all its invalid fields at once
+type Violations struct {
+	fields []*errdetails.BadRequest_FieldViolation
+}
+
+// Add records a field violation
+func (v *Violations) Add(field, description string) {
+	v.fields = append(v.fields, &errdetails.BadRequest_FieldViolation{Field: field, Description: description})
+}
+
+// Err returns an InvalidArgument error listing the violations, or nil if there are none
+func (v *Violations) Err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	messages := make([]string, len(v.fields))
+	for i, f := range v.fields {
+		messages[i] = f.Field + ": " + f.Description
+	}
+	e := New(codes.InvalidArgument, strings.Join(messages, "; "))
+	e.badRequest = &errdetails.BadRequest{FieldViolations: v.fields}
+	return e
+}
+
+// FieldViolations returns the field violations of an error received from a call
+func FieldViolations(err error) []*errdetails.BadRequest_FieldViolation {
+	var fields []*errdetails.BadRequest_FieldViolation
+	for _, detail := range Status(err).Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			fields = append(fields, badRequest.FieldViolations...)
+		}
+	}
+	return fields
+}
+
+// Info returns the google.rpc.ErrorInfo of an error received from a call, or nil
+func Info(err error) *errdetails.ErrorInfo {
+	for _, detail := range Status(err).Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info
+		}
+	}
+	return nil
+}
+
+// RetryDelay returns the delay an error received from a call asks for before retrying
+func RetryDelay(err error) (time.Duration, bool) {
+	for _, detail := range Status(err).Details() {
+		if retry, ok := detail.(*errdetails.RetryInfo); ok {
+			return retry.RetryDelay.AsDuration(), true
+		}
+	}
+	return 0, false
+}
+
+// LocalizedMessage returns the message of an error received from a call for locale,
+// falling back to the first localized message and then to the status message
+func LocalizedMessage(err error, locale string) string {
+	st := Status(err)
+	var fallback *errdetails.LocalizedMessage
+	for _, detail := range st.Details() {
+		if localized, ok := detail.(*errdetails.LocalizedMessage); ok {
+			if localized.Locale == locale {
+				return localized.Message
+			}
+			if fallback == nil {
+				fallback = localized
+			}
+		}
+	}
+	if fallback != nil {
+		return fallback.Message
+	}
+	return st.Message()
+}
@@ -0,0 +1,155 @@
+package errors
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	grpcpkg "github.com/LucasPluta/GoMicroserviceFramework/pkg/grpc"
+	"github.com/lib/pq"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	testgrpc "google.golang.org/grpc/interop/grpc_testing"
+	"google.golang.org/grpc/metadata"
+)
+
+// failingService fails UnaryCall with the error named by the request payload
+type failingService struct {
+	testgrpc.UnimplementedTestServiceServer
+}
+
+var serviceErrors = map[string]error{
+	"violations": func() error {
+		var v Violations
+		v.Add("email", "must not be empty")
+		v.Add("age", "must be positive")
+		return v.Err()
+	}(),
+	"locked": New(codes.FailedPrecondition, "account is locked").
+		WithReason("ACCOUNT_LOCKED", "user-service", map[string]string{"user_id": "42"}).
+		WithRetryDelay(3 * time.Second),
+	"duplicate": fmt.Errorf("insert user: %w", &pq.Error{Code: "23505", Constraint: "users_email_key", Detail: "Key (email)=(a@b.c) already exists."}),
+}
+
+func (failingService) UnaryCall(ctx context.Context, req *testgrpc.SimpleRequest) (*testgrpc.SimpleResponse, error) {
+	return nil, serviceErrors[string(req.Payload.GetBody())]
+}
+
+func newErrorServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := grpcpkg.NewServer(ServerOptions()...)
+	testgrpc.RegisterTestServiceServer(server, failingService{})
+	adapter := grpcpkg.NewConnectAdapter()
+	adapter.UseServerInterceptors(UnaryServerInterceptor(), StreamServerInterceptor())
+	testgrpc.RegisterTestServiceServer(adapter, failingService{})
+
+	srv := httptest.NewUnstartedServer(grpcpkg.NewMux(server, adapter))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// checkDetails checks the details of each error of serviceErrors as the client read them
+func checkDetails(t *testing.T, protocol, name string, err error) {
+	t.Helper()
+	switch name {
+	case "violations":
+		fields := FieldViolations(err)
+		if Status(err).Code() != codes.InvalidArgument || len(fields) != 2 || fields[0].Field != "email" || fields[1].Field != "age" {
+			t.Errorf("%s %s: %v with violations %v", protocol, name, err, fields)
+		}
+	case "locked":
+		info := Info(err)
+		if info == nil || info.Reason != "ACCOUNT_LOCKED" || info.Domain != "user-service" || info.Metadata["user_id"] != "42" {
+			t.Errorf("%s %s: ErrorInfo = %v", protocol, name, info)
+		}
+		if delay, ok := RetryDelay(err); !ok || delay != 3*time.Second {
+			t.Errorf("%s %s: RetryDelay = %v, %v; want 3s", protocol, name, delay, ok)
+		}
+		if st := Status(err); st.Code() != codes.FailedPrecondition || st.Message() != "account is locked" {
+			t.Errorf("%s %s: status = %s %q", protocol, name, st.Code(), st.Message())
+		}
+	case "duplicate":
+		info := Info(err)
+		if Status(err).Code() != codes.AlreadyExists || info == nil || info.Reason != "UNIQUE_VIOLATION" || info.Metadata["constraint"] != "users_email_key" {
+			t.Errorf("%s %s: %v with ErrorInfo %v", protocol, name, err, info)
+		}
+		if strings.Contains(Status(err).Message(), "a@b.c") {
+			t.Errorf("%s %s: message leaks the row: %q", protocol, name, Status(err).Message())
+		}
+	}
+}
+
+func TestDetailsOverGRPC(t *testing.T) {
+	srv := newErrorServer(t)
+	conn, err := grpc.NewClient(strings.TrimPrefix(srv.URL, "https://"),
+		grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{InsecureSkipVerify: true})))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	defer conn.Close()
+	client := testgrpc.NewTestServiceClient(conn)
+
+	for name := range serviceErrors {
+		var trailer metadata.MD
+		_, err := client.UnaryCall(context.Background(), &testgrpc.SimpleRequest{Payload: &testgrpc.Payload{Body: []byte(name)}}, grpc.Trailer(&trailer))
+		if len(trailer.Get("grpc-status-details-bin")) == 0 {
+			t.Errorf("grpc %s: no grpc-status-details-bin trailer", name)
+		}
+		checkDetails(t, "grpc", name, err)
+	}
+}
+
+func TestDetailsOverConnect(t *testing.T) {
+	srv := newErrorServer(t)
+	protocols := map[string][]connect.ClientOption{
+		"connect-json": {connect.WithProtoJSON()},
+		"grpc-web":     {connect.WithGRPCWeb()},
+	}
+	for protocol, opts := range protocols {
+		client := connect.NewClient[testgrpc.SimpleRequest, testgrpc.SimpleResponse](
+			srv.Client(), srv.URL+"/grpc.testing.TestService/UnaryCall", opts...)
+		for name := range serviceErrors {
+			_, err := client.CallUnary(context.Background(), connect.NewRequest(&testgrpc.SimpleRequest{Payload: &testgrpc.Payload{Body: []byte(name)}}))
+			checkDetails(t, protocol, name, err)
+		}
+	}
+
+	// Browsers read the details from the JSON body
+	body := `{"payload":{"body":"bG9ja2Vk"}}` // base64 of "locked"
+	resp, err := srv.Client().Post(srv.URL+"/grpc.testing.TestService/UnaryCall", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	defer resp.Body.Close()
+	var wire struct {
+		Code    string
+		Message string
+		Details []struct {
+			Type  string
+			Debug map[string]any
+		}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&wire); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if resp.StatusCode != http.StatusBadRequest || wire.Code != "failed_precondition" {
+		t.Errorf("JSON error = %d %s, want 400 failed_precondition", resp.StatusCode, wire.Code)
+	}
+	var types []string
+	for _, detail := range wire.Details {
+		types = append(types, detail.Type)
+	}
+	if got := strings.Join(types, ","); got != "google.rpc.ErrorInfo,google.rpc.RetryInfo" {
+		t.Errorf("JSON details = %s, want ErrorInfo and RetryInfo", got)
+	}
+}
@@ -0,0 +1,50 @@
+package errors
+
+import (
+	"context"
+	"log/slog"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// UnaryServerInterceptor converts the errors returned by handlers with Convert, so
+// handlers can return domain, database and context errors as they are. Errors that become
+// Internal are logged with their cause, which the client does not see. Install it
+// innermost, after the other interceptors, so they observe the converted codes; pass it
+// to the ConnectAdapter as well.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return nil, convert(ctx, err)
+		}
+		return resp, nil
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart of UnaryServerInterceptor
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := handler(srv, ss); err != nil {
+			return convert(ss.Context(), err)
+		}
+		return nil
+	}
+}
+
+// ServerOptions returns the interceptors as server options for grpc.NewServer
+func ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(StreamServerInterceptor()),
+	}
+}
+
+func convert(ctx context.Context, err error) error {
+	st := Status(err)
+	if st.Code() == codes.Internal && st.Message() != err.Error() {
+		slog.ErrorContext(ctx, "Internal error", "error", err)
+	}
+	return st.Err()
+}
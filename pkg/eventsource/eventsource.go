@@ -0,0 +1,274 @@
+package eventsource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// Header carrying the fully-qualified protobuf name of an event
+const EventTypeHeader = "Eventsource-Type"
+
+// ErrConcurrencyConflict is returned by Append when the aggregate changed since expectedVersion
+var ErrConcurrencyConflict = errors.New("aggregate was modified concurrently")
+
+// Config holds configuration for an event store
+type Config struct {
+	Stream         string        // JetStream stream name (default: EVENTS)
+	SubjectPrefix  string        // Subject prefix; events go to <prefix>.<type>.<id> (default: events)
+	SnapshotBucket string        // KV bucket for snapshots (default: <stream>_snapshots)
+	SnapshotEvery  int           // Replayed events after which Load stores a snapshot (0 disables)
+	MaxAge         time.Duration // Stream retention (0 keeps events forever)
+}
+
+// Event is a decoded event read back from the stream
+type Event struct {
+	AggregateType string
+	AggregateID   string
+	Sequence      uint64 // Stream sequence; also the aggregate version after this event
+	Type          string
+	Data          proto.Message
+	Time          time.Time
+}
+
+// Aggregate is rebuilt by applying its events in order
+type Aggregate interface {
+	Apply(event proto.Message) error
+}
+
+// Store appends and reads aggregate events on a JetStream stream
+type Store struct {
+	js        nats.JetStreamContext
+	cfg       Config
+	snapshots nats.KeyValue
+}
+
+// NewStore creates an event store, creating the stream and snapshot bucket if needed
+func NewStore(js nats.JetStreamContext, cfg Config) (*Store, error) {
+	if cfg.Stream == "" {
+		cfg.Stream = "EVENTS"
+	}
+	if cfg.SubjectPrefix == "" {
+		cfg.SubjectPrefix = "events"
+	}
+	if cfg.SnapshotBucket == "" {
+		cfg.SnapshotBucket = cfg.Stream + "_snapshots"
+	}
+
+	if _, err := js.StreamInfo(cfg.Stream); errors.Is(err, nats.ErrStreamNotFound) {
+		_, err = js.AddStream(&nats.StreamConfig{
+			Name:     cfg.Stream,
+			Subjects: []string{cfg.SubjectPrefix + ".>"},
+			Storage:  nats.FileStorage,
+			MaxAge:   cfg.MaxAge,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create event stream %s: %w", cfg.Stream, err)
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to look up event stream %s: %w", cfg.Stream, err)
+	}
+
+	kv, err := js.KeyValue(cfg.SnapshotBucket)
+	if errors.Is(err, nats.ErrBucketNotFound) {
+		kv, err = js.CreateKeyValue(&nats.KeyValueConfig{
+			Bucket:      cfg.SnapshotBucket,
+			Description: "Aggregate snapshots for stream " + cfg.Stream,
+			History:     1,
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open snapshot bucket %s: %w", cfg.SnapshotBucket, err)
+	}
+
+	return &Store{
+		js:        js,
+		cfg:       cfg,
+		snapshots: kv,
+	}, nil
+}
+
+// Subject returns the subject events of an aggregate are stored on
+func (s *Store) Subject(aggregateType, aggregateID string) string {
+	return fmt.Sprintf("%s.%s.%s", s.cfg.SubjectPrefix, aggregateType, aggregateID)
+}
+
+// Append publishes events for an aggregate, failing with ErrConcurrencyConflict if
+// the aggregate's version is not expectedVersion (0 for a new aggregate).
+// Events are published one by one; a conflict midway leaves the earlier events appended.
+// It returns the aggregate version after the last appended event.
+func (s *Store) Append(ctx context.Context, aggregateType, aggregateID string, expectedVersion uint64, events ...proto.Message) (uint64, error) {
+	if err := validateToken(aggregateType); err != nil {
+		return 0, err
+	}
+	if err := validateToken(aggregateID); err != nil {
+		return 0, err
+	}
+
+	subject := s.Subject(aggregateType, aggregateID)
+	version := expectedVersion
+
+	for _, event := range events {
+		data, err := proto.Marshal(event)
+		if err != nil {
+			return version, fmt.Errorf("failed to encode event: %w", err)
+		}
+
+		msg := nats.NewMsg(subject)
+		msg.Header.Set(EventTypeHeader, string(event.ProtoReflect().Descriptor().FullName()))
+		msg.Data = data
+
+		ack, err := s.js.PublishMsg(msg, nats.ExpectLastSequencePerSubject(version), nats.Context(ctx))
+		if err != nil {
+			var apiErr *nats.APIError
+			if errors.As(err, &apiErr) && apiErr.ErrorCode == nats.JSErrCodeStreamWrongLastSequence {
+				return version, fmt.Errorf("%w: %s/%s at version %d", ErrConcurrencyConflict, aggregateType, aggregateID, version)
+			}
+			return version, fmt.Errorf("failed to append event to %s: %w", subject, err)
+		}
+
+		version = ack.Sequence
+	}
+
+	return version, nil
+}
+
+// Events returns the events of an aggregate after the given version
+func (s *Store) Events(ctx context.Context, aggregateType, aggregateID string, afterVersion uint64) ([]Event, error) {
+	var events []Event
+	err := s.replay(ctx, aggregateType, aggregateID, afterVersion, func(event Event) error {
+		events = append(events, event)
+		return nil
+	})
+	return events, err
+}
+
+// Load rehydrates an aggregate from its latest snapshot (if it implements Snapshotter)
+// and the events appended after it, returning the aggregate's current version
+func (s *Store) Load(ctx context.Context, aggregateType, aggregateID string, agg Aggregate) (uint64, error) {
+	var version uint64
+
+	snapshotter, canSnapshot := agg.(Snapshotter)
+	if canSnapshot {
+		v, err := s.loadSnapshot(aggregateType, aggregateID, snapshotter)
+		if err != nil {
+			return 0, err
+		}
+		version = v
+	}
+
+	replayed := 0
+	err := s.replay(ctx, aggregateType, aggregateID, version, func(event Event) error {
+		if err := agg.Apply(event.Data); err != nil {
+			return fmt.Errorf("failed to apply event %d to %s/%s: %w", event.Sequence, aggregateType, aggregateID, err)
+		}
+		version = event.Sequence
+		replayed++
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if canSnapshot && s.cfg.SnapshotEvery > 0 && replayed >= s.cfg.SnapshotEvery {
+		if err := s.SaveSnapshot(aggregateType, aggregateID, version, snapshotter); err != nil {
+			// A missing snapshot only costs replay time, so don't fail the load
+			log.Printf("Failed to save snapshot for %s/%s: %v", aggregateType, aggregateID, err)
+		}
+	}
+
+	return version, nil
+}
+
+// replay calls fn for each event on the aggregate subject after afterVersion
+func (s *Store) replay(ctx context.Context, aggregateType, aggregateID string, afterVersion uint64, fn func(Event) error) error {
+	subject := s.Subject(aggregateType, aggregateID)
+
+	last, err := s.js.GetLastMsg(s.cfg.Stream, subject)
+	if errors.Is(err, nats.ErrMsgNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read last event of %s: %w", subject, err)
+	}
+	if last.Sequence <= afterVersion {
+		return nil
+	}
+
+	sub, err := s.js.SubscribeSync(subject,
+		nats.BindStream(s.cfg.Stream),
+		nats.OrderedConsumer(),
+		nats.StartSequence(afterVersion+1),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", subject, err)
+	}
+	defer sub.Unsubscribe()
+
+	for {
+		msg, err := sub.NextMsgWithContext(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to read events of %s: %w", subject, err)
+		}
+
+		event, err := s.decode(msg)
+		if err != nil {
+			return err
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+		if event.Sequence >= last.Sequence {
+			return nil
+		}
+	}
+}
+
+func (s *Store) decode(msg *nats.Msg) (Event, error) {
+	meta, err := msg.Metadata()
+	if err != nil {
+		return Event{}, fmt.Errorf("failed to read event metadata: %w", err)
+	}
+
+	eventType := msg.Header.Get(EventTypeHeader)
+	mt, err := protoregistry.GlobalTypes.FindMessageByName(protoreflect.FullName(eventType))
+	if err != nil {
+		return Event{}, fmt.Errorf("unknown event type %q at sequence %d: %w", eventType, meta.Sequence.Stream, err)
+	}
+
+	data := mt.New().Interface()
+	if err := proto.Unmarshal(msg.Data, data); err != nil {
+		return Event{}, fmt.Errorf("failed to decode event %d: %w", meta.Sequence.Stream, err)
+	}
+
+	// Subjects are <prefix>.<type>.<id>
+	tokens := strings.Split(strings.TrimPrefix(msg.Subject, s.cfg.SubjectPrefix+"."), ".")
+	event := Event{
+		Sequence: meta.Sequence.Stream,
+		Type:     eventType,
+		Data:     data,
+		Time:     meta.Timestamp,
+	}
+	if len(tokens) == 2 {
+		event.AggregateType = tokens[0]
+		event.AggregateID = tokens[1]
+	}
+
+	return event, nil
+}
+
+// validateToken ensures a value can be used as a single subject token
+func validateToken(token string) error {
+	if token == "" || strings.ContainsAny(token, ".*> \t\r\n") {
+		return fmt.Errorf("invalid aggregate type or ID %q", token)
+	}
+	return nil
+}
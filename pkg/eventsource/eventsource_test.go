@@ -0,0 +1,149 @@
+package eventsource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/nats/natstest"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func newTestStore(t *testing.T, cfg Config) *Store {
+	t.Helper()
+	_, js := natstest.NewJetStream(t)
+	store, err := NewStore(js, cfg)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	return store
+}
+
+func events(values ...string) []proto.Message {
+	msgs := make([]proto.Message, len(values))
+	for i, v := range values {
+		msgs[i] = wrapperspb.String(v)
+	}
+	return msgs
+}
+
+// list is an aggregate holding the values of its StringValue events
+type list struct {
+	values  []string
+	applied int // Events applied since creation, not part of the snapshot
+}
+
+func (l *list) Apply(event proto.Message) error {
+	v, ok := event.(*wrapperspb.StringValue)
+	if !ok {
+		return fmt.Errorf("unexpected event %T", event)
+	}
+	l.values = append(l.values, v.Value)
+	l.applied++
+	return nil
+}
+
+func (l *list) MarshalSnapshot() ([]byte, error) {
+	return []byte(strings.Join(l.values, ",")), nil
+}
+
+func (l *list) UnmarshalSnapshot(data []byte) error {
+	l.values = strings.Split(string(data), ",")
+	return nil
+}
+
+func TestAppendOptimisticConcurrency(t *testing.T) {
+	store := newTestStore(t, Config{})
+	ctx := context.Background()
+
+	v1, err := store.Append(ctx, "list", "a", 0, events("one", "two")...)
+	if err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	// Another aggregate's events do not move the version of the first
+	if _, err := store.Append(ctx, "list", "b", 0, events("other")...); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	if _, err := store.Append(ctx, "list", "a", 0, events("stale")...); !errors.Is(err, ErrConcurrencyConflict) {
+		t.Errorf("Append at a stale version = %v, want ErrConcurrencyConflict", err)
+	}
+	if _, err := store.Append(ctx, "list", "a", v1-1, events("stale")...); !errors.Is(err, ErrConcurrencyConflict) {
+		t.Errorf("Append at a past version = %v, want ErrConcurrencyConflict", err)
+	}
+	v2, err := store.Append(ctx, "list", "a", v1, events("three")...)
+	if err != nil {
+		t.Fatalf("Append at the current version: %v", err)
+	}
+
+	got, err := store.Events(ctx, "list", "a", 0)
+	if err != nil {
+		t.Fatalf("Events: %v", err)
+	}
+	var values []string
+	for _, event := range got {
+		values = append(values, event.Data.(*wrapperspb.StringValue).Value)
+		if event.AggregateType != "list" || event.AggregateID != "a" || event.Type != "google.protobuf.StringValue" {
+			t.Errorf("event %d = %s %s/%s", event.Sequence, event.Type, event.AggregateType, event.AggregateID)
+		}
+	}
+	if strings.Join(values, ",") != "one,two,three" || got[len(got)-1].Sequence != v2 {
+		t.Errorf("events = %v ending at %d, want one,two,three ending at %d", values, got[len(got)-1].Sequence, v2)
+	}
+
+	for _, invalid := range []string{"", "a.b", "a*", "a>", "a b"} {
+		if _, err := store.Append(ctx, "list", invalid, 0, events("x")...); err == nil {
+			t.Errorf("Append accepted the aggregate ID %q", invalid)
+		}
+	}
+}
+
+func TestLoadAfterSnapshot(t *testing.T) {
+	store := newTestStore(t, Config{SnapshotEvery: 3})
+	ctx := context.Background()
+	// Valid in a subject, but not in a KV key
+	const id = "user:1@example"
+
+	version, err := store.Append(ctx, "list", id, 0, events("a", "b", "c")...)
+	if err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	first := &list{}
+	if v, err := store.Load(ctx, "list", id, first); err != nil || v != version {
+		t.Fatalf("Load = %d, %v; want %d", v, err, version)
+	}
+	if first.applied != 3 {
+		t.Errorf("first Load applied %d events, want 3", first.applied)
+	}
+	if _, err := store.snapshots.Get(snapshotKey("list", id)); err != nil {
+		t.Fatalf("no snapshot after replaying SnapshotEvery events: %v", err)
+	}
+
+	version, err = store.Append(ctx, "list", id, version, events("d")...)
+	if err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	second := &list{}
+	if v, err := store.Load(ctx, "list", id, second); err != nil || v != version {
+		t.Fatalf("Load = %d, %v; want %d", v, err, version)
+	}
+	if second.applied != 1 {
+		t.Errorf("Load after the snapshot applied %d events, want 1", second.applied)
+	}
+	if got := strings.Join(second.values, ","); got != "a,b,c,d" {
+		t.Errorf("state = %s, want a,b,c,d", got)
+	}
+
+	// Nothing new: the snapshot alone rebuilds the state
+	if err := store.SaveSnapshot("list", id, version, second); err != nil {
+		t.Fatalf("SaveSnapshot: %v", err)
+	}
+	third := &list{}
+	if v, err := store.Load(ctx, "list", id, third); err != nil || v != version || third.applied != 0 {
+		t.Errorf("Load = %d, %v after applying %d events; want %d from the snapshot alone", v, err, third.applied, version)
+	}
+}
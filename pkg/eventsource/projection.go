@@ -0,0 +1,121 @@
+package eventsource
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/nats-io/nats.go"
+)
+
+// Projection builds a Postgres read model from the event stream
+type Projection interface {
+	// Name identifies the projection's checkpoint
+	Name() string
+	// Handle applies one event inside the transaction that also advances the checkpoint
+	Handle(ctx context.Context, tx *sql.Tx, event Event) error
+}
+
+const checkpointTableSQL = `CREATE TABLE IF NOT EXISTS eventsource_checkpoints (
+	projection TEXT PRIMARY KEY,
+	sequence BIGINT NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+)`
+
+// EnsureCheckpointTable creates the table projections store their position in
+func EnsureCheckpointTable(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, checkpointTableSQL); err != nil {
+		return fmt.Errorf("failed to create checkpoint table: %w", err)
+	}
+	return nil
+}
+
+// Checkpoint returns the last stream sequence a projection has applied
+func Checkpoint(ctx context.Context, db *sql.DB, projection string) (uint64, error) {
+	var seq uint64
+	err := db.QueryRowContext(ctx,
+		`SELECT sequence FROM eventsource_checkpoints WHERE projection = $1`, projection,
+	).Scan(&seq)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read checkpoint for %s: %w", projection, err)
+	}
+	return seq, nil
+}
+
+// RunProjection consumes the whole event stream into the projection, starting after its
+// checkpoint. Each event and the checkpoint update commit in one transaction, so a restart
+// resumes exactly where it stopped. It blocks until ctx is done or the projection fails.
+func (s *Store) RunProjection(ctx context.Context, db *sql.DB, p Projection) error {
+	if err := EnsureCheckpointTable(ctx, db); err != nil {
+		return err
+	}
+
+	checkpoint, err := Checkpoint(ctx, db, p.Name())
+	if err != nil {
+		return err
+	}
+
+	sub, err := s.js.SubscribeSync(s.cfg.SubjectPrefix+".>",
+		nats.BindStream(s.cfg.Stream),
+		nats.OrderedConsumer(),
+		nats.StartSequence(checkpoint+1),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe projection %s: %w", p.Name(), err)
+	}
+	defer sub.Unsubscribe()
+
+	log.Printf("Projection %s started after sequence %d", p.Name(), checkpoint)
+
+	for {
+		msg, err := sub.NextMsgWithContext(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("projection %s failed to read events: %w", p.Name(), err)
+		}
+
+		event, err := s.decode(msg)
+		if err != nil {
+			return fmt.Errorf("projection %s: %w", p.Name(), err)
+		}
+
+		if err := s.project(ctx, db, p, event); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Store) project(ctx context.Context, db *sql.DB, p Projection, event Event) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("projection %s failed to begin transaction: %w", p.Name(), err)
+	}
+	defer tx.Rollback()
+
+	if err := p.Handle(ctx, tx, event); err != nil {
+		return fmt.Errorf("projection %s failed at sequence %d: %w", p.Name(), event.Sequence, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO eventsource_checkpoints (projection, sequence, updated_at)
+		VALUES ($1, $2, now())
+		ON CONFLICT (projection) DO UPDATE SET sequence = EXCLUDED.sequence, updated_at = now()`,
+		p.Name(), event.Sequence,
+	)
+	if err != nil {
+		return fmt.Errorf("projection %s failed to save checkpoint: %w", p.Name(), err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("projection %s failed to commit: %w", p.Name(), err)
+	}
+
+	return nil
+}
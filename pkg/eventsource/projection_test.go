@@ -0,0 +1,190 @@
+package eventsource
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// checkpointDB is an in-memory database/sql driver that understands the checkpoint
+// statements of projection.go, so projections run without Postgres. Checkpoints written in
+// a transaction are only kept when it commits.
+type checkpointDB struct {
+	mu          sync.Mutex
+	checkpoints map[string]int64
+}
+
+func newCheckpointDB(t *testing.T) (*sql.DB, *checkpointDB) {
+	state := &checkpointDB{checkpoints: map[string]int64{}}
+	db := sql.OpenDB(state)
+	t.Cleanup(func() { db.Close() })
+	return db, state
+}
+
+func (d *checkpointDB) Connect(context.Context) (driver.Conn, error) {
+	return &checkpointConn{db: d}, nil
+}
+func (d *checkpointDB) Driver() driver.Driver            { return d }
+func (d *checkpointDB) Open(string) (driver.Conn, error) { return &checkpointConn{db: d}, nil }
+
+func (d *checkpointDB) checkpoint(projection string) (int64, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	seq, ok := d.checkpoints[projection]
+	return seq, ok
+}
+
+type checkpointConn struct {
+	db      *checkpointDB
+	pending map[string]int64 // Writes of the open transaction, nil outside one
+}
+
+func (c *checkpointConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("prepare is not supported: %s", query)
+}
+func (c *checkpointConn) Close() error { return nil }
+
+func (c *checkpointConn) Begin() (driver.Tx, error) {
+	c.pending = map[string]int64{}
+	return c, nil
+}
+
+func (c *checkpointConn) Commit() error {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	for projection, seq := range c.pending {
+		c.db.checkpoints[projection] = seq
+	}
+	c.pending = nil
+	return nil
+}
+
+func (c *checkpointConn) Rollback() error {
+	c.pending = nil
+	return nil
+}
+
+func (c *checkpointConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	switch {
+	case strings.Contains(query, "CREATE TABLE"):
+	case strings.Contains(query, "INSERT INTO eventsource_checkpoints"):
+		if c.pending == nil {
+			return nil, errors.New("checkpoint written outside a transaction")
+		}
+		c.pending[args[0].Value.(string)] = args[1].Value.(int64)
+	default:
+		return nil, fmt.Errorf("unexpected statement: %s", query)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c *checkpointConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if !strings.Contains(query, "FROM eventsource_checkpoints") {
+		return nil, fmt.Errorf("unexpected query: %s", query)
+	}
+	rows := &checkpointRows{}
+	if seq, ok := c.db.checkpoint(args[0].Value.(string)); ok {
+		rows.values = []int64{seq}
+	}
+	return rows, nil
+}
+
+type checkpointRows struct {
+	values []int64
+}
+
+func (r *checkpointRows) Columns() []string { return []string{"sequence"} }
+func (r *checkpointRows) Close() error      { return nil }
+
+func (r *checkpointRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	dest[0], r.values = r.values[0], r.values[1:]
+	return nil
+}
+
+// recorder is a projection that records the values it handles and fails on "poison"
+// while failPoison is set
+type recorder struct {
+	mu         sync.Mutex
+	failPoison bool
+	handled    []string
+}
+
+func (r *recorder) Name() string { return "recorder" }
+
+func (r *recorder) Handle(_ context.Context, _ *sql.Tx, event Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	value := event.Data.(*wrapperspb.StringValue).Value
+	if value == "poison" && r.failPoison {
+		return errors.New("poisoned")
+	}
+	r.handled = append(r.handled, value)
+	return nil
+}
+
+func (r *recorder) values() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return strings.Join(r.handled, ",")
+}
+
+func TestProjectionCheckpoint(t *testing.T) {
+	store := newTestStore(t, Config{})
+	db, state := newCheckpointDB(t)
+	ctx := context.Background()
+
+	if _, err := store.Append(ctx, "list", "a", 0, events("one", "two")...); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if _, err := store.Append(ctx, "list", "b", 0, events("poison")...); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	// The failed event rolls back, so the checkpoint stays on the last handled event
+	p := &recorder{failPoison: true}
+	if err := store.RunProjection(ctx, db, p); err == nil || !strings.Contains(err.Error(), "poisoned") {
+		t.Fatalf("RunProjection = %v, want the projection's error", err)
+	}
+	if seq, _ := state.checkpoint("recorder"); seq != 2 {
+		t.Errorf("checkpoint after the failure = %d, want 2", seq)
+	}
+	if got := p.values(); got != "one,two" {
+		t.Errorf("handled %s, want one,two", got)
+	}
+
+	// A restart resumes after the checkpoint, then follows new events until cancelled
+	p = &recorder{}
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() { done <- store.RunProjection(runCtx, db, p) }()
+	if _, err := store.Append(ctx, "list", "a", 2, events("three")...); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for p.values() != "poison,three" && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("RunProjection after cancel = %v, want nil", err)
+	}
+	if got := p.values(); got != "poison,three" {
+		t.Errorf("handled %s after the restart, want poison,three", got)
+	}
+	if seq, err := Checkpoint(ctx, db, "recorder"); err != nil || seq != 4 {
+		t.Errorf("Checkpoint = %d, %v; want 4", seq, err)
+	}
+}
@@ -0,0 +1,65 @@
+package eventsource
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+)
+
+// Snapshotter is an aggregate whose state can be saved and restored,
+// so Load only replays the events appended after the snapshot
+type Snapshotter interface {
+	Aggregate
+	MarshalSnapshot() ([]byte, error)
+	UnmarshalSnapshot(data []byte) error
+}
+
+// snapshotKey returns the KV key of an aggregate's snapshot. Both parts are base64url
+// encoded: subject tokens may hold characters, such as ':' or '@', that keys may not.
+func snapshotKey(aggregateType, aggregateID string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(aggregateType)) + "." + base64.RawURLEncoding.EncodeToString([]byte(aggregateID))
+}
+
+// SaveSnapshot stores the aggregate state taken at the given version
+func (s *Store) SaveSnapshot(aggregateType, aggregateID string, version uint64, agg Snapshotter) error {
+	state, err := agg.MarshalSnapshot()
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	// The value is the 8-byte big-endian version followed by the aggregate state
+	data := make([]byte, 8+len(state))
+	binary.BigEndian.PutUint64(data, version)
+	copy(data[8:], state)
+
+	if _, err := s.snapshots.Put(snapshotKey(aggregateType, aggregateID), data); err != nil {
+		return fmt.Errorf("failed to store snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// loadSnapshot restores the latest snapshot into agg and returns its version (0 if none)
+func (s *Store) loadSnapshot(aggregateType, aggregateID string, agg Snapshotter) (uint64, error) {
+	entry, err := s.snapshots.Get(snapshotKey(aggregateType, aggregateID))
+	if errors.Is(err, nats.ErrKeyNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read snapshot of %s/%s: %w", aggregateType, aggregateID, err)
+	}
+
+	data := entry.Value()
+	if len(data) < 8 {
+		return 0, fmt.Errorf("malformed snapshot of %s/%s", aggregateType, aggregateID)
+	}
+
+	if err := agg.UnmarshalSnapshot(data[8:]); err != nil {
+		return 0, fmt.Errorf("failed to restore snapshot of %s/%s: %w", aggregateType, aggregateID, err)
+	}
+
+	return binary.BigEndian.Uint64(data[:8]), nil
+}
@@ -0,0 +1,138 @@
+package flags
+
+import (
+	"context"
+	"errors"
+
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/flags/flagspb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AdminServer implements the FlagAdminService RPCs on top of a Client
+type AdminServer struct {
+	flagspb.UnimplementedFlagAdminServiceServer
+	client *Client
+}
+
+// NewAdminServer creates an admin server for the given client
+func NewAdminServer(client *Client) *AdminServer {
+	return &AdminServer{
+		client: client,
+	}
+}
+
+// RegisterAdminServer registers the flag admin RPCs on a gRPC server or ConnectAdapter
+func RegisterAdminServer(server grpc.ServiceRegistrar, client *Client) {
+	flagspb.RegisterFlagAdminServiceServer(server, NewAdminServer(client))
+}
+
+// GetFlag returns a single flag
+func (s *AdminServer) GetFlag(ctx context.Context, req *flagspb.GetFlagRequest) (*flagspb.Flag, error) {
+	if req.Key == "" {
+		return nil, status.Error(codes.InvalidArgument, "key is required")
+	}
+
+	flag, ok := s.client.Lookup(req.Key)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "flag %s not found", req.Key)
+	}
+
+	return toProto(flag), nil
+}
+
+// ListFlags returns all known flags
+func (s *AdminServer) ListFlags(ctx context.Context, req *flagspb.ListFlagsRequest) (*flagspb.ListFlagsResponse, error) {
+	resp := &flagspb.ListFlagsResponse{}
+	for _, flag := range s.client.List() {
+		resp.Flags = append(resp.Flags, toProto(flag))
+	}
+	return resp, nil
+}
+
+// SetFlag creates or replaces a flag
+func (s *AdminServer) SetFlag(ctx context.Context, req *flagspb.SetFlagRequest) (*flagspb.Flag, error) {
+	if req.Flag == nil {
+		return nil, status.Error(codes.InvalidArgument, "flag is required")
+	}
+
+	flag := fromProto(req.Flag)
+	if err := flag.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	stored, err := s.client.Set(flag)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return toProto(stored), nil
+}
+
+// DeleteFlag removes a flag from the store
+func (s *AdminServer) DeleteFlag(ctx context.Context, req *flagspb.DeleteFlagRequest) (*flagspb.DeleteFlagResponse, error) {
+	if req.Key == "" {
+		return nil, status.Error(codes.InvalidArgument, "key is required")
+	}
+
+	if err := s.client.Delete(req.Key); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &flagspb.DeleteFlagResponse{}, nil
+}
+
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, ErrUnavailable):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+var kindToProto = map[Kind]flagspb.FlagKind{
+	KindBool:       flagspb.FlagKind_FLAG_KIND_BOOL,
+	KindPercentage: flagspb.FlagKind_FLAG_KIND_PERCENTAGE,
+	KindVariant:    flagspb.FlagKind_FLAG_KIND_VARIANT,
+}
+
+func toProto(flag Flag) *flagspb.Flag {
+	pbFlag := &flagspb.Flag{
+		Key:            flag.Key,
+		Kind:           kindToProto[flag.Kind],
+		Enabled:        flag.Enabled,
+		Percentage:     flag.Percentage,
+		DefaultVariant: flag.DefaultVariant,
+		Attribute:      flag.Attribute,
+		Description:    flag.Description,
+		Revision:       flag.Revision,
+	}
+	for _, v := range flag.Variants {
+		pbFlag.Variants = append(pbFlag.Variants, &flagspb.Variant{Name: v.Name, Weight: v.Weight})
+	}
+	return pbFlag
+}
+
+func fromProto(pbFlag *flagspb.Flag) Flag {
+	flag := Flag{
+		Key:            pbFlag.Key,
+		Enabled:        pbFlag.Enabled,
+		Percentage:     pbFlag.Percentage,
+		DefaultVariant: pbFlag.DefaultVariant,
+		Attribute:      pbFlag.Attribute,
+		Description:    pbFlag.Description,
+	}
+	for kind, pbKind := range kindToProto {
+		if pbKind == pbFlag.Kind {
+			flag.Kind = kind
+		}
+	}
+	for _, v := range pbFlag.Variants {
+		flag.Variants = append(flag.Variants, Variant{Name: v.Name, Weight: v.Weight})
+	}
+	return flag
+}
@@ -0,0 +1,284 @@
+package flags
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// DefaultBucket is the JetStream KV bucket used when none is configured
+const DefaultBucket = "feature_flags"
+
+// ErrUnavailable is returned by write operations when the client runs on local defaults only
+var ErrUnavailable = errors.New("flag store unavailable")
+
+// ErrNotFound is returned when a flag does not exist
+var ErrNotFound = errors.New("flag not found")
+
+// Config holds configuration for the flag client
+type Config struct {
+	Bucket   string          // JetStream KV bucket (default: feature_flags)
+	Defaults map[string]Flag // Local fallback values used when a flag is not in the bucket
+}
+
+// Client evaluates flags from a JetStream KV bucket, kept current with a watch
+type Client struct {
+	defaults map[string]Flag
+	done     chan struct{}
+
+	mu      sync.RWMutex
+	kv      nats.KeyValue
+	watcher nats.KeyWatcher
+	closed  bool
+	flags   map[string]Flag
+}
+
+// Delays between attempts to bind a bucket that was unreachable at startup
+const (
+	minBindRetryDelay = time.Second
+	maxBindRetryDelay = 30 * time.Second
+)
+
+// NewClient creates a flag client backed by the configured KV bucket, creating it if needed.
+// If js is nil the client serves local defaults only; if the bucket cannot be reached, it
+// serves them until a background retry binds the bucket.
+func NewClient(js nats.JetStreamContext, cfg Config) *Client {
+	if cfg.Bucket == "" {
+		cfg.Bucket = DefaultBucket
+	}
+
+	c := &Client{
+		defaults: cfg.Defaults,
+		done:     make(chan struct{}),
+		flags:    map[string]Flag{},
+	}
+
+	if js == nil {
+		log.Println("Feature flags: no JetStream context, using local defaults")
+		return c
+	}
+
+	if err := c.bind(js, cfg.Bucket); err != nil {
+		log.Printf("Feature flags: %v, using local defaults until it is reachable", err)
+		go c.retryBind(js, cfg.Bucket)
+		return c
+	}
+
+	log.Printf("Feature flags: watching bucket %s", cfg.Bucket)
+	return c
+}
+
+// bind opens the bucket, creating it if needed, and watches it. The initial values are
+// applied before it returns so the first evaluation sees them.
+func (c *Client) bind(js nats.JetStreamContext, bucket string) error {
+	kv, err := js.KeyValue(bucket)
+	if errors.Is(err, nats.ErrBucketNotFound) {
+		kv, err = js.CreateKeyValue(&nats.KeyValueConfig{
+			Bucket:      bucket,
+			Description: "Runtime feature flags",
+			History:     5,
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open bucket %s: %w", bucket, err)
+	}
+
+	watcher, err := kv.WatchAll()
+	if err != nil {
+		return fmt.Errorf("failed to watch bucket %s: %w", bucket, err)
+	}
+
+	for entry := range watcher.Updates() {
+		if entry == nil {
+			break
+		}
+		c.apply(entry)
+	}
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return watcher.Stop()
+	}
+	c.kv = kv
+	c.watcher = watcher
+	c.mu.Unlock()
+
+	go c.watch(watcher)
+	return nil
+}
+
+// retryBind binds the bucket in the background, backing off between attempts, until it
+// succeeds or the client is closed
+func (c *Client) retryBind(js nats.JetStreamContext, bucket string) {
+	delay := minBindRetryDelay
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-time.After(delay):
+		}
+
+		err := c.bind(js, bucket)
+		if err == nil {
+			log.Printf("Feature flags: watching bucket %s", bucket)
+			return
+		}
+		log.Printf("Feature flags: %v, retrying in %s", err, delay)
+		delay = min(delay*2, maxBindRetryDelay)
+	}
+}
+
+func (c *Client) watch(watcher nats.KeyWatcher) {
+	for entry := range watcher.Updates() {
+		if entry != nil {
+			c.apply(entry)
+		}
+	}
+}
+
+func (c *Client) apply(entry nats.KeyValueEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry.Operation() != nats.KeyValuePut {
+		delete(c.flags, entry.Key())
+		return
+	}
+
+	var flag Flag
+	if err := json.Unmarshal(entry.Value(), &flag); err != nil {
+		log.Printf("Feature flags: ignoring malformed flag %s: %v", entry.Key(), err)
+		return
+	}
+	flag.Key = entry.Key()
+	flag.Revision = entry.Revision()
+	c.flags[entry.Key()] = flag
+}
+
+// Close stops watching for updates and retrying to bind the bucket
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	close(c.done)
+	if c.watcher == nil {
+		return nil
+	}
+	return c.watcher.Stop()
+}
+
+// store returns the bound bucket, or nil while the client runs on local defaults
+func (c *Client) store() nats.KeyValue {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.kv
+}
+
+// Lookup returns the current flag, falling back to the local default
+func (c *Client) Lookup(key string) (Flag, bool) {
+	c.mu.RLock()
+	flag, ok := c.flags[key]
+	c.mu.RUnlock()
+	if ok {
+		return flag, true
+	}
+
+	flag, ok = c.defaults[key]
+	return flag, ok
+}
+
+// Bool reports whether a bool or percentage flag is on for the attributes in ctx.
+// def is returned when the flag is unknown.
+func (c *Client) Bool(ctx context.Context, key string, def bool) bool {
+	flag, ok := c.Lookup(key)
+	if !ok {
+		return def
+	}
+	return flag.IsOn(AttributesFromContext(ctx))
+}
+
+// Variant returns the variant selected for the attributes in ctx.
+// def is returned when the flag is unknown or resolves to no variant.
+func (c *Client) Variant(ctx context.Context, key string, def string) string {
+	flag, ok := c.Lookup(key)
+	if !ok {
+		return def
+	}
+	if v := flag.VariantFor(AttributesFromContext(ctx)); v != "" {
+		return v
+	}
+	return def
+}
+
+// List returns all known flags, including local defaults not overridden in the bucket
+func (c *Client) List() []Flag {
+	c.mu.RLock()
+	result := make([]Flag, 0, len(c.flags)+len(c.defaults))
+	for _, flag := range c.flags {
+		result = append(result, flag)
+	}
+	for key, flag := range c.defaults {
+		if _, ok := c.flags[key]; !ok {
+			result = append(result, flag)
+		}
+	}
+	c.mu.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result
+}
+
+// Set validates and stores a flag, returning it with its new revision
+func (c *Client) Set(flag Flag) (Flag, error) {
+	kv := c.store()
+	if kv == nil {
+		return Flag{}, ErrUnavailable
+	}
+	if err := flag.Validate(); err != nil {
+		return Flag{}, err
+	}
+
+	data, err := json.Marshal(flag)
+	if err != nil {
+		return Flag{}, fmt.Errorf("failed to encode flag %s: %w", flag.Key, err)
+	}
+
+	rev, err := kv.Put(flag.Key, data)
+	if err != nil {
+		return Flag{}, fmt.Errorf("failed to store flag %s: %w", flag.Key, err)
+	}
+
+	flag.Revision = rev
+	return flag, nil
+}
+
+// Delete removes a flag from the bucket so evaluation falls back to the local default.
+// It returns ErrNotFound if the bucket holds no such flag, which KV deletes don't report.
+func (c *Client) Delete(key string) error {
+	kv := c.store()
+	if kv == nil {
+		return ErrUnavailable
+	}
+	if _, err := kv.Get(key); err != nil {
+		if errors.Is(err, nats.ErrKeyNotFound) || errors.Is(err, nats.ErrInvalidKey) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to read flag %s: %w", key, err)
+	}
+	if err := kv.Delete(key); err != nil {
+		return fmt.Errorf("failed to delete flag %s: %w", key, err)
+	}
+	return nil
+}
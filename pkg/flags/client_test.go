@@ -0,0 +1,95 @@
+package flags
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/nats/natstest"
+	"github.com/nats-io/nats.go"
+)
+
+// unreachable fails the first bucket lookups, like JetStream during a startup race
+type unreachable struct {
+	nats.JetStreamContext
+	failures atomic.Int32
+}
+
+func (u *unreachable) KeyValue(bucket string) (nats.KeyValue, error) {
+	if u.failures.Add(-1) >= 0 {
+		return nil, nats.ErrTimeout
+	}
+	return u.JetStreamContext.KeyValue(bucket)
+}
+
+func TestClientBindsBucketLater(t *testing.T) {
+	_, js := natstest.NewJetStream(t)
+	kv, err := js.CreateKeyValue(&nats.KeyValueConfig{Bucket: DefaultBucket})
+	if err != nil {
+		t.Fatalf("CreateKeyValue: %v", err)
+	}
+	if _, err := kv.Put("checkout.v2", []byte(`{"kind":"bool","enabled":true}`)); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	flaky := &unreachable{JetStreamContext: js}
+	flaky.failures.Store(1)
+	c := NewClient(flaky, Config{Defaults: map[string]Flag{"checkout.v2": {Key: "checkout.v2", Kind: KindBool}}})
+	defer c.Close()
+
+	if _, err := c.Set(Flag{Key: "other", Kind: KindBool}); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Set before binding = %v, want ErrUnavailable", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !c.Bool(context.Background(), "checkout.v2", false) {
+		if time.Now().After(deadline) {
+			t.Fatal("client never bound the bucket")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	_, js := natstest.NewJetStream(t)
+	c := NewClient(js, Config{})
+	defer c.Close()
+
+	if err := c.Delete("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete(missing) = %v, want ErrNotFound", err)
+	}
+
+	if _, err := c.Set(Flag{Key: "checkout.v2", Kind: KindBool, Enabled: true}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := c.Delete("checkout.v2"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := c.Delete("checkout.v2"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second Delete = %v, want ErrNotFound", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		flag  Flag
+		valid bool
+	}{
+		{"bool", Flag{Key: "checkout.v2", Kind: KindBool}, true},
+		{"percentage", Flag{Key: "rollout", Kind: KindPercentage, Percentage: 12.5}, true},
+		{"space in key", Flag{Key: "new checkout", Kind: KindBool}, false},
+		{"wildcard key", Flag{Key: "checkout.*", Kind: KindBool}, false},
+		{"leading dot", Flag{Key: ".checkout", Kind: KindBool}, false},
+		{"trailing dot", Flag{Key: "checkout.", Kind: KindBool}, false},
+		{"NaN percentage", Flag{Key: "rollout", Kind: KindPercentage, Percentage: math.NaN()}, false},
+		{"percentage above 100", Flag{Key: "rollout", Kind: KindPercentage, Percentage: 101}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.flag.Validate(); (err == nil) != tt.valid {
+			t.Errorf("%s: Validate() = %v, want valid %v", tt.name, err, tt.valid)
+		}
+	}
+}
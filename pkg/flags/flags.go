@@ -0,0 +1,174 @@
+package flags
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"regexp"
+)
+
+// Kind identifies how a flag is evaluated
+type Kind string
+
+const (
+	KindBool       Kind = "bool"       // On or off for everyone
+	KindPercentage Kind = "percentage" // On for a stable percentage of subjects
+	KindVariant    Kind = "variant"    // One of several weighted string variants
+)
+
+// Well-known evaluation attributes
+const (
+	AttrUserID = "user_id"
+	AttrTenant = "tenant"
+)
+
+// Variant is a named value of a variant flag with its relative weight
+type Variant struct {
+	Name   string `json:"name"`
+	Weight uint32 `json:"weight"`
+}
+
+// Flag is a runtime feature flag, kill switch or tunable
+type Flag struct {
+	Key            string    `json:"key"`
+	Kind           Kind      `json:"kind"`
+	Enabled        bool      `json:"enabled"`                   // Value of bool flags; kill switch for the other kinds
+	Percentage     float64   `json:"percentage,omitempty"`      // Rollout percentage (0-100) for percentage flags
+	Variants       []Variant `json:"variants,omitempty"`        // Weighted variants for variant flags
+	DefaultVariant string    `json:"default_variant,omitempty"` // Variant served when disabled or the attribute is missing
+	Attribute      string    `json:"attribute,omitempty"`       // Attribute used for bucketing (default: user_id)
+	Description    string    `json:"description,omitempty"`
+	Revision       uint64    `json:"-"` // KV revision the flag was read at
+}
+
+// validKey matches the keys a JetStream KV bucket accepts
+var validKey = regexp.MustCompile(`^[-/_=a-zA-Z0-9]+(\.[-/_=a-zA-Z0-9]+)*$`)
+
+// Validate checks that the flag is well formed
+func (f Flag) Validate() error {
+	if f.Key == "" {
+		return fmt.Errorf("flag key is required")
+	}
+	if !validKey.MatchString(f.Key) {
+		return fmt.Errorf("flag key %q is invalid: use letters, digits, '-', '_', '/', '=' and inner dots", f.Key)
+	}
+
+	switch f.Kind {
+	case KindBool:
+	case KindPercentage:
+		if math.IsNaN(f.Percentage) || f.Percentage < 0 || f.Percentage > 100 {
+			return fmt.Errorf("flag %s: percentage must be between 0 and 100", f.Key)
+		}
+	case KindVariant:
+		if len(f.Variants) == 0 {
+			return fmt.Errorf("flag %s: at least one variant is required", f.Key)
+		}
+		var total uint32
+		for _, v := range f.Variants {
+			if v.Name == "" {
+				return fmt.Errorf("flag %s: variant name is required", f.Key)
+			}
+			total += v.Weight
+		}
+		if total == 0 {
+			return fmt.Errorf("flag %s: variant weights must not all be zero", f.Key)
+		}
+	default:
+		return fmt.Errorf("flag %s: unknown kind %q", f.Key, f.Kind)
+	}
+
+	return nil
+}
+
+// Attributes are the request properties flags are evaluated against
+type Attributes map[string]string
+
+type attributesKey struct{}
+
+// WithAttributes returns a context carrying the given evaluation attributes,
+// merged over any attributes already present
+func WithAttributes(ctx context.Context, attrs Attributes) context.Context {
+	merged := Attributes{}
+	for k, v := range AttributesFromContext(ctx) {
+		merged[k] = v
+	}
+	for k, v := range attrs {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, attributesKey{}, merged)
+}
+
+// AttributesFromContext returns the evaluation attributes stored in the context
+func AttributesFromContext(ctx context.Context) Attributes {
+	attrs, _ := ctx.Value(attributesKey{}).(Attributes)
+	return attrs
+}
+
+// IsOn reports whether a bool or percentage flag is on for the given attributes
+func (f Flag) IsOn(attrs Attributes) bool {
+	if !f.Enabled {
+		return false
+	}
+
+	switch f.Kind {
+	case KindBool:
+		return true
+	case KindPercentage:
+		subject, ok := attrs[f.bucketAttribute()]
+		if !ok || subject == "" {
+			return false
+		}
+		return bucket(f.Key, subject) < f.Percentage
+	default:
+		return false
+	}
+}
+
+// VariantFor returns the variant selected for the given attributes.
+// Disabled flags and subjects without the bucketing attribute get the default variant.
+func (f Flag) VariantFor(attrs Attributes) string {
+	if !f.Enabled || f.Kind != KindVariant || len(f.Variants) == 0 {
+		return f.DefaultVariant
+	}
+
+	subject, ok := attrs[f.bucketAttribute()]
+	if !ok || subject == "" {
+		return f.DefaultVariant
+	}
+
+	var total uint32
+	for _, v := range f.Variants {
+		total += v.Weight
+	}
+	if total == 0 {
+		return f.DefaultVariant
+	}
+
+	point := bucket(f.Key, subject) / 100 * float64(total)
+	var cumulative float64
+	for _, v := range f.Variants {
+		cumulative += float64(v.Weight)
+		if point < cumulative {
+			return v.Name
+		}
+	}
+
+	return f.Variants[len(f.Variants)-1].Name
+}
+
+func (f Flag) bucketAttribute() string {
+	if f.Attribute != "" {
+		return f.Attribute
+	}
+	return AttrUserID
+}
+
+// bucket maps a subject to a stable point in [0, 100) that is independent per flag
+func bucket(key, subject string) float64 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	h.Write([]byte{':'})
+	h.Write([]byte(subject))
+	return float64(h.Sum32()%10000) / 100
+}
@@ -0,0 +1,167 @@
+package flags
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/authn"
+	"google.golang.org/grpc"
+)
+
+// subjects returns n distinct user attributes
+func subjects(n int) []Attributes {
+	attrs := make([]Attributes, n)
+	for i := range attrs {
+		attrs[i] = Attributes{AttrUserID: fmt.Sprintf("user-%d", i)}
+	}
+	return attrs
+}
+
+func TestIsOnPercentage(t *testing.T) {
+	const n = 10000
+	users := subjects(n)
+
+	for _, percentage := range []float64{0, 1, 25, 50, 99.5, 100} {
+		flag := Flag{Key: "rollout", Kind: KindPercentage, Enabled: true, Percentage: percentage}
+		on := 0
+		for _, attrs := range users {
+			if flag.IsOn(attrs) {
+				on++
+			}
+		}
+		// Within 1.5 points of the target
+		if got := float64(on) / n * 100; math.Abs(got-percentage) > 1.5 {
+			t.Errorf("%v%% rollout is on for %.2f%% of subjects", percentage, got)
+		}
+	}
+
+	// Raising the percentage only adds subjects, and a subject keeps its answer
+	low := Flag{Key: "rollout", Kind: KindPercentage, Enabled: true, Percentage: 10}
+	high := low
+	high.Percentage = 30
+	for _, attrs := range users {
+		if low.IsOn(attrs) && !high.IsOn(attrs) {
+			t.Fatalf("%s is on at 10%% but off at 30%%", attrs[AttrUserID])
+		}
+		if low.IsOn(attrs) != low.IsOn(Attributes{AttrUserID: attrs[AttrUserID]}) {
+			t.Fatalf("%s got two answers", attrs[AttrUserID])
+		}
+	}
+
+	// Each flag buckets independently: two 50% rollouts overlap on about a quarter
+	other := Flag{Key: "other", Kind: KindPercentage, Enabled: true, Percentage: 50}
+	half := Flag{Key: "rollout", Kind: KindPercentage, Enabled: true, Percentage: 50}
+	both := 0
+	for _, attrs := range users {
+		if half.IsOn(attrs) && other.IsOn(attrs) {
+			both++
+		}
+	}
+	if got := float64(both) / n * 100; math.Abs(got-25) > 2 {
+		t.Errorf("two 50%% rollouts overlap on %.2f%% of subjects, want about 25%%", got)
+	}
+}
+
+func TestIsOn(t *testing.T) {
+	tests := []struct {
+		name  string
+		flag  Flag
+		attrs Attributes
+		want  bool
+	}{
+		{"bool on", Flag{Kind: KindBool, Enabled: true}, nil, true},
+		{"bool off", Flag{Kind: KindBool}, Attributes{AttrUserID: "u"}, false},
+		{"full rollout", Flag{Key: "f", Kind: KindPercentage, Enabled: true, Percentage: 100}, Attributes{AttrUserID: "u"}, true},
+		{"killed rollout", Flag{Key: "f", Kind: KindPercentage, Percentage: 100}, Attributes{AttrUserID: "u"}, false},
+		{"no subject", Flag{Key: "f", Kind: KindPercentage, Enabled: true, Percentage: 100}, Attributes{AttrTenant: "t"}, false},
+		{"empty subject", Flag{Key: "f", Kind: KindPercentage, Enabled: true, Percentage: 100}, Attributes{AttrUserID: ""}, false},
+		{"tenant attribute", Flag{Key: "f", Kind: KindPercentage, Enabled: true, Percentage: 100, Attribute: AttrTenant}, Attributes{AttrTenant: "t"}, true},
+		{"variant flag", Flag{Key: "f", Kind: KindVariant, Enabled: true, Variants: []Variant{{"a", 1}}}, Attributes{AttrUserID: "u"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.flag.IsOn(tt.attrs); got != tt.want {
+			t.Errorf("%s: IsOn = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestVariantFor(t *testing.T) {
+	flag := Flag{
+		Key:            "checkout",
+		Kind:           KindVariant,
+		Enabled:        true,
+		Variants:       []Variant{{"control", 1}, {"never", 0}, {"treatment", 3}},
+		DefaultVariant: "control",
+	}
+
+	const n = 10000
+	counts := map[string]int{}
+	for _, attrs := range subjects(n) {
+		variant := flag.VariantFor(attrs)
+		counts[variant]++
+		if again := flag.VariantFor(attrs); again != variant {
+			t.Fatalf("%s got %s, then %s", attrs[AttrUserID], variant, again)
+		}
+	}
+	if counts["never"] != 0 {
+		t.Errorf("a zero-weight variant was served %d times", counts["never"])
+	}
+	if got := float64(counts["treatment"]) / n * 100; math.Abs(got-75) > 1.5 {
+		t.Errorf("treatment (weight 3 of 4) served to %.2f%% of subjects, want about 75%%", got)
+	}
+
+	disabled := flag
+	disabled.Enabled = false
+	for name, tt := range map[string]struct {
+		flag  Flag
+		attrs Attributes
+	}{
+		"disabled":           {disabled, Attributes{AttrUserID: "u"}},
+		"no subject":         {flag, Attributes{AttrTenant: "t"}},
+		"not a variant flag": {Flag{Kind: KindBool, Enabled: true, DefaultVariant: "control"}, Attributes{AttrUserID: "u"}},
+	} {
+		if got := tt.flag.VariantFor(tt.attrs); got != "control" {
+			t.Errorf("%s: VariantFor = %q, want the default", name, got)
+		}
+	}
+}
+
+func TestInterceptorSetsAttributesFromClaims(t *testing.T) {
+	var got Attributes
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		got = AttributesFromContext(ctx)
+		return nil, nil
+	}
+	interceptor := UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}
+
+	// Attributes set earlier are kept unless the claims override them
+	ctx := WithAttributes(context.Background(), Attributes{"region": "eu", AttrTenant: "header"})
+	ctx = authn.NewContext(ctx, &authn.Claims{Subject: "user-1", Tenant: "acme"})
+	if _, err := interceptor(ctx, nil, info, handler); err != nil {
+		t.Fatalf("interceptor: %v", err)
+	}
+	want := Attributes{AttrUserID: "user-1", AttrTenant: "acme", "region": "eu"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("attributes = %v, want %v", got, want)
+	}
+
+	// Without claims there is nothing to add
+	if _, err := interceptor(context.Background(), nil, info, handler); err != nil {
+		t.Fatalf("interceptor: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("attributes without claims = %v, want none", got)
+	}
+
+	// Evaluation then follows the caller
+	rollout := Flag{Key: "f", Kind: KindPercentage, Enabled: true, Percentage: 100, Attribute: AttrTenant}
+	if _, err := interceptor(authn.NewContext(context.Background(), &authn.Claims{Tenant: "acme"}), nil, info, handler); err != nil {
+		t.Fatalf("interceptor: %v", err)
+	}
+	if !rollout.IsOn(got) {
+		t.Error("a tenant rollout is off for a caller of that tenant")
+	}
+}
@@ -0,0 +1,597 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        v6.31.1
+// source: pkg/flags/flagspb/flags.proto
+
+package flagspb
+
+import (
+	_ "github.com/LucasPluta/GoMicroserviceFramework/pkg/authz/authzpb"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type FlagKind int32
+
+const (
+	FlagKind_FLAG_KIND_UNSPECIFIED FlagKind = 0
+	FlagKind_FLAG_KIND_BOOL        FlagKind = 1
+	FlagKind_FLAG_KIND_PERCENTAGE  FlagKind = 2
+	FlagKind_FLAG_KIND_VARIANT     FlagKind = 3
+)
+
+// Enum value maps for FlagKind.
+var (
+	FlagKind_name = map[int32]string{
+		0: "FLAG_KIND_UNSPECIFIED",
+		1: "FLAG_KIND_BOOL",
+		2: "FLAG_KIND_PERCENTAGE",
+		3: "FLAG_KIND_VARIANT",
+	}
+	FlagKind_value = map[string]int32{
+		"FLAG_KIND_UNSPECIFIED": 0,
+		"FLAG_KIND_BOOL":        1,
+		"FLAG_KIND_PERCENTAGE":  2,
+		"FLAG_KIND_VARIANT":     3,
+	}
+)
+
+func (x FlagKind) Enum() *FlagKind {
+	p := new(FlagKind)
+	*p = x
+	return p
+}
+
+func (x FlagKind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (FlagKind) Descriptor() protoreflect.EnumDescriptor {
+	return file_pkg_flags_flagspb_flags_proto_enumTypes[0].Descriptor()
+}
+
+func (FlagKind) Type() protoreflect.EnumType {
+	return &file_pkg_flags_flagspb_flags_proto_enumTypes[0]
+}
+
+func (x FlagKind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use FlagKind.Descriptor instead.
+func (FlagKind) EnumDescriptor() ([]byte, []int) {
+	return file_pkg_flags_flagspb_flags_proto_rawDescGZIP(), []int{0}
+}
+
+type Variant struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Weight        uint32                 `protobuf:"varint,2,opt,name=weight,proto3" json:"weight,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Variant) Reset() {
+	*x = Variant{}
+	mi := &file_pkg_flags_flagspb_flags_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Variant) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Variant) ProtoMessage() {}
+
+func (x *Variant) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_flags_flagspb_flags_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Variant.ProtoReflect.Descriptor instead.
+func (*Variant) Descriptor() ([]byte, []int) {
+	return file_pkg_flags_flagspb_flags_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Variant) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Variant) GetWeight() uint32 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
+type Flag struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Key            string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Kind           FlagKind               `protobuf:"varint,2,opt,name=kind,proto3,enum=flags.FlagKind" json:"kind,omitempty"`
+	Enabled        bool                   `protobuf:"varint,3,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	Percentage     float64                `protobuf:"fixed64,4,opt,name=percentage,proto3" json:"percentage,omitempty"`
+	Variants       []*Variant             `protobuf:"bytes,5,rep,name=variants,proto3" json:"variants,omitempty"`
+	DefaultVariant string                 `protobuf:"bytes,6,opt,name=default_variant,json=defaultVariant,proto3" json:"default_variant,omitempty"`
+	Attribute      string                 `protobuf:"bytes,7,opt,name=attribute,proto3" json:"attribute,omitempty"`
+	Description    string                 `protobuf:"bytes,8,opt,name=description,proto3" json:"description,omitempty"`
+	Revision       uint64                 `protobuf:"varint,9,opt,name=revision,proto3" json:"revision,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Flag) Reset() {
+	*x = Flag{}
+	mi := &file_pkg_flags_flagspb_flags_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Flag) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Flag) ProtoMessage() {}
+
+func (x *Flag) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_flags_flagspb_flags_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Flag.ProtoReflect.Descriptor instead.
+func (*Flag) Descriptor() ([]byte, []int) {
+	return file_pkg_flags_flagspb_flags_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Flag) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *Flag) GetKind() FlagKind {
+	if x != nil {
+		return x.Kind
+	}
+	return FlagKind_FLAG_KIND_UNSPECIFIED
+}
+
+func (x *Flag) GetEnabled() bool {
+	if x != nil {
+		return x.Enabled
+	}
+	return false
+}
+
+func (x *Flag) GetPercentage() float64 {
+	if x != nil {
+		return x.Percentage
+	}
+	return 0
+}
+
+func (x *Flag) GetVariants() []*Variant {
+	if x != nil {
+		return x.Variants
+	}
+	return nil
+}
+
+func (x *Flag) GetDefaultVariant() string {
+	if x != nil {
+		return x.DefaultVariant
+	}
+	return ""
+}
+
+func (x *Flag) GetAttribute() string {
+	if x != nil {
+		return x.Attribute
+	}
+	return ""
+}
+
+func (x *Flag) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Flag) GetRevision() uint64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+type GetFlagRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetFlagRequest) Reset() {
+	*x = GetFlagRequest{}
+	mi := &file_pkg_flags_flagspb_flags_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetFlagRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetFlagRequest) ProtoMessage() {}
+
+func (x *GetFlagRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_flags_flagspb_flags_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetFlagRequest.ProtoReflect.Descriptor instead.
+func (*GetFlagRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_flags_flagspb_flags_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetFlagRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+type ListFlagsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListFlagsRequest) Reset() {
+	*x = ListFlagsRequest{}
+	mi := &file_pkg_flags_flagspb_flags_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListFlagsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListFlagsRequest) ProtoMessage() {}
+
+func (x *ListFlagsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_flags_flagspb_flags_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListFlagsRequest.ProtoReflect.Descriptor instead.
+func (*ListFlagsRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_flags_flagspb_flags_proto_rawDescGZIP(), []int{3}
+}
+
+type ListFlagsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Flags         []*Flag                `protobuf:"bytes,1,rep,name=flags,proto3" json:"flags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListFlagsResponse) Reset() {
+	*x = ListFlagsResponse{}
+	mi := &file_pkg_flags_flagspb_flags_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListFlagsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListFlagsResponse) ProtoMessage() {}
+
+func (x *ListFlagsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_flags_flagspb_flags_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListFlagsResponse.ProtoReflect.Descriptor instead.
+func (*ListFlagsResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_flags_flagspb_flags_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListFlagsResponse) GetFlags() []*Flag {
+	if x != nil {
+		return x.Flags
+	}
+	return nil
+}
+
+type SetFlagRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Flag          *Flag                  `protobuf:"bytes,1,opt,name=flag,proto3" json:"flag,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetFlagRequest) Reset() {
+	*x = SetFlagRequest{}
+	mi := &file_pkg_flags_flagspb_flags_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetFlagRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetFlagRequest) ProtoMessage() {}
+
+func (x *SetFlagRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_flags_flagspb_flags_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetFlagRequest.ProtoReflect.Descriptor instead.
+func (*SetFlagRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_flags_flagspb_flags_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *SetFlagRequest) GetFlag() *Flag {
+	if x != nil {
+		return x.Flag
+	}
+	return nil
+}
+
+type DeleteFlagRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteFlagRequest) Reset() {
+	*x = DeleteFlagRequest{}
+	mi := &file_pkg_flags_flagspb_flags_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteFlagRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteFlagRequest) ProtoMessage() {}
+
+func (x *DeleteFlagRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_flags_flagspb_flags_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteFlagRequest.ProtoReflect.Descriptor instead.
+func (*DeleteFlagRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_flags_flagspb_flags_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DeleteFlagRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+type DeleteFlagResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteFlagResponse) Reset() {
+	*x = DeleteFlagResponse{}
+	mi := &file_pkg_flags_flagspb_flags_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteFlagResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteFlagResponse) ProtoMessage() {}
+
+func (x *DeleteFlagResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_flags_flagspb_flags_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteFlagResponse.ProtoReflect.Descriptor instead.
+func (*DeleteFlagResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_flags_flagspb_flags_proto_rawDescGZIP(), []int{7}
+}
+
+var File_pkg_flags_flagspb_flags_proto protoreflect.FileDescriptor
+
+const file_pkg_flags_flagspb_flags_proto_rawDesc = "" +
+	"\n" +
+	"\x1dpkg/flags/flagspb/flags.proto\x12\x05flags\x1a\x1dpkg/authz/authzpb/authz.proto\"5\n" +
+	"\aVariant\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x16\n" +
+	"\x06weight\x18\x02 \x01(\rR\x06weight\"\xa8\x02\n" +
+	"\x04Flag\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12#\n" +
+	"\x04kind\x18\x02 \x01(\x0e2\x0f.flags.FlagKindR\x04kind\x12\x18\n" +
+	"\aenabled\x18\x03 \x01(\bR\aenabled\x12\x1e\n" +
+	"\n" +
+	"percentage\x18\x04 \x01(\x01R\n" +
+	"percentage\x12*\n" +
+	"\bvariants\x18\x05 \x03(\v2\x0e.flags.VariantR\bvariants\x12'\n" +
+	"\x0fdefault_variant\x18\x06 \x01(\tR\x0edefaultVariant\x12\x1c\n" +
+	"\tattribute\x18\a \x01(\tR\tattribute\x12 \n" +
+	"\vdescription\x18\b \x01(\tR\vdescription\x12\x1a\n" +
+	"\brevision\x18\t \x01(\x04R\brevision\"\"\n" +
+	"\x0eGetFlagRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\"\x12\n" +
+	"\x10ListFlagsRequest\"6\n" +
+	"\x11ListFlagsResponse\x12!\n" +
+	"\x05flags\x18\x01 \x03(\v2\v.flags.FlagR\x05flags\"1\n" +
+	"\x0eSetFlagRequest\x12\x1f\n" +
+	"\x04flag\x18\x01 \x01(\v2\v.flags.FlagR\x04flag\"%\n" +
+	"\x11DeleteFlagRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\"\x14\n" +
+	"\x12DeleteFlagResponse*j\n" +
+	"\bFlagKind\x12\x19\n" +
+	"\x15FLAG_KIND_UNSPECIFIED\x10\x00\x12\x12\n" +
+	"\x0eFLAG_KIND_BOOL\x10\x01\x12\x18\n" +
+	"\x14FLAG_KIND_PERCENTAGE\x10\x02\x12\x15\n" +
+	"\x11FLAG_KIND_VARIANT\x10\x032\xbd\x02\n" +
+	"\x10FlagAdminService\x12?\n" +
+	"\aGetFlag\x12\x15.flags.GetFlagRequest\x1a\v.flags.Flag\"\x10\xa2\xbb\x18\f\x12\n" +
+	"flags.read\x12P\n" +
+	"\tListFlags\x12\x17.flags.ListFlagsRequest\x1a\x18.flags.ListFlagsResponse\"\x10\xa2\xbb\x18\f\x12\n" +
+	"flags.read\x12@\n" +
+	"\aSetFlag\x12\x15.flags.SetFlagRequest\x1a\v.flags.Flag\"\x11\xa2\xbb\x18\r\x12\vflags.write\x12T\n" +
+	"\n" +
+	"DeleteFlag\x12\x18.flags.DeleteFlagRequest\x1a\x19.flags.DeleteFlagResponse\"\x11\xa2\xbb\x18\r\x12\vflags.writeBAZ?github.com/LucasPluta/GoMicroserviceFramework/pkg/flags/flagspbb\x06proto3"
+
+var (
+	file_pkg_flags_flagspb_flags_proto_rawDescOnce sync.Once
+	file_pkg_flags_flagspb_flags_proto_rawDescData []byte
+)
+
+func file_pkg_flags_flagspb_flags_proto_rawDescGZIP() []byte {
+	file_pkg_flags_flagspb_flags_proto_rawDescOnce.Do(func() {
+		file_pkg_flags_flagspb_flags_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pkg_flags_flagspb_flags_proto_rawDesc), len(file_pkg_flags_flagspb_flags_proto_rawDesc)))
+	})
+	return file_pkg_flags_flagspb_flags_proto_rawDescData
+}
+
+var file_pkg_flags_flagspb_flags_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_pkg_flags_flagspb_flags_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_pkg_flags_flagspb_flags_proto_goTypes = []any{
+	(FlagKind)(0),              // 0: flags.FlagKind
+	(*Variant)(nil),            // 1: flags.Variant
+	(*Flag)(nil),               // 2: flags.Flag
+	(*GetFlagRequest)(nil),     // 3: flags.GetFlagRequest
+	(*ListFlagsRequest)(nil),   // 4: flags.ListFlagsRequest
+	(*ListFlagsResponse)(nil),  // 5: flags.ListFlagsResponse
+	(*SetFlagRequest)(nil),     // 6: flags.SetFlagRequest
+	(*DeleteFlagRequest)(nil),  // 7: flags.DeleteFlagRequest
+	(*DeleteFlagResponse)(nil), // 8: flags.DeleteFlagResponse
+}
+var file_pkg_flags_flagspb_flags_proto_depIdxs = []int32{
+	0, // 0: flags.Flag.kind:type_name -> flags.FlagKind
+	1, // 1: flags.Flag.variants:type_name -> flags.Variant
+	2, // 2: flags.ListFlagsResponse.flags:type_name -> flags.Flag
+	2, // 3: flags.SetFlagRequest.flag:type_name -> flags.Flag
+	3, // 4: flags.FlagAdminService.GetFlag:input_type -> flags.GetFlagRequest
+	4, // 5: flags.FlagAdminService.ListFlags:input_type -> flags.ListFlagsRequest
+	6, // 6: flags.FlagAdminService.SetFlag:input_type -> flags.SetFlagRequest
+	7, // 7: flags.FlagAdminService.DeleteFlag:input_type -> flags.DeleteFlagRequest
+	2, // 8: flags.FlagAdminService.GetFlag:output_type -> flags.Flag
+	5, // 9: flags.FlagAdminService.ListFlags:output_type -> flags.ListFlagsResponse
+	2, // 10: flags.FlagAdminService.SetFlag:output_type -> flags.Flag
+	8, // 11: flags.FlagAdminService.DeleteFlag:output_type -> flags.DeleteFlagResponse
+	8, // [8:12] is the sub-list for method output_type
+	4, // [4:8] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_pkg_flags_flagspb_flags_proto_init() }
+func file_pkg_flags_flagspb_flags_proto_init() {
+	if File_pkg_flags_flagspb_flags_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pkg_flags_flagspb_flags_proto_rawDesc), len(file_pkg_flags_flagspb_flags_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pkg_flags_flagspb_flags_proto_goTypes,
+		DependencyIndexes: file_pkg_flags_flagspb_flags_proto_depIdxs,
+		EnumInfos:         file_pkg_flags_flagspb_flags_proto_enumTypes,
+		MessageInfos:      file_pkg_flags_flagspb_flags_proto_msgTypes,
+	}.Build()
+	File_pkg_flags_flagspb_flags_proto = out.File
+	file_pkg_flags_flagspb_flags_proto_goTypes = nil
+	file_pkg_flags_flagspb_flags_proto_depIdxs = nil
+}
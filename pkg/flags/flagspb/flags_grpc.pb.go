@@ -0,0 +1,247 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v6.31.1
+// source: pkg/flags/flagspb/flags.proto
+
+package flagspb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	FlagAdminService_GetFlag_FullMethodName    = "/flags.FlagAdminService/GetFlag"
+	FlagAdminService_ListFlags_FullMethodName  = "/flags.FlagAdminService/ListFlags"
+	FlagAdminService_SetFlag_FullMethodName    = "/flags.FlagAdminService/SetFlag"
+	FlagAdminService_DeleteFlag_FullMethodName = "/flags.FlagAdminService/DeleteFlag"
+)
+
+// FlagAdminServiceClient is the client API for FlagAdminService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// FlagAdminService reads and updates runtime feature flags
+type FlagAdminServiceClient interface {
+	// Get a single flag by key
+	GetFlag(ctx context.Context, in *GetFlagRequest, opts ...grpc.CallOption) (*Flag, error)
+	// List all flags in the bucket
+	ListFlags(ctx context.Context, in *ListFlagsRequest, opts ...grpc.CallOption) (*ListFlagsResponse, error)
+	// Create or replace a flag
+	SetFlag(ctx context.Context, in *SetFlagRequest, opts ...grpc.CallOption) (*Flag, error)
+	// Delete a flag so evaluation falls back to the local default
+	DeleteFlag(ctx context.Context, in *DeleteFlagRequest, opts ...grpc.CallOption) (*DeleteFlagResponse, error)
+}
+
+type flagAdminServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewFlagAdminServiceClient(cc grpc.ClientConnInterface) FlagAdminServiceClient {
+	return &flagAdminServiceClient{cc}
+}
+
+func (c *flagAdminServiceClient) GetFlag(ctx context.Context, in *GetFlagRequest, opts ...grpc.CallOption) (*Flag, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Flag)
+	err := c.cc.Invoke(ctx, FlagAdminService_GetFlag_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *flagAdminServiceClient) ListFlags(ctx context.Context, in *ListFlagsRequest, opts ...grpc.CallOption) (*ListFlagsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListFlagsResponse)
+	err := c.cc.Invoke(ctx, FlagAdminService_ListFlags_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *flagAdminServiceClient) SetFlag(ctx context.Context, in *SetFlagRequest, opts ...grpc.CallOption) (*Flag, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Flag)
+	err := c.cc.Invoke(ctx, FlagAdminService_SetFlag_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *flagAdminServiceClient) DeleteFlag(ctx context.Context, in *DeleteFlagRequest, opts ...grpc.CallOption) (*DeleteFlagResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteFlagResponse)
+	err := c.cc.Invoke(ctx, FlagAdminService_DeleteFlag_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// FlagAdminServiceServer is the server API for FlagAdminService service.
+// All implementations must embed UnimplementedFlagAdminServiceServer
+// for forward compatibility.
+//
+// FlagAdminService reads and updates runtime feature flags
+type FlagAdminServiceServer interface {
+	// Get a single flag by key
+	GetFlag(context.Context, *GetFlagRequest) (*Flag, error)
+	// List all flags in the bucket
+	ListFlags(context.Context, *ListFlagsRequest) (*ListFlagsResponse, error)
+	// Create or replace a flag
+	SetFlag(context.Context, *SetFlagRequest) (*Flag, error)
+	// Delete a flag so evaluation falls back to the local default
+	DeleteFlag(context.Context, *DeleteFlagRequest) (*DeleteFlagResponse, error)
+	mustEmbedUnimplementedFlagAdminServiceServer()
+}
+
+// UnimplementedFlagAdminServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedFlagAdminServiceServer struct{}
+
+func (UnimplementedFlagAdminServiceServer) GetFlag(context.Context, *GetFlagRequest) (*Flag, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetFlag not implemented")
+}
+func (UnimplementedFlagAdminServiceServer) ListFlags(context.Context, *ListFlagsRequest) (*ListFlagsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListFlags not implemented")
+}
+func (UnimplementedFlagAdminServiceServer) SetFlag(context.Context, *SetFlagRequest) (*Flag, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetFlag not implemented")
+}
+func (UnimplementedFlagAdminServiceServer) DeleteFlag(context.Context, *DeleteFlagRequest) (*DeleteFlagResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteFlag not implemented")
+}
+func (UnimplementedFlagAdminServiceServer) mustEmbedUnimplementedFlagAdminServiceServer() {}
+func (UnimplementedFlagAdminServiceServer) testEmbeddedByValue()                          {}
+
+// UnsafeFlagAdminServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to FlagAdminServiceServer will
+// result in compilation errors.
+type UnsafeFlagAdminServiceServer interface {
+	mustEmbedUnimplementedFlagAdminServiceServer()
+}
+
+func RegisterFlagAdminServiceServer(s grpc.ServiceRegistrar, srv FlagAdminServiceServer) {
+	// If the following call pancis, it indicates UnimplementedFlagAdminServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&FlagAdminService_ServiceDesc, srv)
+}
+
+func _FlagAdminService_GetFlag_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetFlagRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FlagAdminServiceServer).GetFlag(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FlagAdminService_GetFlag_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FlagAdminServiceServer).GetFlag(ctx, req.(*GetFlagRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FlagAdminService_ListFlags_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListFlagsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FlagAdminServiceServer).ListFlags(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FlagAdminService_ListFlags_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FlagAdminServiceServer).ListFlags(ctx, req.(*ListFlagsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FlagAdminService_SetFlag_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetFlagRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FlagAdminServiceServer).SetFlag(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FlagAdminService_SetFlag_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FlagAdminServiceServer).SetFlag(ctx, req.(*SetFlagRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FlagAdminService_DeleteFlag_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteFlagRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FlagAdminServiceServer).DeleteFlag(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FlagAdminService_DeleteFlag_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FlagAdminServiceServer).DeleteFlag(ctx, req.(*DeleteFlagRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// FlagAdminService_ServiceDesc is the grpc.ServiceDesc for FlagAdminService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var FlagAdminService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "flags.FlagAdminService",
+	HandlerType: (*FlagAdminServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetFlag",
+			Handler:    _FlagAdminService_GetFlag_Handler,
+		},
+		{
+			MethodName: "ListFlags",
+			Handler:    _FlagAdminService_ListFlags_Handler,
+		},
+		{
+			MethodName: "SetFlag",
+			Handler:    _FlagAdminService_SetFlag_Handler,
+		},
+		{
+			MethodName: "DeleteFlag",
+			Handler:    _FlagAdminService_DeleteFlag_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "pkg/flags/flagspb/flags.proto",
+}
@@ -0,0 +1,57 @@
+package flags
+
+import (
+	"context"
+
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/authn"
+	"google.golang.org/grpc"
+)
+
+// UnaryServerInterceptor sets the evaluation attributes of the caller from the claims of
+// its token: the subject as AttrUserID and the tenant as AttrTenant. It must run after
+// authentication; calls without claims keep the attributes they have.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(withClaims(ctx), req)
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart of UnaryServerInterceptor
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &contextStream{ServerStream: ss, ctx: withClaims(ss.Context())})
+	}
+}
+
+// ServerOptions returns the interceptors as server options for grpc.NewServer
+func ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(StreamServerInterceptor()),
+	}
+}
+
+func withClaims(ctx context.Context) context.Context {
+	claims, ok := authn.FromContext(ctx)
+	if !ok {
+		return ctx
+	}
+	attrs := Attributes{}
+	if claims.Subject != "" {
+		attrs[AttrUserID] = claims.Subject
+	}
+	if claims.Tenant != "" {
+		attrs[AttrTenant] = claims.Tenant
+	}
+	return WithAttributes(ctx, attrs)
+}
+
+// contextStream overrides the context of a server stream
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
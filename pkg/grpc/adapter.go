@@ -0,0 +1,526 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"connectrpc.com/connect"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// ConnectAdapter serves gRPC service implementations over the Connect, gRPC-Web and gRPC
+// protocols. It implements grpc.ServiceRegistrar, so the generated Register functions work
+// with it, and builds the procedures from the service descriptors:
+//
+//	adapter := grpc.NewConnectAdapter(connect.WithInterceptors(...))
+//	pb.RegisterUserServiceServer(grpcServer, h)
+//	pb.RegisterUserServiceServer(adapter, h)
+//
+// Unary and all streaming kinds are supported. Request headers reach the implementation as
+// incoming metadata, and headers and trailers set with grpc.SetHeader, grpc.SetTrailer or
+// the stream methods are written to the response.
+type ConnectAdapter struct {
+	mux  *http.ServeMux
+	opts []connect.HandlerOption
+
+	unaryInterceptor  grpc.UnaryServerInterceptor
+	streamInterceptor grpc.StreamServerInterceptor
+}
+
+// NewConnectAdapter creates an adapter. Options such as interceptors are applied to every procedure.
+func NewConnectAdapter(opts ...connect.HandlerOption) *ConnectAdapter {
+	return &ConnectAdapter{
+		mux:  http.NewServeMux(),
+		opts: opts,
+	}
+}
+
+// UseServerInterceptors runs gRPC server interceptors around the implementation, as
+// grpc.NewServer does, so interceptors written for the gRPC server (such as call logging)
+// also apply to Connect calls. Either may be nil. Repeated calls chain the interceptors,
+// the first outermost, as grpc.ChainUnaryInterceptor does. Call it before registering services.
+func (a *ConnectAdapter) UseServerInterceptors(unary grpc.UnaryServerInterceptor, stream grpc.StreamServerInterceptor) {
+	a.unaryInterceptor = chainUnary(a.unaryInterceptor, unary)
+	a.streamInterceptor = chainStream(a.streamInterceptor, stream)
+}
+
+// chainUnary runs outer around inner; either may be nil
+func chainUnary(outer, inner grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	if outer == nil {
+		return inner
+	}
+	if inner == nil {
+		return outer
+	}
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return outer(ctx, req, info, func(ctx context.Context, req any) (any, error) {
+			return inner(ctx, req, info, handler)
+		})
+	}
+}
+
+// chainStream runs outer around inner; either may be nil
+func chainStream(outer, inner grpc.StreamServerInterceptor) grpc.StreamServerInterceptor {
+	if outer == nil {
+		return inner
+	}
+	if inner == nil {
+		return outer
+	}
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return outer(srv, ss, info, func(srv any, ss grpc.ServerStream) error {
+			return inner(srv, ss, info, handler)
+		})
+	}
+}
+
+// RegisterService serves the methods of desc, implemented by impl
+func (a *ConnectAdapter) RegisterService(desc *grpc.ServiceDesc, impl any) {
+	if impl != nil {
+		if handlerType := reflect.TypeOf(desc.HandlerType).Elem(); !reflect.TypeOf(impl).Implements(handlerType) {
+			log.Fatalf("ConnectAdapter.RegisterService found the handler of type %v that does not satisfy %v", reflect.TypeOf(impl), handlerType)
+		}
+	}
+
+	for _, method := range desc.Methods {
+		procedure := "/" + desc.ServiceName + "/" + method.MethodName
+		a.mux.Handle(procedure, connect.NewUnaryHandler(procedure, unaryHandler(impl, method.Handler, a.unaryInterceptor), a.options(desc.ServiceName, method.MethodName)...))
+	}
+	for _, stream := range desc.Streams {
+		procedure := "/" + desc.ServiceName + "/" + stream.StreamName
+		opts := a.options(desc.ServiceName, stream.StreamName)
+		handler := stream.Handler
+		if interceptor := a.streamInterceptor; interceptor != nil {
+			info := &grpc.StreamServerInfo{FullMethod: procedure, IsClientStream: stream.ClientStreams, IsServerStream: stream.ServerStreams}
+			next := stream.Handler
+			handler = func(srv any, ss grpc.ServerStream) error {
+				return interceptor(srv, ss, info, next)
+			}
+		}
+		var h http.Handler
+		switch {
+		case stream.ClientStreams && stream.ServerStreams:
+			h = connect.NewBidiStreamHandler(procedure, bidiStreamHandler(impl, handler), opts...)
+		case stream.ClientStreams:
+			h = connect.NewClientStreamHandler(procedure, clientStreamHandler(impl, handler), opts...)
+		default:
+			h = connect.NewServerStreamHandler(procedure, serverStreamHandler(impl, handler), opts...)
+		}
+		a.mux.Handle(procedure, h)
+	}
+}
+
+// ServeHTTP serves the registered procedures
+func (a *ConnectAdapter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mux.ServeHTTP(w, r)
+}
+
+// options returns the handler options of a method: the adapter's codecs, the message size
+// limits of the gRPC server, the method's schema and its idempotency level (enabling
+// Connect GET requests), then the user's options
+func (a *ConnectAdapter) options(service, method string) []connect.HandlerOption {
+	opts := []connect.HandlerOption{
+		connect.WithCodec(protoCodec),
+		connect.WithCodec(jsonCodec),
+		connect.WithReadMaxBytes(MaxMessageSize),
+		connect.WithSendMaxBytes(MaxMessageSize),
+	}
+	if d, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(service)); err == nil {
+		if sd, ok := d.(protoreflect.ServiceDescriptor); ok {
+			if md := sd.Methods().ByName(protoreflect.Name(method)); md != nil {
+				opts = append(opts, connect.WithSchema(md))
+				if mo, ok := md.Options().(*descriptorpb.MethodOptions); ok {
+					switch mo.GetIdempotencyLevel() {
+					case descriptorpb.MethodOptions_NO_SIDE_EFFECTS:
+						opts = append(opts, connect.WithIdempotency(connect.IdempotencyNoSideEffects))
+					case descriptorpb.MethodOptions_IDEMPOTENT:
+						opts = append(opts, connect.WithIdempotency(connect.IdempotencyIdempotent))
+					}
+				}
+			}
+		}
+	}
+	return append(opts, a.opts...)
+}
+
+// methodHandler is the type of grpc.MethodDesc.Handler
+type methodHandler = func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error)
+
+func unaryHandler(impl any, handler methodHandler, interceptor grpc.UnaryServerInterceptor) func(context.Context, *connect.Request[frame]) (*connect.Response[frame], error) {
+	return func(ctx context.Context, req *connect.Request[frame]) (*connect.Response[frame], error) {
+		s := newAdapterStream(ctx, req.Spec().Procedure, req.Peer(), req.Header(), http.Header{}, http.Header{})
+		s.buffered = true
+		resp, err := handler(impl, s.ctx, req.Msg.decode, interceptor)
+		if err != nil {
+			return nil, s.fail(err)
+		}
+		msg, ok := resp.(proto.Message)
+		if !ok {
+			return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("response is not a proto message: %T", resp))
+		}
+		return s.response(msg), nil
+	}
+}
+
+func serverStreamHandler(impl any, handler grpc.StreamHandler) func(context.Context, *connect.Request[frame], *connect.ServerStream[frame]) error {
+	return func(ctx context.Context, req *connect.Request[frame], stream *connect.ServerStream[frame]) error {
+		conn := stream.Conn()
+		s := newAdapterStream(ctx, req.Spec().Procedure, req.Peer(), req.Header(), conn.ResponseHeader(), conn.ResponseTrailer())
+		received := false
+		s.recv = func(f *frame) error {
+			if received {
+				return io.EOF
+			}
+			received = true
+			*f = *req.Msg
+			return nil
+		}
+		s.send = conn.Send
+		return s.fail(handler(impl, s))
+	}
+}
+
+func clientStreamHandler(impl any, handler grpc.StreamHandler) func(context.Context, *connect.ClientStream[frame]) (*connect.Response[frame], error) {
+	return func(ctx context.Context, stream *connect.ClientStream[frame]) (*connect.Response[frame], error) {
+		conn := stream.Conn()
+		s := newAdapterStream(ctx, stream.Spec().Procedure, stream.Peer(), stream.RequestHeader(), http.Header{}, http.Header{})
+		s.buffered = true
+		s.recv = func(f *frame) error { return conn.Receive(f) }
+
+		// The single response is returned once the handler completes
+		var msg proto.Message
+		s.send = func(m any) error {
+			if msg != nil {
+				return status.Error(codes.Internal, "client streaming method sent more than one response")
+			}
+			msg = m.(*frame).msg
+			return nil
+		}
+		if err := handler(impl, s); err != nil {
+			return nil, s.fail(err)
+		}
+		if msg == nil {
+			return nil, connect.NewError(connect.CodeInternal, errors.New("client streaming method returned no response"))
+		}
+		return s.response(msg), nil
+	}
+}
+
+func bidiStreamHandler(impl any, handler grpc.StreamHandler) func(context.Context, *connect.BidiStream[frame, frame]) error {
+	return func(ctx context.Context, stream *connect.BidiStream[frame, frame]) error {
+		conn := stream.Conn()
+		s := newAdapterStream(ctx, stream.Spec().Procedure, stream.Peer(), stream.RequestHeader(), conn.ResponseHeader(), conn.ResponseTrailer())
+		s.recv = func(f *frame) error { return conn.Receive(f) }
+		s.send = conn.Send
+		return s.fail(handler(impl, s))
+	}
+}
+
+// adapterStream presents a Connect call to a gRPC implementation as a grpc.ServerStream,
+// and to grpc.SetHeader and friends through its transportStream
+type adapterStream struct {
+	ctx    context.Context
+	method string
+	recv   func(*frame) error
+	send   func(any) error
+
+	// buffered calls (unary and client streaming) return their headers with the single response
+	buffered bool
+
+	mu         sync.Mutex
+	header     http.Header // Response headers, written on the first send
+	trailer    http.Header
+	headerSent bool
+}
+
+func newAdapterStream(ctx context.Context, method string, p connect.Peer, reqHeader, header, trailer http.Header) *adapterStream {
+	s := &adapterStream{method: method, header: header, trailer: trailer}
+	ctx = metadata.NewIncomingContext(ctx, headerToMetadata(reqHeader))
+	ctx = peer.NewContext(ctx, &peer.Peer{Addr: peerAddr(p.Addr)})
+	s.ctx = grpc.NewContextWithServerTransportStream(ctx, &transportStream{s})
+	return s
+}
+
+func (s *adapterStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *adapterStream) SetHeader(md metadata.MD) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.headerSent {
+		return status.Error(codes.Internal, "transport: the stream is done or WriteHeader was already called")
+	}
+	appendMetadata(s.header, md)
+	return nil
+}
+
+func (s *adapterStream) SendHeader(md metadata.MD) error {
+	if err := s.SetHeader(md); err != nil {
+		return err
+	}
+	if s.buffered {
+		return nil
+	}
+	s.markHeaderSent()
+	return grpcError(s.send(nil))
+}
+
+func (s *adapterStream) SetTrailer(md metadata.MD) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	appendMetadata(s.trailer, md)
+}
+
+func (s *adapterStream) SendMsg(m any) error {
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return status.Errorf(codes.Internal, "message is not a proto message: %T", m)
+	}
+	s.markHeaderSent()
+	return grpcError(s.send(&frame{msg: msg}))
+}
+
+func (s *adapterStream) RecvMsg(m any) error {
+	var f frame
+	if err := s.recv(&f); err != nil {
+		if errors.Is(err, io.EOF) {
+			// Implementations compare with io.EOF directly
+			return io.EOF
+		}
+		return grpcError(err)
+	}
+	return f.decode(m)
+}
+
+func (s *adapterStream) markHeaderSent() {
+	s.mu.Lock()
+	s.headerSent = true
+	s.mu.Unlock()
+}
+
+// response builds a unary response carrying the headers and trailers set by the implementation
+func (s *adapterStream) response(msg proto.Message) *connect.Response[frame] {
+	resp := connect.NewResponse(&frame{msg: msg})
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	mergeHeader(resp.Header(), s.header)
+	mergeHeader(resp.Trailer(), s.trailer)
+	return resp
+}
+
+// fail converts an implementation error to a Connect error. Buffered calls lose their
+// response, so their headers and trailers travel as the error's metadata.
+func (s *adapterStream) fail(err error) error {
+	if err == nil {
+		return nil
+	}
+	connectErr := connectError(err)
+	if s.buffered {
+		s.mu.Lock()
+		mergeHeader(connectErr.Meta(), s.header)
+		mergeHeader(connectErr.Meta(), s.trailer)
+		s.mu.Unlock()
+	}
+	return connectErr
+}
+
+// transportStream implements grpc.ServerTransportStream for grpc.SetHeader, grpc.SendHeader and grpc.SetTrailer
+type transportStream struct {
+	s *adapterStream
+}
+
+func (t *transportStream) Method() string                  { return t.s.method }
+func (t *transportStream) SetHeader(md metadata.MD) error  { return t.s.SetHeader(md) }
+func (t *transportStream) SendHeader(md metadata.MD) error { return t.s.SendHeader(md) }
+func (t *transportStream) SetTrailer(md metadata.MD) error {
+	t.s.SetTrailer(md)
+	return nil
+}
+
+// frame is a message passing through the adapter. Requests keep their encoded form until
+// the implementation decodes them into its own type; responses hold the implementation's message.
+type frame struct {
+	data  []byte
+	codec *adapterCodec
+	msg   proto.Message
+}
+
+func (f *frame) decode(v any) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return status.Errorf(codes.Internal, "message is not a proto message: %T", v)
+	}
+	if f.codec == nil {
+		// Connect skips the codec for empty payloads: the message is the zero value
+		return nil
+	}
+	if err := f.codec.unmarshal(f.data, msg); err != nil {
+		return status.Errorf(codes.InvalidArgument, "unmarshal %s request: %v", f.codec.name, err)
+	}
+	return nil
+}
+
+// adapterCodec replaces Connect's proto and JSON codecs, deferring request decoding to the implementation
+type adapterCodec struct {
+	name      string
+	marshal   func(proto.Message) ([]byte, error)
+	unmarshal func([]byte, proto.Message) error
+}
+
+var (
+	protoCodec = &adapterCodec{name: "proto", marshal: proto.Marshal, unmarshal: proto.Unmarshal}
+	jsonCodec  = &adapterCodec{
+		name:      "json",
+		marshal:   protojson.Marshal,
+		unmarshal: protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal,
+	}
+)
+
+func (c *adapterCodec) Name() string { return c.name }
+
+// Marshal encodes response frames, and plain messages such as the gRPC error status
+func (c *adapterCodec) Marshal(v any) ([]byte, error) {
+	switch m := v.(type) {
+	case *frame:
+		if m.msg != nil {
+			return c.marshal(m.msg)
+		}
+	case proto.Message:
+		return c.marshal(m)
+	}
+	return nil, fmt.Errorf("adapter cannot marshal %T", v)
+}
+
+func (c *adapterCodec) Unmarshal(data []byte, v any) error {
+	switch m := v.(type) {
+	case *frame:
+		// Connect reuses its read buffers once Unmarshal returns
+		m.data = bytes.Clone(data)
+		m.codec = c
+		return nil
+	case proto.Message:
+		return c.unmarshal(data, m)
+	}
+	return fmt.Errorf("adapter cannot unmarshal into %T", v)
+}
+
+// connectError converts a gRPC status error, including its details, to a Connect error
+func connectError(err error) *connect.Error {
+	var connectErr *connect.Error
+	if errors.As(err, &connectErr) {
+		return connectErr
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		st = status.FromContextError(err)
+	}
+	connectErr = connect.NewError(connect.Code(st.Code()), errors.New(st.Message()))
+	for _, detail := range st.Proto().GetDetails() {
+		if d, err := connect.NewErrorDetail(detail); err == nil {
+			connectErr.AddDetail(d)
+		}
+	}
+	return connectErr
+}
+
+// errorWriter writes errors in the protocol of the request
+var errorWriter = connect.NewErrorWriter()
+
+// WriteError answers a Connect, gRPC-Web or gRPC request with err in the caller's own
+// protocol, for middleware that rejects calls before they reach a handler. gRPC status
+// errors keep their code and details; requests in no RPC protocol get the Connect JSON.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	if writeErr := errorWriter.Write(w, r, connectError(err)); writeErr != nil {
+		log.Printf("Failed to write error for %s: %v", r.URL.Path, writeErr)
+	}
+}
+
+// grpcError converts a Connect error returned by the stream to a gRPC status error,
+// including its details
+func grpcError(err error) error {
+	var connectErr *connect.Error
+	if err == nil || !errors.As(err, &connectErr) {
+		return err
+	}
+	st := status.New(codes.Code(connectErr.Code()), connectErr.Message()).Proto()
+	for _, detail := range connectErr.Details() {
+		st.Details = append(st.Details, &anypb.Any{TypeUrl: "type.googleapis.com/" + detail.Type(), Value: detail.Bytes()})
+	}
+	return status.FromProto(st).Err()
+}
+
+// headerToMetadata converts request headers to incoming metadata, dropping protocol headers
+func headerToMetadata(h http.Header) metadata.MD {
+	md := make(metadata.MD, len(h))
+	for key, values := range h {
+		key = strings.ToLower(key)
+		if reservedHeader(key) {
+			continue
+		}
+		if strings.HasSuffix(key, "-bin") {
+			for _, v := range values {
+				if decoded, err := connect.DecodeBinaryHeader(v); err == nil {
+					md.Append(key, string(decoded))
+				}
+			}
+			continue
+		}
+		md.Append(key, values...)
+	}
+	return md
+}
+
+// appendMetadata adds outgoing metadata to response headers or trailers
+func appendMetadata(h http.Header, md metadata.MD) {
+	for key, values := range md {
+		if reservedHeader(key) {
+			continue
+		}
+		for _, v := range values {
+			if strings.HasSuffix(key, "-bin") {
+				v = connect.EncodeBinaryHeader([]byte(v))
+			}
+			h.Add(key, v)
+		}
+	}
+}
+
+func mergeHeader(dst, src http.Header) {
+	for key, values := range src {
+		dst[key] = append(dst[key], values...)
+	}
+}
+
+// reservedHeader reports whether a header belongs to the transport rather than the application
+func reservedHeader(key string) bool {
+	switch key {
+	case "content-type", "content-length", "content-encoding", "accept-encoding", "te", "trailer", "connection":
+		return true
+	}
+	return strings.HasPrefix(key, "grpc-") || strings.HasPrefix(key, "connect-") || strings.HasPrefix(key, ":")
+}
+
+// peerAddr is the remote address reported by Connect
+type peerAddr string
+
+func (a peerAddr) Network() string { return "tcp" }
+func (a peerAddr) String() string  { return string(a) }
@@ -0,0 +1,320 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"connectrpc.com/connect"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	testgrpc "google.golang.org/grpc/interop/grpc_testing"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// binaryValue checks that -bin metadata survives the base64 encoding of the protocols
+var binaryValue = []byte{0x00, 0xff, 0x10, '\n'}
+
+// testService implements every kind of call, setting a header and a trailer in each. A
+// request whose response_status has a code fails with that code and an ErrorInfo detail.
+type testService struct {
+	testgrpc.UnimplementedTestServiceServer
+}
+
+func setMetadata(ctx context.Context, kind string) {
+	grpc.SetHeader(ctx, metadata.Pairs("x-kind", kind, "x-data-bin", string(binaryValue)))
+	grpc.SetTrailer(ctx, metadata.Pairs("x-trailer", kind))
+}
+
+func failure(st *testgrpc.EchoStatus) error {
+	if st.GetCode() == 0 {
+		return nil
+	}
+	s, err := status.New(codes.Code(st.GetCode()), st.GetMessage()).WithDetails(&errdetails.ErrorInfo{Reason: "TEST_FAILURE", Domain: "test"})
+	if err != nil {
+		return err
+	}
+	return s.Err()
+}
+
+func (testService) UnaryCall(ctx context.Context, req *testgrpc.SimpleRequest) (*testgrpc.SimpleResponse, error) {
+	setMetadata(ctx, "unary")
+	if err := failure(req.ResponseStatus); err != nil {
+		return nil, err
+	}
+	return &testgrpc.SimpleResponse{Payload: req.Payload}, nil
+}
+
+func (testService) StreamingOutputCall(req *testgrpc.StreamingOutputCallRequest, ss grpc.ServerStreamingServer[testgrpc.StreamingOutputCallResponse]) error {
+	setMetadata(ss.Context(), "server")
+	for _, params := range req.ResponseParameters {
+		body := bytes.Repeat([]byte{'x'}, int(params.Size))
+		if err := ss.Send(&testgrpc.StreamingOutputCallResponse{Payload: &testgrpc.Payload{Body: body}}); err != nil {
+			return err
+		}
+	}
+	return failure(req.ResponseStatus)
+}
+
+func (testService) StreamingInputCall(ss grpc.ClientStreamingServer[testgrpc.StreamingInputCallRequest, testgrpc.StreamingInputCallResponse]) error {
+	setMetadata(ss.Context(), "client")
+	var size int32
+	for {
+		req, err := ss.Recv()
+		if err == io.EOF {
+			return ss.SendAndClose(&testgrpc.StreamingInputCallResponse{AggregatedPayloadSize: size})
+		}
+		if err != nil {
+			return err
+		}
+		size += int32(len(req.Payload.GetBody()))
+	}
+}
+
+func (testService) FullDuplexCall(ss grpc.BidiStreamingServer[testgrpc.StreamingOutputCallRequest, testgrpc.StreamingOutputCallResponse]) error {
+	setMetadata(ss.Context(), "bidi")
+	for {
+		req, err := ss.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := failure(req.ResponseStatus); err != nil {
+			return err
+		}
+		if err := ss.Send(&testgrpc.StreamingOutputCallResponse{Payload: req.Payload}); err != nil {
+			return err
+		}
+	}
+}
+
+// adapterProtocols are the client options of each protocol the adapter serves
+var adapterProtocols = map[string][]connect.ClientOption{
+	"connect":      nil,
+	"connect-json": {connect.WithProtoJSON()},
+	"grpc":         {connect.WithGRPC()},
+	"grpc-web":     {connect.WithGRPCWeb()},
+}
+
+func newAdapterServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	adapter := NewConnectAdapter()
+	testgrpc.RegisterTestServiceServer(adapter, testService{})
+	srv := httptest.NewUnstartedServer(adapter)
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// checkMetadata checks the header and trailer set by the implementation
+func checkMetadata(t *testing.T, name string, header, trailer interface{ Get(string) string }, kind string) {
+	t.Helper()
+	if got := header.Get("X-Kind"); got != kind {
+		t.Errorf("%s: header x-kind = %q, want %q", name, got, kind)
+	}
+	if got, err := connect.DecodeBinaryHeader(header.Get("X-Data-Bin")); err != nil || !bytes.Equal(got, binaryValue) {
+		t.Errorf("%s: header x-data-bin = %q (%v), want %q", name, got, err, binaryValue)
+	}
+	if got := trailer.Get("X-Trailer"); got != kind {
+		t.Errorf("%s: trailer x-trailer = %q, want %q", name, got, kind)
+	}
+}
+
+// checkError checks that a failure kept its code, message and ErrorInfo detail
+func checkError(t *testing.T, name string, err error) {
+	t.Helper()
+	var connectErr *connect.Error
+	if !errors.As(err, &connectErr) {
+		t.Fatalf("%s: error %v is not a Connect error", name, err)
+	}
+	if connectErr.Code() != connect.CodeFailedPrecondition || connectErr.Message() != "not ready" {
+		t.Errorf("%s: error = %s %q, want failed_precondition \"not ready\"", name, connectErr.Code(), connectErr.Message())
+	}
+	for _, detail := range connectErr.Details() {
+		value, err := detail.Value()
+		if info, ok := value.(*errdetails.ErrorInfo); err == nil && ok && info.Reason == "TEST_FAILURE" {
+			return
+		}
+	}
+	t.Errorf("%s: error lost its ErrorInfo detail: %v", name, connectErr.Details())
+}
+
+func TestAdapterUnary(t *testing.T) {
+	srv := newAdapterServer(t)
+	ctx := context.Background()
+
+	for name, opts := range adapterProtocols {
+		client := connect.NewClient[testgrpc.SimpleRequest, testgrpc.SimpleResponse](
+			srv.Client(), srv.URL+"/grpc.testing.TestService/UnaryCall", opts...)
+
+		resp, err := client.CallUnary(ctx, connect.NewRequest(&testgrpc.SimpleRequest{Payload: &testgrpc.Payload{Body: []byte("ping")}}))
+		if err != nil {
+			t.Errorf("%s: UnaryCall: %v", name, err)
+			continue
+		}
+		if got := string(resp.Msg.Payload.GetBody()); got != "ping" {
+			t.Errorf("%s: UnaryCall payload = %q, want ping", name, got)
+		}
+		checkMetadata(t, name, resp.Header(), resp.Trailer(), "unary")
+
+		_, err = client.CallUnary(ctx, connect.NewRequest(&testgrpc.SimpleRequest{
+			ResponseStatus: &testgrpc.EchoStatus{Code: int32(codes.FailedPrecondition), Message: "not ready"},
+		}))
+		checkError(t, name, err)
+		var connectErr *connect.Error
+		if errors.As(err, &connectErr) && connectErr.Meta().Get("X-Kind") != "unary" {
+			t.Errorf("%s: failed UnaryCall lost its header: %v", name, connectErr.Meta())
+		}
+	}
+}
+
+func TestAdapterServerStream(t *testing.T) {
+	srv := newAdapterServer(t)
+	ctx := context.Background()
+
+	for name, opts := range adapterProtocols {
+		client := connect.NewClient[testgrpc.StreamingOutputCallRequest, testgrpc.StreamingOutputCallResponse](
+			srv.Client(), srv.URL+"/grpc.testing.TestService/StreamingOutputCall", opts...)
+
+		stream, err := client.CallServerStream(ctx, connect.NewRequest(&testgrpc.StreamingOutputCallRequest{
+			ResponseParameters: []*testgrpc.ResponseParameters{{Size: 1}, {Size: 2}, {Size: 3}},
+			ResponseStatus:     &testgrpc.EchoStatus{Code: int32(codes.FailedPrecondition), Message: "not ready"},
+		}))
+		if err != nil {
+			t.Errorf("%s: StreamingOutputCall: %v", name, err)
+			continue
+		}
+		var sizes []int
+		for stream.Receive() {
+			sizes = append(sizes, len(stream.Msg().Payload.GetBody()))
+		}
+		if len(sizes) != 3 || sizes[0] != 1 || sizes[2] != 3 {
+			t.Errorf("%s: received sizes %v, want [1 2 3]", name, sizes)
+		}
+		checkError(t, name, stream.Err())
+		checkMetadata(t, name, stream.ResponseHeader(), stream.ResponseTrailer(), "server")
+		stream.Close()
+	}
+}
+
+func TestAdapterClientStream(t *testing.T) {
+	srv := newAdapterServer(t)
+	ctx := context.Background()
+
+	for name, opts := range adapterProtocols {
+		client := connect.NewClient[testgrpc.StreamingInputCallRequest, testgrpc.StreamingInputCallResponse](
+			srv.Client(), srv.URL+"/grpc.testing.TestService/StreamingInputCall", opts...)
+
+		stream := client.CallClientStream(ctx)
+		for _, body := range []string{"a", "bb", "ccc"} {
+			if err := stream.Send(&testgrpc.StreamingInputCallRequest{Payload: &testgrpc.Payload{Body: []byte(body)}}); err != nil {
+				t.Fatalf("%s: Send: %v", name, err)
+			}
+		}
+		resp, err := stream.CloseAndReceive()
+		if err != nil {
+			t.Errorf("%s: StreamingInputCall: %v", name, err)
+			continue
+		}
+		if resp.Msg.AggregatedPayloadSize != 6 {
+			t.Errorf("%s: aggregated size = %d, want 6", name, resp.Msg.AggregatedPayloadSize)
+		}
+		checkMetadata(t, name, resp.Header(), resp.Trailer(), "client")
+	}
+}
+
+func TestAdapterBidiStream(t *testing.T) {
+	srv := newAdapterServer(t)
+	ctx := context.Background()
+
+	for name, opts := range adapterProtocols {
+		client := connect.NewClient[testgrpc.StreamingOutputCallRequest, testgrpc.StreamingOutputCallResponse](
+			srv.Client(), srv.URL+"/grpc.testing.TestService/FullDuplexCall", opts...)
+
+		stream := client.CallBidiStream(ctx)
+		// Each message is echoed before the next is sent
+		for _, body := range []string{"one", "two"} {
+			if err := stream.Send(&testgrpc.StreamingOutputCallRequest{Payload: &testgrpc.Payload{Body: []byte(body)}}); err != nil {
+				t.Fatalf("%s: Send: %v", name, err)
+			}
+			resp, err := stream.Receive()
+			if err != nil {
+				t.Fatalf("%s: Receive: %v", name, err)
+			}
+			if got := string(resp.Payload.GetBody()); got != body {
+				t.Errorf("%s: echo = %q, want %q", name, got, body)
+			}
+		}
+		if err := stream.Send(&testgrpc.StreamingOutputCallRequest{
+			ResponseStatus: &testgrpc.EchoStatus{Code: int32(codes.FailedPrecondition), Message: "not ready"},
+		}); err != nil {
+			t.Fatalf("%s: Send: %v", name, err)
+		}
+		stream.CloseRequest()
+		_, err := stream.Receive()
+		checkError(t, name, err)
+		checkMetadata(t, name, stream.ResponseHeader(), stream.ResponseTrailer(), "bidi")
+		stream.CloseResponse()
+	}
+}
+
+func TestAdapterMessageSizeLimit(t *testing.T) {
+	srv := newAdapterServer(t)
+	client := connect.NewClient[testgrpc.SimpleRequest, testgrpc.SimpleResponse](
+		srv.Client(), srv.URL+"/grpc.testing.TestService/UnaryCall")
+
+	req := &testgrpc.SimpleRequest{Payload: &testgrpc.Payload{Body: make([]byte, MaxMessageSize+1)}}
+	_, err := client.CallUnary(context.Background(), connect.NewRequest(req))
+	if connect.CodeOf(err) != connect.CodeResourceExhausted {
+		t.Errorf("oversized request: %v, want resource_exhausted", err)
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	st, err := status.New(codes.PermissionDenied, "denied").WithDetails(&errdetails.ErrorInfo{Reason: "TEST_FAILURE"})
+	if err != nil {
+		t.Fatalf("WithDetails: %v", err)
+	}
+	tests := []struct {
+		contentType string
+		wantStatus  int
+		wantType    string
+		wantHeader  string // grpc-status in the headers, "" for none
+		wantBody    string // Substring of the body
+	}{
+		{"application/grpc", http.StatusOK, "application/grpc", "7", ""},
+		{"application/grpc-web+proto", http.StatusOK, "application/grpc-web+proto", "7", ""},
+		{"application/connect+proto", http.StatusOK, "application/connect+proto", "", `"code":"permission_denied"`},
+		{"application/json", http.StatusForbidden, "application/json", "", `"code":"permission_denied"`},
+		{"text/plain", http.StatusForbidden, "application/json", "", `"message":"denied"`},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/test.Service/Method", nil)
+		req.Header.Set("Content-Type", tt.contentType)
+		rec := httptest.NewRecorder()
+		WriteError(rec, req, st.Err())
+
+		if rec.Code != tt.wantStatus || rec.Header().Get("Content-Type") != tt.wantType {
+			t.Errorf("%s: %d %s, want %d %s", tt.contentType, rec.Code, rec.Header().Get("Content-Type"), tt.wantStatus, tt.wantType)
+		}
+		if got := rec.Header().Get("Grpc-Status"); got != tt.wantHeader {
+			t.Errorf("%s: grpc-status = %q, want %q", tt.contentType, got, tt.wantHeader)
+		}
+		if tt.wantHeader != "" && rec.Header().Get("Grpc-Status-Details-Bin") == "" {
+			t.Errorf("%s: error details were dropped", tt.contentType)
+		}
+		if !strings.Contains(rec.Body.String(), tt.wantBody) {
+			t.Errorf("%s: body %q does not contain %q", tt.contentType, rec.Body, tt.wantBody)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"connectrpc.com/connect"
+	"golang.org/x/net/http2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+)
+
+const (
+	// DefaultCallTimeout bounds unary calls whose context has no deadline
+	DefaultCallTimeout = 30 * time.Second
+
+	// DefaultKeepaliveTime is how often idle client connections are pinged.
+	// Servers created by this package permit pings at this rate.
+	DefaultKeepaliveTime = 30 * time.Second
+)
+
+// ClientConfig holds the configuration for client connections
+type ClientConfig struct {
+	Target string // host:port of the server, or any gRPC target such as dns:///service:50051
+
+	// TLS enables TLS when set. CAFile verifies the server (system roots if empty);
+	// CertFile and KeyFile present a client certificate for mTLS.
+	TLS        *TLSConfig
+	ServerName string // Overrides the name verified against the server certificate
+
+	CallTimeout   time.Duration // Default: DefaultCallTimeout; negative disables
+	KeepaliveTime time.Duration // Default: DefaultKeepaliveTime
+	Retry         *RetryPolicy  // Default: DefaultRetryPolicy
+
+	// LoadBalancing is the load balancing policy across the addresses the target resolves
+	// to, such as "round_robin" or registry.LeastRequest (default: pick_first)
+	LoadBalancing string
+
+	// PropagateAuthorization forwards the authorization of the call being served
+	// to downstream calls, in addition to the request ID and trace context
+	PropagateAuthorization bool
+}
+
+// RetryPolicy is the retry policy applied to all methods through the service config
+type RetryPolicy struct {
+	MaxAttempts       int
+	InitialBackoff    time.Duration
+	MaxBackoff        time.Duration
+	BackoffMultiplier float64
+	RetryableCodes    []codes.Code
+}
+
+// DefaultRetryPolicy retries calls that failed before reaching the server's handler
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:       3,
+	InitialBackoff:    100 * time.Millisecond,
+	MaxBackoff:        2 * time.Second,
+	BackoffMultiplier: 2,
+	RetryableCodes:    []codes.Code{codes.Unavailable},
+}
+
+// serviceConfig renders the retry policy, and the load balancing policy if set, as a gRPC service config
+func (p RetryPolicy) serviceConfig(loadBalancing string) (string, error) {
+	config := map[string]interface{}{
+		"methodConfig": []interface{}{
+			map[string]interface{}{
+				"name": []interface{}{map[string]interface{}{}}, // all methods
+				"retryPolicy": map[string]interface{}{
+					"maxAttempts":          p.MaxAttempts,
+					"initialBackoff":       durationString(p.InitialBackoff),
+					"maxBackoff":           durationString(p.MaxBackoff),
+					"backoffMultiplier":    p.BackoffMultiplier,
+					"retryableStatusCodes": p.RetryableCodes, // encoded as numeric codes
+				},
+			},
+		},
+	}
+	if loadBalancing != "" {
+		config["loadBalancingConfig"] = []interface{}{
+			map[string]interface{}{loadBalancing: map[string]interface{}{}},
+		}
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode service config: %w", err)
+	}
+	return string(data), nil
+}
+
+// Dial creates a client connection with TLS, keepalives, retries, default call timeouts
+// and the propagation interceptors. The connection is established lazily on first use.
+// Extra options, such as interceptors, are applied after the defaults.
+func Dial(config ClientConfig, extraOpts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	creds := insecure.NewCredentials()
+	if config.TLS != nil {
+		tlsConfig, err := NewClientTLS(*config.TLS, config.ServerName)
+		if err != nil {
+			return nil, err
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	}
+
+	retry := DefaultRetryPolicy
+	if config.Retry != nil {
+		retry = *config.Retry
+	}
+	serviceConfig, err := retry.serviceConfig(config.LoadBalancing)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultServiceConfig(serviceConfig),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                keepaliveOrDefault(config.KeepaliveTime),
+			Timeout:             10 * time.Second,
+			PermitWithoutStream: true,
+		}),
+		grpc.WithDefaultCallOptions(
+			grpc.MaxCallRecvMsgSize(MaxMessageSize), // Matching the server
+			grpc.MaxCallSendMsgSize(MaxMessageSize),
+		),
+		grpc.WithChainUnaryInterceptor(
+			timeoutUnaryClientInterceptor(callTimeout(config.CallTimeout)),
+			UnaryClientInterceptor(config.PropagateAuthorization),
+		),
+		grpc.WithChainStreamInterceptor(StreamClientInterceptor(config.PropagateAuthorization)),
+	}
+	opts = append(opts, extraOpts...)
+
+	conn, err := grpc.NewClient(config.Target, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client for %s: %w", config.Target, err)
+	}
+	return conn, nil
+}
+
+// ConnectClient holds what generated or hand-written Connect clients need to reach a server
+type ConnectClient struct {
+	HTTPClient *http.Client
+	BaseURL    string
+	Options    []connect.ClientOption
+}
+
+// NewConnectClient is the Connect-go counterpart of Dial: an HTTP/2 client with the same
+// TLS settings, default call timeout and propagation interceptor. Retries are left to the
+// caller since Connect has no service config.
+func NewConnectClient(config ClientConfig, extraOpts ...connect.ClientOption) (*ConnectClient, error) {
+	transport := &http2.Transport{
+		ReadIdleTimeout: keepaliveOrDefault(config.KeepaliveTime),
+		PingTimeout:     10 * time.Second,
+	}
+	scheme := "https"
+	if config.TLS != nil {
+		tlsConfig, err := NewClientTLS(*config.TLS, config.ServerName)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = tlsConfig
+	} else {
+		// HTTP/2 without TLS (h2c), as served by StartConnectServer
+		scheme = "http"
+		transport.AllowHTTP = true
+		transport.DialTLSContext = func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		}
+	}
+
+	opts := []connect.ClientOption{
+		connect.WithInterceptors(
+			connectTimeoutInterceptor(callTimeout(config.CallTimeout)),
+			ConnectClientInterceptor(config.PropagateAuthorization),
+		),
+	}
+	opts = append(opts, extraOpts...)
+
+	return &ConnectClient{
+		HTTPClient: &http.Client{Transport: transport},
+		BaseURL:    scheme + "://" + config.Target,
+		Options:    opts,
+	}, nil
+}
+
+// NewConnectProcedureClient creates a client for one procedure, e.g.
+// "/exampleservice.ExampleServiceService/GetStatus"
+func NewConnectProcedureClient[Req, Res any](c *ConnectClient, procedure string, opts ...connect.ClientOption) *connect.Client[Req, Res] {
+	return connect.NewClient[Req, Res](c.HTTPClient, c.BaseURL+procedure, append(c.Options, opts...)...)
+}
+
+// NewClientTLS creates the client TLS configuration for a TLSConfig: CAFile verifies the
+// server (system roots if empty) and CertFile/KeyFile present a client certificate.
+// The protocol settings match the server's, so TLS 1.2 servers remain reachable.
+func NewClientTLS(config TLSConfig, serverName string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:       tls.VersionTLS12,
+		CipherSuites:     GetSecureCipherSuites(),
+		CurvePreferences: newBaseServerTLSConfig().CurvePreferences,
+		ServerName:       serverName,
+		NextProtos:       []string{"h2"},
+	}
+
+	if config.CAFile != "" {
+		pool, err := loadCertPool(config.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if config.CertFile != "" || config.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+func callTimeout(d time.Duration) time.Duration {
+	if d == 0 {
+		return DefaultCallTimeout
+	}
+	return d
+}
+
+func keepaliveOrDefault(d time.Duration) time.Duration {
+	if d <= 0 {
+		return DefaultKeepaliveTime
+	}
+	return d
+}
+
+// timeoutUnaryClientInterceptor bounds unary calls whose context has no deadline.
+// Streams are long-lived by nature and are left to the caller.
+func timeoutUnaryClientInterceptor(timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if _, ok := ctx.Deadline(); !ok && timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+func connectTimeoutInterceptor(timeout time.Duration) connect.UnaryInterceptorFunc {
+	return func(next connect.UnaryFunc) connect.UnaryFunc {
+		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+			if _, ok := ctx.Deadline(); !ok && timeout > 0 && req.Spec().IsClient {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+			return next(ctx, req)
+		}
+	}
+}
+
+func durationString(d time.Duration) string {
+	return fmt.Sprintf("%.3fs", d.Seconds())
+}
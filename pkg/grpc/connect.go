@@ -1,10 +1,10 @@
 package grpc
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
-	"strings"
 
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
@@ -19,12 +19,11 @@ type ConnectServerConfig struct {
 	ConnectHandler http.Handler
 }
 
-// NewConnectServer creates a server that supports both gRPC and Connect-RPC
-func NewConnectServer() *grpc.Server {
-	opts := []grpc.ServerOption{
-		grpc.MaxRecvMsgSize(10 * 1024 * 1024), // 10MB
-		grpc.MaxSendMsgSize(10 * 1024 * 1024), // 10MB
-	}
+// NewConnectServer creates a server that supports both gRPC and Connect-RPC.
+// Extra options, such as interceptors, are applied after the defaults.
+func NewConnectServer(extraOpts ...grpc.ServerOption) *grpc.Server {
+	opts := defaultServerOptions()
+	opts = append(opts, extraOpts...)
 
 	server := grpc.NewServer(opts...)
 
@@ -34,8 +33,9 @@ func NewConnectServer() *grpc.Server {
 	return server
 }
 
-// NewSecureConnectServer creates a TLS-enabled server that supports both gRPC and Connect-RPC
-func NewSecureConnectServer(tlsConfig TLSConfig) (*grpc.Server, error) {
+// NewSecureConnectServer creates a TLS-enabled server that supports both gRPC and Connect-RPC.
+// Extra options, such as interceptors, are applied after the defaults.
+func NewSecureConnectServer(tlsConfig TLSConfig, extraOpts ...grpc.ServerOption) (*grpc.Server, error) {
 	serverTLSConfig, err := NewServerTLSConfig(tlsConfig)
 	if err != nil {
 		return nil, err
@@ -43,11 +43,8 @@ func NewSecureConnectServer(tlsConfig TLSConfig) (*grpc.Server, error) {
 
 	creds := credentials.NewTLS(serverTLSConfig)
 
-	opts := []grpc.ServerOption{
-		grpc.Creds(creds),
-		grpc.MaxRecvMsgSize(10 * 1024 * 1024), // 10MB
-		grpc.MaxSendMsgSize(10 * 1024 * 1024), // 10MB
-	}
+	opts := append(defaultServerOptions(), grpc.Creds(creds))
+	opts = append(opts, extraOpts...)
 
 	server := grpc.NewServer(opts...)
 
@@ -60,32 +57,54 @@ func NewSecureConnectServer(tlsConfig TLSConfig) (*grpc.Server, error) {
 // StartConnectServer starts a server that handles both gRPC and Connect-RPC protocols
 // connectHandler should be the Connect-RPC handler (can be nil to only support gRPC)
 func StartConnectServer(grpcServer *grpc.Server, connectHandler http.Handler, port string) error {
-	// Create a handler that routes between gRPC and Connect-RPC
-	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		contentType := r.Header.Get("Content-Type")
-
-		// Route to Connect handler if available and content type matches
-		if connectHandler != nil &&
-			(strings.Contains(contentType, "application/json") ||
-				strings.Contains(contentType, "application/connect") ||
-				strings.Contains(contentType, "application/proto")) {
-			connectHandler.ServeHTTP(w, r)
-			return
-		}
-
-		// Default to gRPC handler for application/grpc
-		grpcServer.ServeHTTP(w, r)
-	})
+	return StartMux(NewMux(grpcServer, connectHandler), port)
+}
 
-	// Wrap with h2c to support HTTP/2 without TLS
-	h2cHandler := h2c.NewHandler(handler, &http2.Server{})
+// StartSecureConnectServer starts a TLS-enabled server that handles both gRPC and Connect-RPC protocols
+// connectHandler should be the Connect-RPC handler (can be nil to only support gRPC)
+func StartSecureConnectServer(grpcServer *grpc.Server, connectHandler http.Handler, tlsConfig TLSConfig, port string) error {
+	return StartSecureMux(NewMux(grpcServer, connectHandler), tlsConfig, port)
+}
 
+// StartMux starts a server for the mux's protocols and plain HTTP handlers
+func StartMux(mux *Mux, port string) error {
 	addr := fmt.Sprintf(":%s", port)
-	if connectHandler != nil {
-		log.Printf("Dual-protocol server listening on %s (supports gRPC and Connect-RPC)", addr)
+	if mux.connectHandler != nil {
+		log.Printf("Dual-protocol server listening on %s (supports gRPC, gRPC-Web and Connect-RPC)", addr)
 	} else {
 		log.Printf("gRPC server listening on %s", addr)
 	}
+	return serveH2C(mux, addr)
+}
+
+// StartSecureMux starts a TLS-enabled server for the mux's protocols and plain HTTP handlers
+func StartSecureMux(mux *Mux, tlsConfig TLSConfig, port string) error {
+	addr := fmt.Sprintf(":%s", port)
+	if mux.connectHandler != nil {
+		log.Printf("Secure dual-protocol server listening on %s (TLS enabled, supports gRPC, gRPC-Web and Connect-RPC)", addr)
+	} else {
+		log.Printf("Secure gRPC server listening on %s (TLS enabled)", addr)
+	}
+	return serveTLS(mux, tlsConfig, addr)
+}
+
+// StartHTTP serves any handler, such as a proxy, over HTTP/1.1 and HTTP/2 without TLS (h2c)
+func StartHTTP(handler http.Handler, port string) error {
+	addr := fmt.Sprintf(":%s", port)
+	log.Printf("HTTP server listening on %s", addr)
+	return serveH2C(handler, addr)
+}
+
+// StartSecureHTTP serves any handler over HTTP/1.1 and HTTP/2 with TLS
+func StartSecureHTTP(handler http.Handler, tlsConfig TLSConfig, port string) error {
+	addr := fmt.Sprintf(":%s", port)
+	log.Printf("Secure HTTP server listening on %s (TLS enabled)", addr)
+	return serveTLS(handler, tlsConfig, addr)
+}
+
+func serveH2C(handler http.Handler, addr string) error {
+	// Wrap with h2c to support HTTP/2 without TLS
+	h2cHandler := h2c.NewHandler(handler, &http2.Server{})
 
 	if err := http.ListenAndServe(addr, h2cHandler); err != nil {
 		return fmt.Errorf("failed to serve: %w", err)
@@ -94,37 +113,30 @@ func StartConnectServer(grpcServer *grpc.Server, connectHandler http.Handler, po
 	return nil
 }
 
-// StartSecureConnectServer starts a TLS-enabled server that handles both gRPC and Connect-RPC protocols
-// connectHandler should be the Connect-RPC handler (can be nil to only support gRPC)
-func StartSecureConnectServer(grpcServer *grpc.Server, connectHandler http.Handler, tlsConfig TLSConfig, port string) error {
+func serveTLS(handler http.Handler, tlsConfig TLSConfig, addr string) error {
+	// Without a shared reloader, this listener watches the files itself while it serves
+	if tlsConfig.Reloader == nil && tlsConfig.ReloadInterval > 0 {
+		reloader, err := NewCertReloader(tlsConfig)
+		if err != nil {
+			return fmt.Errorf("failed to create TLS config: %w", err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go reloader.Watch(ctx, tlsConfig.ReloadInterval)
+		tlsConfig.Reloader = reloader
+	}
+
 	// Load TLS configuration
 	serverTLSConfig, err := NewServerTLSConfig(tlsConfig)
 	if err != nil {
 		return fmt.Errorf("failed to create TLS config: %w", err)
 	}
 
-	// Create a handler that routes between gRPC and Connect-RPC
-	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		contentType := r.Header.Get("Content-Type")
-
-		// Route to Connect handler if available and content type matches
-		if connectHandler != nil &&
-			(strings.Contains(contentType, "application/json") ||
-				strings.Contains(contentType, "application/connect") ||
-				strings.Contains(contentType, "application/proto")) {
-			connectHandler.ServeHTTP(w, r)
-			return
-		}
-
-		// Default to gRPC handler for application/grpc
-		grpcServer.ServeHTTP(w, r)
-	})
-
 	// Create HTTP/2 server with TLS
 	http2Server := &http2.Server{}
 
 	server := &http.Server{
-		Addr:      fmt.Sprintf(":%s", port),
+		Addr:      addr,
 		Handler:   handler,
 		TLSConfig: serverTLSConfig,
 	}
@@ -134,13 +146,6 @@ func StartSecureConnectServer(grpcServer *grpc.Server, connectHandler http.Handl
 		return fmt.Errorf("failed to configure HTTP/2: %w", err)
 	}
 
-	addr := fmt.Sprintf(":%s", port)
-	if connectHandler != nil {
-		log.Printf("Secure dual-protocol server listening on %s (TLS enabled, supports gRPC and Connect-RPC)", addr)
-	} else {
-		log.Printf("Secure gRPC server listening on %s (TLS enabled)", addr)
-	}
-
 	if err := server.ListenAndServeTLS("", ""); err != nil {
 		return fmt.Errorf("failed to serve: %w", err)
 	}
@@ -0,0 +1,209 @@
+package grpc
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// corsAllowedHeaders are the request headers browsers may send to Connect and gRPC-Web
+// endpoints, including the framework's propagation headers
+var corsAllowedHeaders = []string{
+	"Content-Type",
+	"Connect-Protocol-Version",
+	"Connect-Timeout-Ms",
+	"Connect-Accept-Encoding",
+	"Connect-Content-Encoding",
+	"Grpc-Timeout",
+	"Grpc-Accept-Encoding",
+	"X-Grpc-Web",
+	"X-User-Agent",
+	"Authorization",
+	"X-Request-Id",
+	"Traceparent",
+	"Tracestate",
+}
+
+// corsExposedHeaders are the response headers browsers may read, so clients see errors and request IDs
+var corsExposedHeaders = []string{
+	"Grpc-Status",
+	"Grpc-Message",
+	"Grpc-Status-Details-Bin",
+	"Content-Encoding",
+	"Connect-Content-Encoding",
+	"X-Request-Id",
+}
+
+// CORSConfig holds the cross-origin policy for browser clients
+type CORSConfig struct {
+	// AllowedOrigins lists origins such as "http://localhost:3000", patterns such as
+	// "https://*.example.com", or "*" for any origin
+	AllowedOrigins []string
+	AllowedMethods []string // Default: GET, POST
+	AllowedHeaders []string // Added to the Connect and gRPC-Web headers
+	ExposedHeaders []string // Added to the status and request ID headers
+
+	// ExposedTrailers are trailer names set by handlers. Connect unary responses send
+	// them as "Trailer-" prefixed headers, which must be exposed as well.
+	ExposedTrailers []string
+
+	AllowCredentials bool          // Allow cookies and client certificates
+	MaxAge           time.Duration // Preflight cache duration (default: 2h)
+
+	// CSRFProtection rejects requests from origins that are not allowed, and requires
+	// requests using the JSON codec to carry the Connect-Protocol-Version header (or the
+	// "connect=v1" query parameter for GET), which plain HTML forms and links cannot send
+	CSRFProtection bool
+}
+
+// CORS applies a cross-origin policy to browser requests
+type CORS struct {
+	cfg            CORSConfig
+	allowedMethods string
+	allowedHeaders string
+	exposedHeaders string
+	maxAge         string
+}
+
+// NewCORS creates the middleware for a policy. Credentials require explicit origins: with
+// "*", any site could make credentialed calls on behalf of the user, so it is an error.
+func NewCORS(cfg CORSConfig) (*CORS, error) {
+	if cfg.AllowCredentials && slices.Contains(cfg.AllowedOrigins, "*") {
+		return nil, errors.New(`CORS: allowing credentials requires explicit origins, not "*"`)
+	}
+	if len(cfg.AllowedMethods) == 0 {
+		cfg.AllowedMethods = []string{http.MethodGet, http.MethodPost}
+	}
+	if cfg.MaxAge <= 0 {
+		cfg.MaxAge = 2 * time.Hour
+	}
+
+	exposed := append(append([]string{}, corsExposedHeaders...), cfg.ExposedHeaders...)
+	for _, trailer := range cfg.ExposedTrailers {
+		exposed = append(exposed, trailer, "Trailer-"+trailer)
+	}
+
+	return &CORS{
+		cfg:            cfg,
+		allowedMethods: strings.Join(cfg.AllowedMethods, ", "),
+		allowedHeaders: strings.Join(append(append([]string{}, corsAllowedHeaders...), cfg.AllowedHeaders...), ", "),
+		exposedHeaders: strings.Join(exposed, ", "),
+		maxAge:         strconv.Itoa(int(cfg.MaxAge.Seconds())),
+	}, nil
+}
+
+// Middleware answers preflight requests and adds the CORS headers to responses.
+// Install it in front of everything else (Mux.Use) since preflights carry no credentials.
+func (c *CORS) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			// Not sent by a browser, so neither CORS nor CSRF apply
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		allowed := c.originAllowed(origin)
+		w.Header().Add("Vary", "Origin")
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			if allowed {
+				c.setAllowOrigin(w, origin)
+				w.Header().Set("Access-Control-Allow-Methods", c.allowedMethods)
+				w.Header().Set("Access-Control-Allow-Headers", c.allowedHeaders)
+				w.Header().Set("Access-Control-Max-Age", c.maxAge)
+			}
+			// Browsers block the request when the headers are missing
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if c.cfg.CSRFProtection {
+			if !allowed && !sameOrigin(r, origin) {
+				c.forbid(w, "origin "+origin+" is not allowed")
+				return
+			}
+			if !c.protocolHeaderPresent(r) {
+				c.forbid(w, "the Connect-Protocol-Version header is required")
+				return
+			}
+		}
+		if allowed {
+			c.setAllowOrigin(w, origin)
+			w.Header().Set("Access-Control-Expose-Headers", c.exposedHeaders)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (c *CORS) originAllowed(origin string) bool {
+	for _, pattern := range c.cfg.AllowedOrigins {
+		if pattern == "*" || pattern == origin {
+			return true
+		}
+		if ok, err := path.Match(pattern, origin); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *CORS) setAllowOrigin(w http.ResponseWriter, origin string) {
+	if c.cfg.AllowCredentials {
+		// Browsers reject "*" with credentials; echo the origin instead
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+		return
+	}
+	if len(c.cfg.AllowedOrigins) == 1 && c.cfg.AllowedOrigins[0] == "*" {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+}
+
+// protocolHeaderPresent reports whether a JSON RPC request proves it came from a Connect client.
+// Requests using other codecs are not checked: their content types already force a preflight.
+// Neither are plain HTTP endpoints, whose paths aren't of the "/package.Service/Method" form.
+func (c *CORS) protocolHeaderPresent(r *http.Request) bool {
+	if service, method, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/"); !ok ||
+		!strings.Contains(service, ".") || method == "" || strings.Contains(method, "/") {
+		return true
+	}
+	switch DetectProtocol(r) {
+	case ProtocolConnectUnary:
+		if !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+			return true
+		}
+		return r.Header.Get("Connect-Protocol-Version") != ""
+	case ProtocolConnectGet:
+		query := r.URL.Query()
+		if query.Get("encoding") != "json" {
+			return true
+		}
+		return query.Get("connect") == "v1" || r.Header.Get("Connect-Protocol-Version") != ""
+	}
+	return true
+}
+
+func (c *CORS) forbid(w http.ResponseWriter, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(map[string]string{"code": "permission_denied", "message": message})
+}
+
+// sameOrigin reports whether origin is the origin the request was sent to
+func sameOrigin(r *http.Request, origin string) bool {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return origin == scheme+"://"+r.Host
+}
@@ -0,0 +1,32 @@
+package grpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewCORSRejectsWildcardWithCredentials(t *testing.T) {
+	if _, err := NewCORS(CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true}); err == nil {
+		t.Error(`NewCORS accepted "*" with credentials`)
+	}
+
+	cors, err := NewCORS(CORSConfig{AllowedOrigins: []string{"https://*.example.com"}, AllowCredentials: true})
+	if err != nil {
+		t.Fatalf("NewCORS: %v", err)
+	}
+	handler := cors.Middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	for origin, want := range map[string]string{
+		"https://app.example.com": "https://app.example.com",
+		"https://evil.test":       "",
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/pkg.Service/Method", nil)
+		req.Header.Set("Origin", origin)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != want {
+			t.Errorf("origin %s: Access-Control-Allow-Origin = %q, want %q", origin, got, want)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// Protocol is the RPC protocol of an HTTP request
+type Protocol string
+
+const (
+	ProtocolGRPC             Protocol = "grpc"              // application/grpc[+codec]
+	ProtocolGRPCWeb          Protocol = "grpc-web"          // application/grpc-web[-text][+codec]
+	ProtocolConnectUnary     Protocol = "connect-unary"     // POST application/proto or application/json
+	ProtocolConnectStreaming Protocol = "connect-streaming" // application/connect+codec
+	ProtocolConnectGet       Protocol = "connect-get"       // GET with encoding and message query parameters
+	ProtocolUnknown          Protocol = ""
+)
+
+// acceptPost lists the content types served by the RPC handlers, reported on 415 responses
+const acceptPost = "application/grpc, application/grpc+proto, application/grpc-web, application/grpc-web+proto, " +
+	"application/connect+proto, application/connect+json, application/proto, application/json"
+
+// DetectProtocol identifies the RPC protocol of r from its method, Content-Type and, for
+// Connect GET requests, its query parameters
+func DetectProtocol(r *http.Request) Protocol {
+	if r.Method == http.MethodGet {
+		query := r.URL.Query()
+		if query.Has("message") && query.Has("encoding") {
+			return ProtocolConnectGet
+		}
+		return ProtocolUnknown
+	}
+	if r.Method != http.MethodPost {
+		return ProtocolUnknown
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return ProtocolUnknown
+	}
+	switch {
+	case mediaType == "application/grpc" || strings.HasPrefix(mediaType, "application/grpc+"):
+		return ProtocolGRPC
+	case strings.HasPrefix(mediaType, "application/grpc-web"):
+		return ProtocolGRPCWeb
+	case strings.HasPrefix(mediaType, "application/connect+"):
+		return ProtocolConnectStreaming
+	case mediaType == "application/proto" || mediaType == "application/json":
+		return ProtocolConnectUnary
+	}
+	return ProtocolUnknown
+}
+
+// Mux routes requests by protocol: gRPC to the gRPC server, gRPC-Web and Connect to the
+// Connect handler, and anything else to the plain HTTP handlers mounted with Handle.
+// A plain handler registered for a path takes precedence over Connect unary and GET
+// requests, whose content types are shared with ordinary JSON endpoints. RPC-looking
+// requests that nothing serves get 415 Unsupported Media Type.
+type Mux struct {
+	grpcServer     *grpc.Server
+	connectHandler http.Handler
+	plain          *http.ServeMux
+	handler        http.Handler // route wrapped by the middleware installed with Use
+}
+
+// NewMux creates a mux for a gRPC server and a Connect handler (nil to serve only gRPC).
+// The Connect handler is wrapped with the propagation Middleware.
+func NewMux(grpcServer *grpc.Server, connectHandler http.Handler) *Mux {
+	if connectHandler != nil {
+		connectHandler = Middleware(connectHandler)
+	}
+	m := &Mux{
+		grpcServer:     grpcServer,
+		connectHandler: connectHandler,
+		plain:          http.NewServeMux(),
+	}
+	m.handler = http.HandlerFunc(m.route)
+	return m
+}
+
+// Use wraps every request, whatever its protocol, with middleware such as CORS.
+// The last middleware installed runs first. Call it before the server starts.
+func (m *Mux) Use(middleware func(http.Handler) http.Handler) {
+	m.handler = middleware(m.handler)
+}
+
+// Handle mounts a plain HTTP handler, using http.ServeMux patterns such as "GET /healthz"
+func (m *Mux) Handle(pattern string, handler http.Handler) {
+	m.plain.Handle(pattern, handler)
+}
+
+// HandleFunc mounts a plain HTTP handler function
+func (m *Mux) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	m.plain.HandleFunc(pattern, handler)
+}
+
+// ServeHTTP serves the request through the middleware and routes it to the handler of its protocol
+func (m *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.handler.ServeHTTP(w, r)
+}
+
+func (m *Mux) route(w http.ResponseWriter, r *http.Request) {
+	protocol := DetectProtocol(r)
+	switch protocol {
+	case ProtocolGRPC:
+		r, cancel := withAbort(w, r)
+		defer cancel()
+		m.grpcServer.ServeHTTP(w, r)
+		return
+	case ProtocolGRPCWeb, ProtocolConnectStreaming:
+		if m.connectHandler != nil {
+			r, cancel := withAbort(w, r)
+			defer cancel()
+			m.connectHandler.ServeHTTP(w, r)
+			return
+		}
+	}
+
+	if _, pattern := m.plain.Handler(r); pattern != "" {
+		m.plain.ServeHTTP(w, r)
+		return
+	}
+
+	switch {
+	case (protocol == ProtocolConnectUnary || protocol == ProtocolConnectGet) && m.connectHandler != nil:
+		m.connectHandler.ServeHTTP(w, r)
+	case r.Method == http.MethodPost:
+		unsupportedMediaType(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+type abortKey struct{}
+
+// withAbort makes the stream of r abortable with AbortStream
+func withAbort(w http.ResponseWriter, r *http.Request) (*http.Request, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(r.Context())
+	abort := func() {
+		// A write waiting for the client's flow-control window only returns once the
+		// stream is reset, which a past write deadline does
+		http.NewResponseController(w).SetWriteDeadline(time.Now())
+		cancel()
+	}
+	return r.WithContext(context.WithValue(ctx, abortKey{}, abort)), cancel
+}
+
+// AbortStream ends the stream served through a Mux whose handler context is ctx: the
+// context is canceled and writes blocked on the client fail, so the handler can wait for
+// them before returning. It reports false for streams it can't abort, such as those of a
+// gRPC server listening on its own; their transport discards blocked writes when the
+// handler returns.
+func AbortStream(ctx context.Context) bool {
+	abort, ok := ctx.Value(abortKey{}).(func())
+	if ok {
+		abort()
+	}
+	return ok
+}
+
+// unsupportedMediaType rejects a request whose content type no handler serves
+func unsupportedMediaType(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Accept-Post", acceptPost)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnsupportedMediaType)
+	json.NewEncoder(w).Encode(map[string]string{
+		"code":    "unimplemented",
+		"message": "unsupported content type " + r.Header.Get("Content-Type"),
+	})
+}
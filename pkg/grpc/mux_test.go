@@ -0,0 +1,177 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"connectrpc.com/connect"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestDetectProtocol(t *testing.T) {
+	tests := []struct {
+		method, target, contentType string
+		want                        Protocol
+	}{
+		{http.MethodPost, "/pkg.Service/Method", "application/grpc", ProtocolGRPC},
+		{http.MethodPost, "/pkg.Service/Method", "application/grpc+proto", ProtocolGRPC},
+		{http.MethodPost, "/pkg.Service/Method", "application/grpc-web+proto", ProtocolGRPCWeb},
+		{http.MethodPost, "/pkg.Service/Method", "application/grpc-web-text", ProtocolGRPCWeb},
+		{http.MethodPost, "/pkg.Service/Method", "application/connect+json", ProtocolConnectStreaming},
+		{http.MethodPost, "/pkg.Service/Method", "application/proto", ProtocolConnectUnary},
+		{http.MethodPost, "/pkg.Service/Method", "application/json; charset=utf-8", ProtocolConnectUnary},
+		{http.MethodGet, "/pkg.Service/Method?encoding=json&message={}", "", ProtocolConnectGet},
+		{http.MethodGet, "/healthz", "", ProtocolUnknown},
+		{http.MethodPost, "/upload", "text/plain", ProtocolUnknown},
+		{http.MethodPut, "/pkg.Service/Method", "application/grpc", ProtocolUnknown},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.target, nil)
+		if tt.contentType != "" {
+			req.Header.Set("Content-Type", tt.contentType)
+		}
+		if got := DetectProtocol(req); got != tt.want {
+			t.Errorf("DetectProtocol(%s %s, %q) = %q, want %q", tt.method, tt.target, tt.contentType, got, tt.want)
+		}
+	}
+}
+
+// headerEcho is a health server that also returns an incoming header as a response header,
+// so the test sees metadata cross the adapter in both directions
+type headerEcho struct {
+	*health.Server
+}
+
+func (h headerEcho) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("x-echo"); len(values) > 0 {
+		grpc.SetHeader(ctx, metadata.Pairs("x-echo", values[0]))
+	}
+	return h.Server.Check(ctx, req)
+}
+
+// newMuxServer serves a health service over every protocol through a Mux, plus a plain
+// JSON endpoint sharing the Connect unary content type
+func newMuxServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	impl := headerEcho{health.NewServer()}
+
+	grpcServer := NewServer()
+	adapter := NewConnectAdapter()
+	for _, registrar := range []grpc.ServiceRegistrar{grpcServer, adapter} {
+		healthpb.RegisterHealthServer(registrar, impl)
+	}
+
+	mux := NewMux(grpcServer, adapter)
+	mux.HandleFunc("POST /api/echo", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Handler", "plain")
+		io.Copy(w, r.Body)
+	})
+
+	srv := httptest.NewUnstartedServer(mux)
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestMuxServesEveryProtocol(t *testing.T) {
+	srv := newMuxServer(t)
+	ctx := context.Background()
+	want := healthpb.HealthCheckResponse_SERVING
+
+	// gRPC, to the gRPC server
+	conn, err := grpc.NewClient(strings.TrimPrefix(srv.URL, "https://"),
+		grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{InsecureSkipVerify: true})))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	defer conn.Close()
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil || resp.Status != want {
+		t.Errorf("gRPC Check = %v, %v; want %s", resp, err, want)
+	}
+
+	// Connect (JSON, through the adapter's codecs) and gRPC-Web, to the adapter
+	checkURL := srv.URL + "/grpc.health.v1.Health/Check"
+	for name, opts := range map[string][]connect.ClientOption{
+		"connect":  {connect.WithProtoJSON()},
+		"grpc-web": {connect.WithGRPCWeb()},
+	} {
+		client := connect.NewClient[healthpb.HealthCheckRequest, healthpb.HealthCheckResponse](srv.Client(), checkURL, opts...)
+		req := connect.NewRequest(&healthpb.HealthCheckRequest{})
+		req.Header().Set("X-Echo", name)
+		resp, err := client.CallUnary(ctx, req)
+		if err != nil {
+			t.Errorf("%s Check: %v", name, err)
+			continue
+		}
+		if resp.Msg.Status != want {
+			t.Errorf("%s Check = %s, want %s", name, resp.Msg.Status, want)
+		}
+		if got := resp.Header().Get("X-Echo"); got != name {
+			t.Errorf("%s Check header X-Echo = %q, want %q", name, got, name)
+		}
+	}
+
+	// A server stream over the Connect streaming protocol
+	watch := connect.NewClient[healthpb.HealthCheckRequest, healthpb.HealthCheckResponse](
+		srv.Client(), srv.URL+"/grpc.health.v1.Health/Watch")
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := watch.CallServerStream(streamCtx, connect.NewRequest(&healthpb.HealthCheckRequest{}))
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	if !stream.Receive() {
+		t.Fatalf("Watch received nothing: %v", stream.Err())
+	}
+	if stream.Msg().Status != want {
+		t.Errorf("Watch = %s, want %s", stream.Msg().Status, want)
+	}
+}
+
+func TestMuxRoutesPlainHandlers(t *testing.T) {
+	srv := newMuxServer(t)
+
+	tests := []struct {
+		method, path, contentType string
+		wantStatus                int
+		wantHandler               string
+	}{
+		// A plain handler takes precedence over Connect unary for its path
+		{http.MethodPost, "/api/echo", "application/json", http.StatusOK, "plain"},
+		{http.MethodPost, "/upload", "text/plain", http.StatusUnsupportedMediaType, ""},
+		{http.MethodGet, "/missing", "", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest(tt.method, srv.URL+tt.path, strings.NewReader("{}"))
+		if err != nil {
+			t.Fatalf("NewRequest: %v", err)
+		}
+		if tt.contentType != "" {
+			req.Header.Set("Content-Type", tt.contentType)
+		}
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", tt.method, tt.path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.wantStatus || resp.Header.Get("X-Handler") != tt.wantHandler {
+			t.Errorf("%s %s (%s) = %d from %q, want %d from %q", tt.method, tt.path, tt.contentType,
+				resp.StatusCode, resp.Header.Get("X-Handler"), tt.wantStatus, tt.wantHandler)
+		}
+		if resp.StatusCode == http.StatusUnsupportedMediaType && resp.Header.Get("Accept-Post") == "" {
+			t.Errorf("%s %s: 415 without Accept-Post", tt.method, tt.path)
+		}
+	}
+}
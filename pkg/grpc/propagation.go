@@ -0,0 +1,218 @@
+package grpc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"connectrpc.com/connect"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// RequestIDHeader carries the request ID across service hops and is echoed in responses
+	RequestIDHeader = "x-request-id"
+
+	authorizationHeader = "authorization"
+)
+
+// traceHeaders are the W3C trace context headers forwarded to downstream calls
+var traceHeaders = []string{"traceparent", "tracestate"}
+
+// propagated holds the incoming headers that outgoing calls forward
+type propagated struct {
+	requestID     string
+	trace         map[string]string
+	authorization string
+}
+
+type propagatedKey struct{}
+
+// NewRequestID returns a random request ID
+func NewRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// WithRequestID returns a context whose outgoing calls carry the given request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	p := fromContext(ctx)
+	p.requestID = id
+	return context.WithValue(ctx, propagatedKey{}, &p)
+}
+
+// RequestIDFromContext returns the request ID of the call being served, if any
+func RequestIDFromContext(ctx context.Context) string {
+	return fromContext(ctx).requestID
+}
+
+// TraceIDFromContext returns the W3C trace ID of the call being served, if any
+func TraceIDFromContext(ctx context.Context) string {
+	// traceparent: version-traceid-parentid-flags
+	parts := strings.Split(fromContext(ctx).trace["traceparent"], "-")
+	if len(parts) < 4 || len(parts[1]) != 32 {
+		return ""
+	}
+	return parts[1]
+}
+
+func fromContext(ctx context.Context) propagated {
+	if p, ok := ctx.Value(propagatedKey{}).(*propagated); ok {
+		return *p
+	}
+	return propagated{}
+}
+
+// incoming stores the propagated headers of an inbound call, assigning a request ID if missing
+func incoming(ctx context.Context, get func(string) string) context.Context {
+	p := &propagated{
+		requestID:     get(RequestIDHeader),
+		authorization: get(authorizationHeader),
+	}
+	if p.requestID == "" {
+		p.requestID = NewRequestID()
+	}
+	for _, key := range traceHeaders {
+		if v := get(key); v != "" {
+			if p.trace == nil {
+				p.trace = map[string]string{}
+			}
+			p.trace[key] = v
+		}
+	}
+	return context.WithValue(ctx, propagatedKey{}, p)
+}
+
+// outgoing returns the headers to send on a downstream call made with ctx
+func outgoing(ctx context.Context, propagateAuth bool) map[string]string {
+	p := fromContext(ctx)
+	headers := make(map[string]string, len(p.trace)+2)
+	for key, v := range p.trace {
+		headers[key] = v
+	}
+	headers[RequestIDHeader] = p.requestID
+	if headers[RequestIDHeader] == "" {
+		headers[RequestIDHeader] = NewRequestID()
+	}
+	if propagateAuth && p.authorization != "" {
+		headers[authorizationHeader] = p.authorization
+	}
+	return headers
+}
+
+func metadataGetter(ctx context.Context) func(string) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return func(key string) string {
+		if values := md.Get(key); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+}
+
+// UnaryServerInterceptor records the request ID, trace context and authorization of
+// incoming calls so client connections created with Dial forward them downstream
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx = incoming(ctx, metadataGetter(ctx))
+		grpc.SetHeader(ctx, metadata.Pairs(RequestIDHeader, RequestIDFromContext(ctx)))
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart of UnaryServerInterceptor
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := incoming(ss.Context(), metadataGetter(ss.Context()))
+		ss.SetHeader(metadata.Pairs(RequestIDHeader, RequestIDFromContext(ctx)))
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// Middleware applies the same propagation to Connect handlers
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := incoming(r.Context(), r.Header.Get)
+		w.Header().Set(RequestIDHeader, RequestIDFromContext(ctx))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// UnaryClientInterceptor forwards the propagated headers of ctx on outgoing calls.
+// The caller's authorization is only forwarded when propagateAuth is set.
+func UnaryClientInterceptor(propagateAuth bool) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(appendOutgoing(ctx, propagateAuth), method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor is the streaming counterpart of UnaryClientInterceptor
+func StreamClientInterceptor(propagateAuth bool) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(appendOutgoing(ctx, propagateAuth), desc, cc, method, opts...)
+	}
+}
+
+// appendOutgoing adds the propagated headers that the caller hasn't set explicitly
+func appendOutgoing(ctx context.Context, propagateAuth bool) context.Context {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	for key, v := range outgoing(ctx, propagateAuth) {
+		if len(md.Get(key)) == 0 {
+			ctx = metadata.AppendToOutgoingContext(ctx, key, v)
+		}
+	}
+	return ctx
+}
+
+// connectPropagator forwards the propagated headers on Connect client calls
+type connectPropagator struct {
+	propagateAuth bool
+}
+
+// ConnectClientInterceptor is the Connect client counterpart of UnaryClientInterceptor
+func ConnectClientInterceptor(propagateAuth bool) connect.Interceptor {
+	return &connectPropagator{propagateAuth: propagateAuth}
+}
+
+func (p *connectPropagator) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if req.Spec().IsClient {
+			setHeaders(req.Header(), outgoing(ctx, p.propagateAuth))
+		}
+		return next(ctx, req)
+	}
+}
+
+func (p *connectPropagator) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+		conn := next(ctx, spec)
+		setHeaders(conn.RequestHeader(), outgoing(ctx, p.propagateAuth))
+		return conn
+	}
+}
+
+func (p *connectPropagator) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return next
+}
+
+func setHeaders(h http.Header, headers map[string]string) {
+	for key, v := range headers {
+		if h.Get(key) == "" {
+			h.Set(key, v)
+		}
+	}
+}
+
+// contextStream overrides the context of a server stream
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
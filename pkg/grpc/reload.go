@@ -0,0 +1,236 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/metrics"
+)
+
+// DefaultReloadInterval is how often certificate files are checked for changes
+const DefaultReloadInterval = 30 * time.Second
+
+// Certificates expiring sooner than this are logged as warnings
+const certExpiryWarning = 7 * 24 * time.Hour
+
+var (
+	certExpiryMetric = metrics.Map("tls_cert_expiry_timestamp_seconds")
+	certReloadMetric = metrics.Map("tls_cert_reloads_total")
+	certErrorMetric  = metrics.Map("tls_cert_reload_errors_total")
+)
+
+// CertReloader serves the server certificate and mTLS client CA pool from files,
+// swapping in new versions when the files change without restarting the server
+type CertReloader struct {
+	config TLSConfig
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	notAfter  time.Time
+	stamps    map[string]fileStamp
+	warnedAt  time.Time
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewCertReloader loads the certificate, key and CA files and returns a reloader for them
+func NewCertReloader(config TLSConfig) (*CertReloader, error) {
+	r := &CertReloader{config: config}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload reads the files again and swaps in the new certificate and CA pool.
+// The new pair is validated first; on error the current certificate stays in use.
+func (r *CertReloader) Reload() error {
+	stamps := r.stampFiles()
+
+	cert, err := tls.LoadX509KeyPair(r.config.CertFile, r.config.KeyFile)
+	if err != nil {
+		certErrorMetric.Add(r.config.CertFile, 1)
+		return fmt.Errorf("failed to load server certificate: %w", err)
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		certErrorMetric.Add(r.config.CertFile, 1)
+		return fmt.Errorf("failed to parse server certificate: %w", err)
+	}
+	now := time.Now()
+	if now.After(leaf.NotAfter) {
+		certErrorMetric.Add(r.config.CertFile, 1)
+		return fmt.Errorf("server certificate expired at %s", leaf.NotAfter.Format(time.RFC3339))
+	}
+	if now.Before(leaf.NotBefore) {
+		certErrorMetric.Add(r.config.CertFile, 1)
+		return fmt.Errorf("server certificate not valid before %s", leaf.NotBefore.Format(time.RFC3339))
+	}
+	cert.Leaf = leaf
+
+	var clientCAs *x509.CertPool
+	if r.config.ClientAuth && r.config.CAFile != "" {
+		caCert, err := os.ReadFile(r.config.CAFile)
+		if err != nil {
+			certErrorMetric.Add(r.config.CertFile, 1)
+			return fmt.Errorf("failed to read CA certificate: %w", err)
+		}
+
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(caCert) {
+			certErrorMetric.Add(r.config.CertFile, 1)
+			return fmt.Errorf("failed to append CA certificate")
+		}
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.clientCAs = clientCAs
+	r.notAfter = leaf.NotAfter
+	r.stamps = stamps
+	r.mu.Unlock()
+
+	certReloadMetric.Add(r.config.CertFile, 1)
+	metrics.SetFloat(certExpiryMetric, r.config.CertFile, float64(leaf.NotAfter.Unix()))
+	log.Printf("Loaded TLS certificate %s (CN=%s, expires %s)",
+		r.config.CertFile, leaf.Subject.CommonName, leaf.NotAfter.Format(time.RFC3339))
+	r.checkExpiry(now)
+
+	return nil
+}
+
+// Watch polls the files every interval and reloads them when they change, until ctx is done
+func (r *CertReloader) Watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultReloadInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if r.changed() {
+				if err := r.Reload(); err != nil {
+					log.Printf("TLS certificate reload failed, keeping current certificate: %v", err)
+					// Don't retry until the files change again
+					stamps := r.stampFiles()
+					r.mu.Lock()
+					r.stamps = stamps
+					r.mu.Unlock()
+				}
+			}
+			r.checkExpiry(now)
+		}
+	}
+}
+
+// GetCertificate returns the current server certificate (for tls.Config.GetCertificate)
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// NotAfter returns the expiry time of the current certificate
+func (r *CertReloader) NotAfter() time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.notAfter
+}
+
+// TLSConfig returns a server tls.Config that always uses the current certificate and client CAs.
+// Listeners sharing the reloader get their own config through TLSConfig.Reloader.
+func (r *CertReloader) TLSConfig() *tls.Config {
+	return r.serverTLSConfig(r.config.ClientAuth)
+}
+
+// serverTLSConfig returns a config for one listener, which may or may not require client
+// certificates; clientAuth needs a reloader that loads the client CAs
+func (r *CertReloader) serverTLSConfig(clientAuth bool) *tls.Config {
+	config := newBaseServerTLSConfig()
+	config.GetCertificate = r.GetCertificate
+	if clientAuth && r.config.ClientAuth && r.config.CAFile != "" {
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	// The per-connection config is cloned lazily so settings added after this call,
+	// such as the ALPN protocols from http2.ConfigureServer, are carried over
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		perConn := config.Clone()
+		perConn.GetConfigForClient = nil
+		if !slices.Contains(perConn.NextProtos, "h2") {
+			perConn.NextProtos = append([]string{"h2"}, perConn.NextProtos...)
+		}
+
+		r.mu.RLock()
+		perConn.ClientCAs = r.clientCAs
+		r.mu.RUnlock()
+		return perConn, nil
+	}
+	return config
+}
+
+func (r *CertReloader) files() []string {
+	files := []string{r.config.CertFile, r.config.KeyFile}
+	if r.config.ClientAuth && r.config.CAFile != "" {
+		files = append(files, r.config.CAFile)
+	}
+	return files
+}
+
+func (r *CertReloader) stampFiles() map[string]fileStamp {
+	stamps := map[string]fileStamp{}
+	for _, file := range r.files() {
+		if info, err := os.Stat(file); err == nil {
+			stamps[file] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+	return stamps
+}
+
+func (r *CertReloader) changed() bool {
+	current := r.stampFiles()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(current) != len(r.stamps) {
+		return true
+	}
+	for file, stamp := range current {
+		if r.stamps[file] != stamp {
+			return true
+		}
+	}
+	return false
+}
+
+// checkExpiry warns about a certificate close to expiry, at most once a day
+func (r *CertReloader) checkExpiry(now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	remaining := r.notAfter.Sub(now)
+	if remaining > certExpiryWarning || now.Sub(r.warnedAt) < 24*time.Hour {
+		return
+	}
+	r.warnedAt = now
+	log.Printf("WARNING: TLS certificate %s expires in %s (at %s)",
+		r.config.CertFile, remaining.Round(time.Minute), r.notAfter.Format(time.RFC3339))
+}
@@ -6,20 +6,23 @@ import (
 	"net"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/reflection"
 )
 
+// MaxMessageSize is the largest message servers accept and send, and clients accept
+const MaxMessageSize = 10 * 1024 * 1024 // 10MB
+
 // ServerConfig holds gRPC server configuration
 type ServerConfig struct {
 	Port string
 }
 
-// NewServer creates and configures a new gRPC server
-func NewServer() *grpc.Server {
-	opts := []grpc.ServerOption{
-		grpc.MaxRecvMsgSize(10 * 1024 * 1024), // 10MB
-		grpc.MaxSendMsgSize(10 * 1024 * 1024), // 10MB
-	}
+// NewServer creates and configures a new gRPC server.
+// Extra options, such as interceptors, are applied after the defaults.
+func NewServer(extraOpts ...grpc.ServerOption) *grpc.Server {
+	opts := defaultServerOptions()
+	opts = append(opts, extraOpts...)
 	
 	server := grpc.NewServer(opts...)
 	
@@ -29,6 +32,22 @@ func NewServer() *grpc.Server {
 	return server
 }
 
+// defaultServerOptions returns the options shared by all servers: message size limits,
+// a keepalive policy accepting the pings of clients created with Dial, and the
+// request ID / trace context propagation interceptors
+func defaultServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(MaxMessageSize),
+		grpc.MaxSendMsgSize(MaxMessageSize),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             DefaultKeepaliveTime / 2,
+			PermitWithoutStream: true,
+		}),
+		grpc.ChainUnaryInterceptor(UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(StreamServerInterceptor()),
+	}
+}
+
 // StartServer starts the gRPC server on the specified port
 func StartServer(server *grpc.Server, port string) error {
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%s", port))
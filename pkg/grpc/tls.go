@@ -5,6 +5,7 @@ import (
 	"crypto/x509"
 	"fmt"
 	"os"
+	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
@@ -16,6 +17,14 @@ type TLSConfig struct {
 	KeyFile    string // Path to the server private key file
 	CAFile     string // Path to the CA certificate file (optional, for mTLS)
 	ClientAuth bool   // Whether to require client certificate authentication (mTLS)
+
+	// ReloadInterval enables certificate hot-reload: the files are checked for changes
+	// at this interval and swapped in without restarting (0 disables reloading)
+	ReloadInterval time.Duration
+
+	// Reloader, if set, serves the certificate and client CAs of a CertReloader shared by
+	// several listeners, which its owner watches; the files and ReloadInterval are ignored
+	Reloader *CertReloader
 }
 
 // GetSecureCipherSuites returns a list of secure cipher suites
@@ -35,16 +44,9 @@ func GetSecureCipherSuites() []uint16 {
 	}
 }
 
-// NewServerTLSConfig creates a secure TLS configuration for the server
-func NewServerTLSConfig(config TLSConfig) (*tls.Config, error) {
-	// Load server certificate and key
-	cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load server certificate: %w", err)
-	}
-
-	tlsConfig := &tls.Config{
-		Certificates: []tls.Certificate{cert},
+// newBaseServerTLSConfig returns the protocol settings shared by all server TLS configurations
+func newBaseServerTLSConfig() *tls.Config {
+	return &tls.Config{
 		MinVersion:   tls.VersionTLS12, // Require TLS 1.2 or higher
 		CipherSuites: GetSecureCipherSuites(),
 		// Prefer server's cipher suite order
@@ -56,6 +58,30 @@ func NewServerTLSConfig(config TLSConfig) (*tls.Config, error) {
 			tls.CurveP384,
 		},
 	}
+}
+
+// NewServerTLSConfig creates a secure TLS configuration for the server. Hot-reload needs
+// a watched CertReloader in config.Reloader: a ReloadInterval alone is an error, as the
+// returned config has no way to stop a watch.
+func NewServerTLSConfig(config TLSConfig) (*tls.Config, error) {
+	if config.Reloader != nil {
+		if config.ClientAuth && !config.Reloader.config.ClientAuth {
+			return nil, fmt.Errorf("client authentication requires a certificate reloader that loads the client CAs")
+		}
+		return config.Reloader.serverTLSConfig(config.ClientAuth), nil
+	}
+	if config.ReloadInterval > 0 {
+		return nil, fmt.Errorf("certificate reloading requires a CertReloader: create one with NewCertReloader, Watch it and set TLSConfig.Reloader")
+	}
+
+	// Load server certificate and key
+	cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server certificate: %w", err)
+	}
+
+	tlsConfig := newBaseServerTLSConfig()
+	tlsConfig.Certificates = []tls.Certificate{cert}
 
 	// Configure mutual TLS (mTLS) if requested
 	if config.ClientAuth && config.CAFile != "" {
@@ -76,8 +102,9 @@ func NewServerTLSConfig(config TLSConfig) (*tls.Config, error) {
 	return tlsConfig, nil
 }
 
-// NewSecureGRPCServer creates a gRPC server with TLS enabled
-func NewSecureGRPCServer(tlsConfig TLSConfig) (*grpc.Server, error) {
+// NewSecureGRPCServer creates a gRPC server with TLS enabled.
+// Extra options, such as interceptors, are applied after the defaults.
+func NewSecureGRPCServer(tlsConfig TLSConfig, extraOpts ...grpc.ServerOption) (*grpc.Server, error) {
 	serverTLSConfig, err := NewServerTLSConfig(tlsConfig)
 	if err != nil {
 		return nil, err
@@ -85,23 +112,23 @@ func NewSecureGRPCServer(tlsConfig TLSConfig) (*grpc.Server, error) {
 
 	creds := credentials.NewTLS(serverTLSConfig)
 
-	opts := []grpc.ServerOption{
-		grpc.Creds(creds),
-		grpc.MaxRecvMsgSize(10 * 1024 * 1024), // 10MB
-		grpc.MaxSendMsgSize(10 * 1024 * 1024), // 10MB
-	}
+	opts := append(defaultServerOptions(), grpc.Creds(creds))
+	opts = append(opts, extraOpts...)
 
 	return grpc.NewServer(opts...), nil
 }
 
-// NewClientTLSConfig creates a secure TLS configuration for gRPC clients
+// NewClientTLSConfig creates secure transport credentials for gRPC clients
 func NewClientTLSConfig(caFile string, serverNameOverride string) (credentials.TransportCredentials, error) {
-	if caFile == "" {
-		// Use system CA pool
-		return credentials.NewClientTLSFromCert(nil, serverNameOverride), nil
+	tlsConfig, err := NewClientTLS(TLSConfig{CAFile: caFile}, serverNameOverride)
+	if err != nil {
+		return nil, err
 	}
+	return credentials.NewTLS(tlsConfig), nil
+}
 
-	// Load CA certificate
+// loadCertPool reads a PEM bundle of CA certificates
+func loadCertPool(caFile string) (*x509.CertPool, error) {
 	caCert, err := os.ReadFile(caFile)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read CA certificate: %w", err)
@@ -111,12 +138,5 @@ func NewClientTLSConfig(caFile string, serverNameOverride string) (credentials.T
 	if !caCertPool.AppendCertsFromPEM(caCert) {
 		return nil, fmt.Errorf("failed to append CA certificate")
 	}
-
-	tlsConfig := &tls.Config{
-		RootCAs:    caCertPool,
-		MinVersion: tls.VersionTLS13,
-		ServerName: serverNameOverride,
-	}
-
-	return credentials.NewTLS(tlsConfig), nil
+	return caCertPool, nil
 }
@@ -0,0 +1,65 @@
+package loadshed
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// gradient is an adaptive concurrency limit in the style of Netflix's gradient2 algorithm.
+// It compares a short-term latency sample with a slowly moving long-term average:
+// when latency rises above the tolerated ratio the limit shrinks proportionally,
+// otherwise it grows by a queue allowance of sqrt(limit).
+type gradient struct {
+	cfg Config
+
+	mu      sync.Mutex
+	limit   float64
+	longRTT float64 // Exponential moving average of latency, in seconds
+}
+
+func newGradient(cfg Config) *gradient {
+	return &gradient{cfg: cfg, limit: float64(cfg.InitialLimit)}
+}
+
+// current returns the limit as a number of concurrent calls
+func (g *gradient) current() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return int(g.limit)
+}
+
+// sample updates the limit with the latency of a completed call. dropped marks calls
+// that failed in a way that signals overload, such as running out of time.
+func (g *gradient) sample(rtt time.Duration, inFlight int, dropped bool) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	short := rtt.Seconds()
+	if short <= 0 {
+		return int(g.limit)
+	}
+	if g.longRTT == 0 {
+		g.longRTT = short
+	}
+	g.longRTT = g.longRTT*0.99 + short*0.01
+	if g.longRTT/short > 2 {
+		// Latency dropped a lot (e.g. after an incident); let the baseline catch up faster
+		g.longRTT *= 0.95
+	}
+
+	// Don't grow the limit while the server isn't using it; that's not evidence it can cope
+	if !dropped && float64(inFlight) < g.limit/2 {
+		return int(g.limit)
+	}
+
+	ratio := 0.5
+	if !dropped {
+		ratio = math.Max(0.5, math.Min(1, g.cfg.Tolerance*g.longRTT/short))
+	}
+	next := g.limit*ratio + math.Sqrt(g.limit)
+
+	g.limit = g.limit*(1-g.cfg.Smoothing) + next*g.cfg.Smoothing
+	g.limit = math.Max(float64(g.cfg.MinLimit), math.Min(float64(g.cfg.MaxLimit), g.limit))
+	return int(g.limit)
+}
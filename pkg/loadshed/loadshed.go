@@ -0,0 +1,399 @@
+package loadshed
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	grpcpkg "github.com/LucasPluta/GoMicroserviceFramework/pkg/grpc"
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/metrics"
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/mtls"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// Priority is the importance class of a request, read from the PriorityHeader. Callers
+// may lower their priority, but only TrustedPeers may raise it above normal.
+type Priority string
+
+const (
+	PriorityCritical Priority = "critical" // May exceed the limit up to MaxLimit; from trusted peers only
+	PriorityHigh     Priority = "high"     // May use the whole limit; from trusted peers only
+	PriorityNormal   Priority = "normal"   // Default; may use 90% of the limit
+	PriorityLow      Priority = "low"      // Sheddable; may use 50% of the limit
+)
+
+// DefaultPriorityHeader is the metadata key / HTTP header carrying the priority
+const DefaultPriorityHeader = "x-priority"
+
+// share is the fraction of the limit each priority may fill before it is shed,
+// so low priority traffic is rejected first as the server approaches its limit
+var share = map[Priority]float64{
+	PriorityHigh:   1.0,
+	PriorityNormal: 0.9,
+	PriorityLow:    0.5,
+}
+
+var (
+	limitMetric    = metrics.Map("concurrency_limit")
+	inFlightMetric = metrics.Map("concurrency_in_flight")
+	streamsMetric  = metrics.Map("concurrency_streams")
+	shedMetric     = metrics.Map("requests_shed_total")
+)
+
+// Config holds the limiter settings
+type Config struct {
+	Name string // Labels the metrics (default: "server")
+
+	InitialLimit int     // Default: 20
+	MinLimit     int     // Default: 5
+	MaxLimit     int     // Default: 1000
+	Smoothing    float64 // Weight of each update, 0-1 (default: 0.2)
+	Tolerance    float64 // Latency increase tolerated before shrinking the limit (default: 1.5)
+
+	PriorityHeader string // Default: DefaultPriorityHeader
+
+	// TrustedPeers lists the SPIFFE IDs (patterns as in mTLS policies) of the peers whose
+	// high and critical priorities are honored; from any other caller, including
+	// browsers and peers without a verified client certificate, they count as normal
+	TrustedPeers []string
+
+	// Exempt lists method patterns that are never shed, e.g. critical RPCs.
+	// Health checks ("/grpc.health.v1.Health/*") are always exempt.
+	Exempt []string
+
+	// RejectCode is returned for shed calls: ResourceExhausted (default) or Unavailable
+	RejectCode codes.Code
+
+	// MaxStreams bounds the open streams (default: 0, unbounded). Streams are shed on
+	// opening like unary calls, but don't hold a slot of the concurrency limit while open:
+	// long-lived subscriptions would fill it and starve unary traffic.
+	MaxStreams int
+}
+
+func (c Config) withDefaults() Config {
+	if c.Name == "" {
+		c.Name = "server"
+	}
+	if c.InitialLimit <= 0 {
+		c.InitialLimit = 20
+	}
+	if c.MinLimit <= 0 {
+		c.MinLimit = 5
+	}
+	if c.MaxLimit <= 0 {
+		c.MaxLimit = 1000
+	}
+	if c.Smoothing <= 0 || c.Smoothing > 1 {
+		c.Smoothing = 0.2
+	}
+	if c.Tolerance < 1 {
+		c.Tolerance = 1.5
+	}
+	if c.PriorityHeader == "" {
+		c.PriorityHeader = DefaultPriorityHeader
+	}
+	if c.RejectCode == codes.OK {
+		c.RejectCode = codes.ResourceExhausted
+	}
+	c.Exempt = append([]string{"/grpc.health.v1.Health/*"}, c.Exempt...)
+	return c
+}
+
+// Limiter sheds load above an adaptive concurrency limit
+type Limiter struct {
+	cfg   Config
+	limit *gradient
+
+	mu       sync.Mutex
+	inFlight int // Unary calls
+	streams  int // Open streams
+}
+
+// NewLimiter creates a limiter starting at the initial limit
+func NewLimiter(cfg Config) *Limiter {
+	cfg = cfg.withDefaults()
+	l := &Limiter{cfg: cfg, limit: newGradient(cfg)}
+	metrics.SetFloat(limitMetric, cfg.Name, float64(cfg.InitialLimit))
+	return l
+}
+
+// Limit returns the current concurrency limit
+func (l *Limiter) Limit() int {
+	return l.limit.current()
+}
+
+// acquire admits a unary call or reports that it must be shed. Admitted calls must call
+// release, which feeds the call's latency and outcome to the limit algorithm.
+func (l *Limiter) acquire(method string, priority Priority) (release func(err error), ok bool) {
+	exempt := matchAny(l.cfg.Exempt, method)
+
+	l.mu.Lock()
+	if !exempt {
+		if float64(l.inFlight) >= l.ceiling(priority) {
+			l.mu.Unlock()
+			shedMetric.Add(methodLabel(method)+" "+string(priority), 1)
+			return nil, false
+		}
+	}
+	l.inFlight++
+	inFlight := l.inFlight
+	l.mu.Unlock()
+	metrics.SetFloat(inFlightMetric, l.cfg.Name, float64(inFlight))
+
+	start := time.Now()
+	return func(err error) {
+		l.mu.Lock()
+		l.inFlight--
+		inFlight := l.inFlight
+		l.mu.Unlock()
+		metrics.SetFloat(inFlightMetric, l.cfg.Name, float64(inFlight))
+
+		// Exempt calls don't feed the algorithm: health checks are cheap and would skew latency
+		if exempt {
+			return
+		}
+		code := status.Code(err)
+		dropped := code == codes.DeadlineExceeded || code == codes.ResourceExhausted
+		newLimit := l.limit.sample(time.Since(start), inFlight+1, dropped)
+		metrics.SetFloat(limitMetric, l.cfg.Name, float64(newLimit))
+	}, true
+}
+
+// admitStream admits a stream or reports that it must be shed. New streams are shed when
+// unary calls have filled the priority's share of the limit, or at MaxStreams.
+func (l *Limiter) admitStream(method string, priority Priority) (release func(), ok bool) {
+	exempt := matchAny(l.cfg.Exempt, method)
+
+	l.mu.Lock()
+	if !exempt {
+		overloaded := float64(l.inFlight) >= l.ceiling(priority)
+		if overloaded || (l.cfg.MaxStreams > 0 && l.streams >= l.cfg.MaxStreams) {
+			l.mu.Unlock()
+			shedMetric.Add(methodLabel(method)+" "+string(priority), 1)
+			return nil, false
+		}
+	}
+	l.streams++
+	streams := l.streams
+	l.mu.Unlock()
+	metrics.SetFloat(streamsMetric, l.cfg.Name, float64(streams))
+
+	return func() {
+		l.mu.Lock()
+		l.streams--
+		streams := l.streams
+		l.mu.Unlock()
+		metrics.SetFloat(streamsMetric, l.cfg.Name, float64(streams))
+	}, true
+}
+
+// ceiling returns the number of unary calls in flight above which calls of a priority are
+// shed: its share of the adaptive limit, or MaxLimit for critical calls. Only Exempt
+// methods are never shed.
+func (l *Limiter) ceiling(priority Priority) float64 {
+	if priority == PriorityCritical {
+		return float64(l.cfg.MaxLimit)
+	}
+	fraction, known := share[priority]
+	if !known {
+		fraction = share[PriorityNormal]
+	}
+	return float64(l.limit.current()) * fraction
+}
+
+// priority returns the priority of a call. Unknown values count as normal, as do high
+// and critical unless the caller is a trusted peer: the header is set by the client.
+func (l *Limiter) priority(get func(string) string, id *mtls.Identity) Priority {
+	switch p := Priority(strings.ToLower(get(l.cfg.PriorityHeader))); p {
+	case PriorityLow:
+		return p
+	case PriorityHigh, PriorityCritical:
+		if id != nil && id.SPIFFEID != "" && matchAny(l.cfg.TrustedPeers, id.SPIFFEID) {
+			return p
+		}
+	}
+	return PriorityNormal
+}
+
+func (l *Limiter) rejection(method string) error {
+	return status.Errorf(l.cfg.RejectCode, "server overloaded, %s was shed", method)
+}
+
+// UnaryServerInterceptor sheds unary calls above the limit
+func (l *Limiter) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		release, ok := l.acquire(info.FullMethod, l.priority(metadataGetter(ctx), peerIdentity(ctx)))
+		if !ok {
+			return nil, l.rejection(info.FullMethod)
+		}
+		resp, err := handler(ctx, req)
+		release(err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor sheds new streams while the server is over its limit. Open
+// streams are counted apart from unary calls (see Config.MaxStreams) and their duration
+// is not used as a latency sample.
+func (l *Limiter) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		release, ok := l.admitStream(info.FullMethod, l.priority(metadataGetter(ss.Context()), peerIdentity(ss.Context())))
+		if !ok {
+			return l.rejection(info.FullMethod)
+		}
+		defer release()
+		return handler(srv, ss)
+	}
+}
+
+// ServerOptions returns the interceptors as server options for grpc.NewServer.
+// They should run early so shed calls cost as little as possible.
+func (l *Limiter) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(l.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(l.StreamServerInterceptor()),
+	}
+}
+
+// Middleware sheds Connect and gRPC-Web requests above the limit, answering in the
+// caller's protocol. Streams are handled as by StreamServerInterceptor; the outcome of
+// unary calls, read from the response status, feeds the limit as on the gRPC path.
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, _ := mtls.IdentityFromTLS(r.TLS)
+		priority := l.priority(r.Header.Get, id)
+		if isStreaming(r) {
+			release, ok := l.admitStream(r.URL.Path, priority)
+			if !ok {
+				l.reject(w, r)
+				return
+			}
+			defer release()
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		release, ok := l.acquire(r.URL.Path, priority)
+		if !ok {
+			l.reject(w, r)
+			return
+		}
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		release(rec.err())
+	})
+}
+
+func (l *Limiter) reject(w http.ResponseWriter, r *http.Request) {
+	grpcpkg.WriteError(w, r, l.rejection(r.URL.Path))
+}
+
+// isStreaming reports whether a request calls a streaming method, from the method's
+// descriptor: gRPC-Web uses the same content type for unary and streaming calls. Unknown
+// methods fall back to the Connect streaming content types.
+func isStreaming(r *http.Request) bool {
+	if md := methodDescriptor(r.URL.Path); md != nil {
+		return md.IsStreamingClient() || md.IsStreamingServer()
+	}
+	return strings.HasPrefix(r.Header.Get("Content-Type"), "application/connect+")
+}
+
+// methodDescriptor returns the registered descriptor of a "/package.Service/Method" path, or nil
+func methodDescriptor(fullMethod string) protoreflect.MethodDescriptor {
+	service, method := path.Split(strings.TrimPrefix(fullMethod, "/"))
+	desc, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(strings.TrimSuffix(service, "/")))
+	if err != nil {
+		return nil
+	}
+	sd, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil
+	}
+	return sd.Methods().ByName(protoreflect.Name(method))
+}
+
+// methodLabel labels the metrics of a call: its method when registered, so that request
+// paths can't create metric keys without bound
+func methodLabel(fullMethod string) string {
+	if methodDescriptor(fullMethod) == nil {
+		return "unknown"
+	}
+	return fullMethod
+}
+
+// peerIdentity returns the verified mTLS identity of a gRPC peer, or nil
+func peerIdentity(ctx context.Context) *mtls.Identity {
+	id, _ := mtls.IdentityFromPeer(ctx)
+	return id
+}
+
+// statusRecorder captures the outcome of a unary Connect or gRPC-Web call
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// err returns the error of the call as far as the limiter cares: gRPC-Web failures carry
+// grpc-status in the headers (trailers-only responses), Connect failures an HTTP status
+func (r *statusRecorder) err() error {
+	if s := r.Header().Get("Grpc-Status"); s != "" && s != "0" {
+		code, err := strconv.Atoi(s)
+		if err != nil {
+			return status.Error(codes.Unknown, "")
+		}
+		return status.Error(codes.Code(code), "")
+	}
+	switch r.status {
+	case http.StatusOK:
+		return nil
+	case http.StatusTooManyRequests:
+		return status.Error(codes.ResourceExhausted, "")
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return status.Error(codes.DeadlineExceeded, "")
+	default:
+		return status.Error(codes.Unknown, "")
+	}
+}
+
+func metadataGetter(ctx context.Context) func(string) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return func(key string) string {
+		if values := md.Get(key); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+}
+
+func matchAny(patterns []string, method string) bool {
+	for _, pattern := range patterns {
+		if ok, err := path.Match(pattern, method); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
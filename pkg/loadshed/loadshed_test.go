@@ -0,0 +1,162 @@
+package loadshed
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/mtls"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestStreamsDoNotHoldUnarySlots(t *testing.T) {
+	l := NewLimiter(Config{InitialLimit: 20})
+
+	for i := 0; i < 50; i++ {
+		if _, ok := l.admitStream("/pkg.Service/Watch", PriorityNormal); !ok {
+			t.Fatalf("stream %d was shed", i)
+		}
+	}
+	release, ok := l.acquire("/pkg.Service/Get", PriorityNormal)
+	if !ok {
+		t.Fatal("unary call was shed behind open streams")
+	}
+	release(nil)
+}
+
+func TestMaxStreams(t *testing.T) {
+	l := NewLimiter(Config{MaxStreams: 2})
+
+	var releases []func()
+	for i := 0; i < 2; i++ {
+		release, ok := l.admitStream("/pkg.Service/Watch", PriorityNormal)
+		if !ok {
+			t.Fatalf("stream %d was shed", i)
+		}
+		releases = append(releases, release)
+	}
+	if _, ok := l.admitStream("/pkg.Service/Watch", PriorityNormal); ok {
+		t.Fatal("stream above MaxStreams was admitted")
+	}
+	if _, ok := l.admitStream("/pkg.Service/Watch", PriorityCritical); ok {
+		t.Fatal("critical stream above MaxStreams was admitted")
+	}
+	releases[0]()
+	if _, ok := l.admitStream("/pkg.Service/Watch", PriorityNormal); !ok {
+		t.Fatal("stream was shed after another closed")
+	}
+}
+
+func TestMiddlewareFeedsFailures(t *testing.T) {
+	l := NewLimiter(Config{InitialLimit: 20})
+	handler := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests) // Connect resource_exhausted
+	}))
+
+	for i := 0; i < 10; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/pkg.Service/Get", strings.NewReader("{}"))
+		req.Header.Set("Content-Type", "application/json")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if limit := l.Limit(); limit >= 20 {
+		t.Errorf("limit = %d after overload responses, want it below 20", limit)
+	}
+}
+
+func TestCriticalIsBoundedByMaxLimit(t *testing.T) {
+	l := NewLimiter(Config{InitialLimit: 1, MinLimit: 1, MaxLimit: 2, Exempt: []string{"/pkg.Service/Exempt"}})
+
+	if _, ok := l.acquire("/pkg.Service/Get", PriorityNormal); !ok {
+		t.Fatal("first call was shed")
+	}
+	if _, ok := l.acquire("/pkg.Service/Get", PriorityHigh); ok {
+		t.Fatal("high call above the limit was admitted")
+	}
+	if _, ok := l.acquire("/pkg.Service/Get", PriorityCritical); !ok {
+		t.Fatal("critical call below MaxLimit was shed")
+	}
+	if _, ok := l.acquire("/pkg.Service/Get", PriorityCritical); ok {
+		t.Fatal("critical call at MaxLimit was admitted")
+	}
+	if _, ok := l.acquire("/pkg.Service/Exempt", PriorityLow); !ok {
+		t.Fatal("exempt call was shed")
+	}
+}
+
+func TestPriorityTrust(t *testing.T) {
+	l := NewLimiter(Config{TrustedPeers: []string{"spiffe://gomicroservice.local/gateway-service"}})
+	trusted := &mtls.Identity{SPIFFEID: "spiffe://gomicroservice.local/gateway-service"}
+	other := &mtls.Identity{SPIFFEID: "spiffe://gomicroservice.local/batch-job"}
+
+	tests := []struct {
+		header string
+		id     *mtls.Identity
+		want   Priority
+	}{
+		{"", nil, PriorityNormal},
+		{"low", nil, PriorityLow},
+		{"critical", nil, PriorityNormal},
+		{"HIGH", other, PriorityNormal},
+		{"critical", trusted, PriorityCritical},
+		{"high", trusted, PriorityHigh},
+		{"urgent!!", trusted, PriorityNormal},
+	}
+	for _, tt := range tests {
+		get := func(string) string { return tt.header }
+		if got := l.priority(get, tt.id); got != tt.want {
+			t.Errorf("priority(%q, %v) = %q, want %q", tt.header, tt.id, got, tt.want)
+		}
+	}
+
+	// An untrusted browser claiming critical is shed like any other call
+	l = NewLimiter(Config{InitialLimit: 1, MinLimit: 1})
+	handler := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := httptest.NewRequest(http.MethodPost, "/pkg.Service/Get", strings.NewReader("{}"))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(DefaultPriorityHeader, "critical")
+		rec := httptest.NewRecorder()
+		l.Middleware(http.NotFoundHandler()).ServeHTTP(rec, req)
+		if rec.Code != http.StatusTooManyRequests {
+			t.Errorf("nested call claiming critical = %d, want 429", rec.Code)
+		}
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/pkg.Service/Get", strings.NewReader("{}"))
+	req.Header.Set("Content-Type", "application/json")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func TestMethodLabel(t *testing.T) {
+	_ = healthpb.File_grpc_health_v1_health_proto // Registers the descriptors
+
+	if got := methodLabel("/grpc.health.v1.Health/Check"); got != "/grpc.health.v1.Health/Check" {
+		t.Errorf("methodLabel(registered) = %q", got)
+	}
+	for _, path := range []string{"/random/path/123", "/grpc.health.v1.Health/Nope", "/"} {
+		if got := methodLabel(path); got != "unknown" {
+			t.Errorf("methodLabel(%q) = %q, want unknown", path, got)
+		}
+	}
+}
+
+func TestIsStreaming(t *testing.T) {
+	_ = healthpb.File_grpc_health_v1_health_proto // Registers the descriptors
+
+	tests := []struct {
+		path, contentType string
+		want              bool
+	}{
+		{"/grpc.health.v1.Health/Check", "application/grpc-web+proto", false},
+		{"/grpc.health.v1.Health/Watch", "application/grpc-web+proto", true},
+		{"/grpc.health.v1.Health/Watch", "application/json", true},
+		{"/unknown.Service/Method", "application/connect+proto", true},
+		{"/unknown.Service/Method", "application/proto", false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, tt.path, nil)
+		req.Header.Set("Content-Type", tt.contentType)
+		if got := isStreaming(req); got != tt.want {
+			t.Errorf("isStreaming(%s, %s) = %v, want %v", tt.path, tt.contentType, got, tt.want)
+		}
+	}
+}
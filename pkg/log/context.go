@@ -0,0 +1,67 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+
+	grpcpkg "github.com/LucasPluta/GoMicroserviceFramework/pkg/grpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+)
+
+type attrsKey struct{}
+
+// With returns a context whose records carry args (key-value pairs or slog.Attrs) in
+// addition to the ones of ctx, e.g. log.With(ctx, "user_id", id)
+func With(ctx context.Context, args ...any) context.Context {
+	record := slog.Record{}
+	record.Add(args...)
+	attrs := append([]slog.Attr(nil), attrsFromContext(ctx)...)
+	record.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	return context.WithValue(ctx, attrsKey{}, attrs)
+}
+
+func attrsFromContext(ctx context.Context) []slog.Attr {
+	attrs, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	return attrs
+}
+
+// contextHandler adds the fields of the call in the record's context: request ID, trace
+// ID, method, peer, and the attributes added with With
+type contextHandler struct {
+	next slog.Handler
+}
+
+func (h *contextHandler) Enabled(ctx context.Context, lvl slog.Level) bool {
+	return h.next.Enabled(ctx, lvl)
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		if id := grpcpkg.RequestIDFromContext(ctx); id != "" {
+			r.AddAttrs(slog.String("request_id", id))
+		}
+		if id := grpcpkg.TraceIDFromContext(ctx); id != "" {
+			r.AddAttrs(slog.String("trace_id", id))
+		}
+		if method, ok := grpc.Method(ctx); ok {
+			r.AddAttrs(slog.String("method", method))
+		}
+		if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+			r.AddAttrs(slog.String("peer", p.Addr.String()))
+		}
+		r.AddAttrs(attrsFromContext(ctx)...)
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{next: h.next.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{next: h.next.WithGroup(name)}
+}
@@ -0,0 +1,124 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+	"path"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// CallOptions configures the call logging interceptors
+type CallOptions struct {
+	// Payloads logs requests, responses and stream messages at debug level, with their
+	// sensitive fields redacted
+	Payloads bool
+	// Skip lists method patterns that are not logged, such as "/grpc.health.v1.Health/*"
+	Skip []string
+}
+
+func (o CallOptions) skip(method string) bool {
+	for _, pattern := range o.Skip {
+		if ok, err := path.Match(pattern, method); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// UnaryServerInterceptor logs each call with its code and duration. Pass it to the
+// ConnectAdapter as well so Connect calls are logged the same way.
+func UnaryServerInterceptor(opts CallOptions) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if opts.skip(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		if opts.Payloads {
+			logPayload(ctx, "RPC request", req)
+		}
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logCall(ctx, start, err)
+		if opts.Payloads && err == nil {
+			logPayload(ctx, "RPC response", resp)
+		}
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart of UnaryServerInterceptor
+func StreamServerInterceptor(opts CallOptions) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if opts.skip(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		if opts.Payloads {
+			ss = &payloadStream{ServerStream: ss}
+		}
+		start := time.Now()
+		err := handler(srv, ss)
+		logCall(ss.Context(), start, err)
+		return err
+	}
+}
+
+// ServerOptions returns the interceptors as server options for grpc.NewServer
+func ServerOptions(opts CallOptions) []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(UnaryServerInterceptor(opts)),
+		grpc.ChainStreamInterceptor(StreamServerInterceptor(opts)),
+	}
+}
+
+// logCall logs the end of a call: server-side failures as errors, other calls as info
+func logCall(ctx context.Context, start time.Time, err error) {
+	code := status.Code(err)
+	lvl := slog.LevelInfo
+	switch code {
+	case codes.Unknown, codes.Internal, codes.DataLoss, codes.Unimplemented:
+		lvl = slog.LevelError
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted:
+		lvl = slog.LevelWarn
+	}
+	attrs := []slog.Attr{
+		slog.String("code", code.String()),
+		slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", status.Convert(err).Message()))
+	}
+	slog.LogAttrs(ctx, lvl, "RPC finished", attrs...)
+}
+
+func logPayload(ctx context.Context, msg string, v any) {
+	m, ok := v.(proto.Message)
+	if !ok || !slog.Default().Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+	slog.DebugContext(ctx, msg, "payload", Payload(m))
+}
+
+// payloadStream logs the messages of a stream
+type payloadStream struct {
+	grpc.ServerStream
+}
+
+func (s *payloadStream) SendMsg(m any) error {
+	if err := s.ServerStream.SendMsg(m); err != nil {
+		return err
+	}
+	logPayload(s.Context(), "Stream message sent", m)
+	return nil
+}
+
+func (s *payloadStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	logPayload(s.Context(), "Stream message received", m)
+	return nil
+}
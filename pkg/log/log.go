@@ -0,0 +1,134 @@
+// Package log sets up structured logging on log/slog: JSON or text records carrying the
+// request ID, trace ID, method and peer of the call in their context, a level that can be
+// changed at runtime, sampling of repetitive records, and payload logging that redacts the
+// fields marked (log.sensitive).
+//
+// After Setup, the slog functions and the standard log package write through it:
+//
+//	log.Setup(log.Config{Format: "json", Level: "info", Service: "user-service"})
+//	slog.InfoContext(ctx, "user created", "user_id", id)
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Config holds the logging settings
+type Config struct {
+	Format   string    // "json" (default) or "text"
+	Level    string    // "debug", "info" (default), "warn" or "error"
+	Output   io.Writer // Default: os.Stderr
+	Service  string    // Added to every record as "service" when set
+	Sampling Sampling
+}
+
+// level is the level of the logger set up by Setup, changed at runtime with SetLevel
+var level = new(slog.LevelVar)
+
+// New creates a logger from cfg
+func New(cfg Config) (*slog.Logger, error) {
+	lvl, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+	level.Set(lvl)
+
+	out := cfg.Output
+	if out == nil {
+		out = os.Stderr
+	}
+	opts := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case "", "json":
+		handler = slog.NewJSONHandler(out, opts)
+	case "text":
+		handler = slog.NewTextHandler(out, opts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q (json or text)", cfg.Format)
+	}
+	if cfg.Service != "" {
+		handler = handler.WithAttrs([]slog.Attr{slog.String("service", cfg.Service)})
+	}
+	handler = newSamplingHandler(handler, cfg.Sampling)
+	return slog.New(&contextHandler{next: handler}), nil
+}
+
+// Setup creates a logger from cfg and makes it the default of the slog and log packages
+func Setup(cfg Config) (*slog.Logger, error) {
+	logger, err := New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	slog.SetDefault(logger)
+	return logger, nil
+}
+
+// ParseLevel parses a level name; "" is info
+func ParseLevel(s string) (slog.Level, error) {
+	var lvl slog.Level
+	if s == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := lvl.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("invalid log level %q (debug, info, warn or error)", s)
+	}
+	return lvl, nil
+}
+
+// Level returns the current level
+func Level() slog.Level {
+	return level.Level()
+}
+
+// SetLevel changes the level at runtime
+func SetLevel(s string) error {
+	lvl, err := ParseLevel(s)
+	if err != nil {
+		return err
+	}
+	level.Set(lvl)
+	// Logged at the new level at least, so the change itself is always visible
+	slog.Log(context.Background(), max(lvl, slog.LevelInfo), "Log level changed", "level", lvl.String())
+	return nil
+}
+
+// LevelHandler serves the level: GET returns {"level":"INFO"}, PUT or POST with
+// {"level":"debug"} (or ?level=debug) changes it. Mount it on an admin port only.
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			requested := r.URL.Query().Get("level")
+			if requested == "" {
+				var body struct {
+					Level string `json:"level"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					http.Error(w, "invalid body: "+err.Error(), http.StatusBadRequest)
+					return
+				}
+				requested = body.Level
+			}
+			if err := SetLevel(requested); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"level": Level().String()})
+	})
+}
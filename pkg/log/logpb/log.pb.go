@@ -0,0 +1,85 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        v6.31.1
+// source: pkg/log/logpb/log.proto
+
+package logpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	descriptorpb "google.golang.org/protobuf/types/descriptorpb"
+	reflect "reflect"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+var file_pkg_log_logpb_log_proto_extTypes = []protoimpl.ExtensionInfo{
+	{
+		ExtendedType:  (*descriptorpb.FieldOptions)(nil),
+		ExtensionType: (*bool)(nil),
+		Field:         50101,
+		Name:          "log.sensitive",
+		Tag:           "varint,50101,opt,name=sensitive",
+		Filename:      "pkg/log/logpb/log.proto",
+	},
+}
+
+// Extension fields to descriptorpb.FieldOptions.
+var (
+	// Keeps the field out of logged payloads, e.g.
+	//   string password = 2 [(log.sensitive) = true];
+	//
+	// optional bool sensitive = 50101;
+	E_Sensitive = &file_pkg_log_logpb_log_proto_extTypes[0]
+)
+
+var File_pkg_log_logpb_log_proto protoreflect.FileDescriptor
+
+const file_pkg_log_logpb_log_proto_rawDesc = "" +
+	"\n" +
+	"\x17pkg/log/logpb/log.proto\x12\x03log\x1a google/protobuf/descriptor.proto:=\n" +
+	"\tsensitive\x12\x1d.google.protobuf.FieldOptions\x18\xb5\x87\x03 \x01(\bR\tsensitiveB=Z;github.com/LucasPluta/GoMicroserviceFramework/pkg/log/logpbb\x06proto3"
+
+var file_pkg_log_logpb_log_proto_goTypes = []any{
+	(*descriptorpb.FieldOptions)(nil), // 0: google.protobuf.FieldOptions
+}
+var file_pkg_log_logpb_log_proto_depIdxs = []int32{
+	0, // 0: log.sensitive:extendee -> google.protobuf.FieldOptions
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	0, // [0:1] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_pkg_log_logpb_log_proto_init() }
+func file_pkg_log_logpb_log_proto_init() {
+	if File_pkg_log_logpb_log_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pkg_log_logpb_log_proto_rawDesc), len(file_pkg_log_logpb_log_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   0,
+			NumExtensions: 1,
+			NumServices:   0,
+		},
+		GoTypes:           file_pkg_log_logpb_log_proto_goTypes,
+		DependencyIndexes: file_pkg_log_logpb_log_proto_depIdxs,
+		ExtensionInfos:    file_pkg_log_logpb_log_proto_extTypes,
+	}.Build()
+	File_pkg_log_logpb_log_proto = out.File
+	file_pkg_log_logpb_log_proto_goTypes = nil
+	file_pkg_log_logpb_log_proto_depIdxs = nil
+}
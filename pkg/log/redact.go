@@ -0,0 +1,123 @@
+package log
+
+import (
+	"log/slog"
+	"sync"
+
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/log/logpb"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Redacted replaces the value of sensitive string fields
+const Redacted = "[REDACTED]"
+
+// Payload returns a log value of a message with its sensitive fields redacted, rendered
+// as JSON only if the record is written:
+//
+//	slog.DebugContext(ctx, "request", "payload", log.Payload(req))
+func Payload(m proto.Message) slog.LogValuer {
+	return payload{m}
+}
+
+type payload struct {
+	m proto.Message
+}
+
+func (p payload) LogValue() slog.Value {
+	if p.m == nil {
+		return slog.StringValue("null")
+	}
+	data, err := protojson.Marshal(Redact(p.m))
+	if err != nil {
+		return slog.StringValue("<" + err.Error() + ">")
+	}
+	return slog.StringValue(string(data))
+}
+
+// Redact returns m with the fields marked (log.sensitive) redacted, in m and in the
+// messages it contains: strings become Redacted and other values are cleared. m itself is
+// returned when it has no sensitive fields, and a copy otherwise.
+func Redact(m proto.Message) proto.Message {
+	if m == nil || !hasSensitive(m.ProtoReflect().Descriptor()) {
+		return m
+	}
+	clone := proto.Clone(m)
+	redact(clone.ProtoReflect())
+	return clone
+}
+
+func redact(m protoreflect.Message) {
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case isSensitive(fd):
+			if fd.Kind() == protoreflect.StringKind && !fd.IsList() && !fd.IsMap() {
+				m.Set(fd, protoreflect.ValueOfString(Redacted))
+			} else {
+				m.Clear(fd)
+			}
+		case fd.IsMap():
+			if fd.MapValue().Message() != nil && hasSensitive(fd.MapValue().Message()) {
+				v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+					redact(mv.Message())
+					return true
+				})
+			}
+		case fd.Message() != nil && hasSensitive(fd.Message()):
+			if fd.IsList() {
+				for i := 0; i < v.List().Len(); i++ {
+					redact(v.List().Get(i).Message())
+				}
+			} else {
+				redact(v.Message())
+			}
+		}
+		return true
+	})
+}
+
+func isSensitive(fd protoreflect.FieldDescriptor) bool {
+	opts, ok := fd.Options().(*descriptorpb.FieldOptions)
+	return ok && proto.GetExtension(opts, logpb.E_Sensitive).(bool)
+}
+
+// sensitiveTypes caches whether a message type has sensitive fields, directly or in the
+// messages it contains
+var sensitiveTypes sync.Map // protoreflect.FullName -> bool
+
+func hasSensitive(md protoreflect.MessageDescriptor) bool {
+	return scanSensitive(md, map[protoreflect.FullName]bool{})
+}
+
+func scanSensitive(md protoreflect.MessageDescriptor, visiting map[protoreflect.FullName]bool) bool {
+	if cached, ok := sensitiveTypes.Load(md.FullName()); ok {
+		return cached.(bool)
+	}
+	if visiting[md.FullName()] {
+		return false // Recursive type: decided by the fields outside the cycle
+	}
+	visiting[md.FullName()] = true
+
+	found := false
+	fields := md.Fields()
+	for i := 0; i < fields.Len() && !found; i++ {
+		fd := fields.Get(i)
+		switch {
+		case isSensitive(fd):
+			found = true
+		case fd.IsMap():
+			if vmd := fd.MapValue().Message(); vmd != nil {
+				found = scanSensitive(vmd, visiting)
+			}
+		case fd.Message() != nil:
+			found = scanSensitive(fd.Message(), visiting)
+		}
+	}
+	if len(visiting) == 1 || found {
+		sensitiveTypes.Store(md.FullName(), found)
+	}
+	delete(visiting, md.FullName())
+	return found
+}
@@ -0,0 +1,282 @@
+package log
+
+import (
+	"testing"
+
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/log/logpb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// The test messages, built as descriptors so no generated code is needed:
+//
+//	message Secret {
+//	  string token = 1 [(log.sensitive) = true];
+//	  int64 pin = 2 [(log.sensitive) = true];
+//	  bytes key = 3 [(log.sensitive) = true];
+//	  repeated string codes = 4 [(log.sensitive) = true];
+//	  Secret nested = 5 [(log.sensitive) = true];
+//	  string note = 6;
+//	}
+//	message Holder {
+//	  Secret secret = 1;
+//	  repeated Secret list = 2;
+//	  map<string, Secret> by_name = 3;
+//	  map<string, string> labels = 4 [(log.sensitive) = true];
+//	  string name = 5;
+//	}
+//	message Node { Node next = 1; Secret secret = 2; string name = 3; }  // Recursive
+//	message Plain { Plain next = 1; string name = 2; }                   // Recursive, nothing sensitive
+//	message Outer { Inner inner = 1; string password = 2 [(log.sensitive) = true]; }
+//	message Inner { Outer outer = 1; string name = 2; }                  // Sensitive only through Outer
+var testFile = func() protoreflect.FileDescriptor {
+	sensitive := func() *descriptorpb.FieldOptions {
+		opts := &descriptorpb.FieldOptions{}
+		proto.SetExtension(opts, logpb.E_Sensitive, true)
+		return opts
+	}
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:     typ.Enum(),
+		}
+		if typeName != "" {
+			f.TypeName = proto.String(".redacttest." + typeName)
+		}
+		return f
+	}
+	repeated := func(f *descriptorpb.FieldDescriptorProto) *descriptorpb.FieldDescriptorProto {
+		f.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+		return f
+	}
+	withOptions := func(f *descriptorpb.FieldDescriptorProto) *descriptorpb.FieldDescriptorProto {
+		f.Options = sensitive()
+		return f
+	}
+	mapEntry := func(name string, value *descriptorpb.FieldDescriptorProto) *descriptorpb.DescriptorProto {
+		value.Name, value.JsonName, value.Number = proto.String("value"), proto.String("value"), proto.Int32(2)
+		return &descriptorpb.DescriptorProto{
+			Name:    proto.String(name),
+			Field:   []*descriptorpb.FieldDescriptorProto{field("key", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""), value},
+			Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+		}
+	}
+	const (
+		str   = descriptorpb.FieldDescriptorProto_TYPE_STRING
+		i64   = descriptorpb.FieldDescriptorProto_TYPE_INT64
+		bytes = descriptorpb.FieldDescriptorProto_TYPE_BYTES
+		msg   = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+	)
+
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("redacttest/redact.proto"),
+		Package: proto.String("redacttest"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("Secret"), Field: []*descriptorpb.FieldDescriptorProto{
+				withOptions(field("token", 1, str, "")),
+				withOptions(field("pin", 2, i64, "")),
+				withOptions(field("key", 3, bytes, "")),
+				withOptions(repeated(field("codes", 4, str, ""))),
+				withOptions(field("nested", 5, msg, "Secret")),
+				field("note", 6, str, ""),
+			}},
+			{Name: proto.String("Holder"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("secret", 1, msg, "Secret"),
+				repeated(field("list", 2, msg, "Secret")),
+				repeated(field("by_name", 3, msg, "Holder.ByNameEntry")),
+				withOptions(repeated(field("labels", 4, msg, "Holder.LabelsEntry"))),
+				field("name", 5, str, ""),
+			}, NestedType: []*descriptorpb.DescriptorProto{
+				mapEntry("ByNameEntry", field("", 0, msg, "Secret")),
+				mapEntry("LabelsEntry", field("", 0, str, "")),
+			}},
+			{Name: proto.String("Node"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("next", 1, msg, "Node"),
+				field("secret", 2, msg, "Secret"),
+				field("name", 3, str, ""),
+			}},
+			{Name: proto.String("Plain"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("next", 1, msg, "Plain"),
+				field("name", 2, str, ""),
+			}},
+			{Name: proto.String("Outer"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("inner", 1, msg, "Inner"),
+				withOptions(field("password", 2, str, "")),
+			}},
+			{Name: proto.String("Inner"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("outer", 1, msg, "Outer"),
+				field("name", 2, str, ""),
+			}},
+		},
+	}
+	fd, err := protodesc.NewFile(fdp, nil)
+	if err != nil {
+		panic(err)
+	}
+	return fd
+}()
+
+func newMessage(name string) *dynamicpb.Message {
+	return dynamicpb.NewMessage(testFile.Messages().ByName(protoreflect.Name(name)))
+}
+
+// set sets the fields of m by name; messages and lists are passed as protoreflect values
+func set(m *dynamicpb.Message, fields map[string]any) *dynamicpb.Message {
+	for name, v := range fields {
+		fd := m.Descriptor().Fields().ByName(protoreflect.Name(name))
+		switch v := v.(type) {
+		case *dynamicpb.Message:
+			m.Set(fd, protoreflect.ValueOfMessage(v))
+		case []*dynamicpb.Message:
+			list := m.Mutable(fd).List()
+			for _, item := range v {
+				list.Append(protoreflect.ValueOfMessage(item))
+			}
+		case []string:
+			list := m.Mutable(fd).List()
+			for _, item := range v {
+				list.Append(protoreflect.ValueOfString(item))
+			}
+		case map[string]*dynamicpb.Message:
+			mp := m.Mutable(fd).Map()
+			for key, item := range v {
+				mp.Set(protoreflect.ValueOfString(key).MapKey(), protoreflect.ValueOfMessage(item))
+			}
+		case map[string]string:
+			mp := m.Mutable(fd).Map()
+			for key, item := range v {
+				mp.Set(protoreflect.ValueOfString(key).MapKey(), protoreflect.ValueOfString(item))
+			}
+		default:
+			m.Set(fd, protoreflect.ValueOf(v))
+		}
+	}
+	return m
+}
+
+func get(m protoreflect.Message, name string) protoreflect.Value {
+	return m.Get(m.Descriptor().Fields().ByName(protoreflect.Name(name)))
+}
+
+func has(m protoreflect.Message, name string) bool {
+	return m.Has(m.Descriptor().Fields().ByName(protoreflect.Name(name)))
+}
+
+func newSecret(note string) *dynamicpb.Message {
+	return set(newMessage("Secret"), map[string]any{
+		"token":  "hunter2",
+		"pin":    int64(1234),
+		"key":    []byte{1, 2, 3},
+		"codes":  []string{"a", "b"},
+		"nested": set(newMessage("Secret"), map[string]any{"token": "inner"}),
+		"note":   note,
+	})
+}
+
+// checkSecret checks that a Secret lost its sensitive values and kept the others
+func checkSecret(t *testing.T, where string, m protoreflect.Message, note string) {
+	t.Helper()
+	if got := get(m, "token").String(); got != Redacted {
+		t.Errorf("%s token = %q, want %q", where, got, Redacted)
+	}
+	for _, name := range []string{"pin", "key", "codes", "nested"} {
+		if has(m, name) {
+			t.Errorf("%s %s = %v, want it cleared", where, name, get(m, name))
+		}
+	}
+	if got := get(m, "note").String(); got != note {
+		t.Errorf("%s note = %q, want %q", where, got, note)
+	}
+}
+
+func TestRedactFieldKinds(t *testing.T) {
+	m := newSecret("kept")
+	redacted := Redact(m).ProtoReflect()
+	checkSecret(t, "Secret", redacted, "kept")
+
+	// The original is left alone
+	if got := get(m, "token").String(); got != "hunter2" {
+		t.Errorf("Redact modified its argument: token = %q", got)
+	}
+}
+
+func TestRedactNestedRepeatedAndMapValues(t *testing.T) {
+	m := set(newMessage("Holder"), map[string]any{
+		"secret":  newSecret("single"),
+		"list":    []*dynamicpb.Message{newSecret("first"), newSecret("second")},
+		"by_name": map[string]*dynamicpb.Message{"x": newSecret("mapped")},
+		"labels":  map[string]string{"team": "payments"},
+		"name":    "holder",
+	})
+	redacted := Redact(m).ProtoReflect()
+
+	checkSecret(t, "Holder.secret", get(redacted, "secret").Message(), "single")
+	list := get(redacted, "list").List()
+	if list.Len() != 2 {
+		t.Fatalf("Holder.list has %d items, want 2", list.Len())
+	}
+	checkSecret(t, "Holder.list[0]", list.Get(0).Message(), "first")
+	checkSecret(t, "Holder.list[1]", list.Get(1).Message(), "second")
+	checkSecret(t, "Holder.by_name[x]", get(redacted, "by_name").Map().Get(protoreflect.ValueOfString("x").MapKey()).Message(), "mapped")
+	if has(redacted, "labels") {
+		t.Error("sensitive map Holder.labels was not cleared")
+	}
+	if got := get(redacted, "name").String(); got != "holder" {
+		t.Errorf("Holder.name = %q, want holder", got)
+	}
+}
+
+func TestRedactRecursiveTypes(t *testing.T) {
+	// A linked list whose last node holds a secret
+	tail := set(newMessage("Node"), map[string]any{"secret": newSecret("deep"), "name": "tail"})
+	head := set(newMessage("Node"), map[string]any{"next": set(newMessage("Node"), map[string]any{"next": tail})})
+
+	redacted := Redact(head).ProtoReflect()
+	node := get(get(redacted, "next").Message(), "next").Message()
+	checkSecret(t, "Node.next.next.secret", get(node, "secret").Message(), "deep")
+	if got := get(node, "name").String(); got != "tail" {
+		t.Errorf("tail name = %q, want tail", got)
+	}
+
+	// Nothing to redact: the message itself is returned
+	plain := set(newMessage("Plain"), map[string]any{"next": set(newMessage("Plain"), map[string]any{"name": "x"})})
+	if Redact(plain) != proto.Message(plain) {
+		t.Error("Redact copied a message without sensitive fields")
+	}
+}
+
+func TestSensitiveTypeCache(t *testing.T) {
+	outer := testFile.Messages().ByName("Outer")
+	inner := testFile.Messages().ByName("Inner")
+
+	// Scanning Outer visits Inner inside the cycle, before Outer's own sensitive field is
+	// seen; Inner must not be cached as free of sensitive fields then
+	if !hasSensitive(outer) {
+		t.Fatal("Outer has no sensitive fields")
+	}
+	if cached, ok := sensitiveTypes.Load(inner.FullName()); ok && !cached.(bool) {
+		t.Error("Inner was cached as free of sensitive fields")
+	}
+	if !hasSensitive(inner) {
+		t.Error("Inner has no sensitive fields after Outer was scanned")
+	}
+	if cached, ok := sensitiveTypes.Load(inner.FullName()); !ok || !cached.(bool) {
+		t.Errorf("Inner cached as %v (%v), want true", cached, ok)
+	}
+
+	m := set(dynamicpb.NewMessage(inner), map[string]any{
+		"outer": set(dynamicpb.NewMessage(outer), map[string]any{"password": "hunter2"}),
+		"name":  "inner",
+	})
+	redacted := Redact(m).ProtoReflect()
+	if got := get(get(redacted, "outer").Message(), "password").String(); got != Redacted {
+		t.Errorf("Inner.outer.password = %q, want %q", got, Redacted)
+	}
+}
@@ -0,0 +1,88 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/metrics"
+)
+
+var sampledMetric = metrics.Int("log_sampled_total")
+
+// Sampling bounds repetitive records: in each Tick, the first First records of a level and
+// message are written, then every Thereafter-th. Warnings and errors are never sampled.
+// Sampling is off while First is 0.
+type Sampling struct {
+	Tick       time.Duration // Default: 1s
+	First      int
+	Thereafter int // 0 drops every record after the first ones
+}
+
+type samplingHandler struct {
+	next    slog.Handler
+	sampler *sampler
+}
+
+func newSamplingHandler(next slog.Handler, cfg Sampling) slog.Handler {
+	if cfg.First <= 0 {
+		return next
+	}
+	if cfg.Tick <= 0 {
+		cfg.Tick = time.Second
+	}
+	return &samplingHandler{next: next, sampler: &sampler{cfg: cfg, counts: map[sampleKey]int{}}}
+}
+
+func (h *samplingHandler) Enabled(ctx context.Context, lvl slog.Level) bool {
+	return h.next.Enabled(ctx, lvl)
+}
+
+func (h *samplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level < slog.LevelWarn && !h.sampler.keep(r.Level, r.Message, r.Time) {
+		sampledMetric.Add(1)
+		return nil
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &samplingHandler{next: h.next.WithAttrs(attrs), sampler: h.sampler}
+}
+
+func (h *samplingHandler) WithGroup(name string) slog.Handler {
+	return &samplingHandler{next: h.next.WithGroup(name), sampler: h.sampler}
+}
+
+type sampleKey struct {
+	level   slog.Level
+	message string
+}
+
+// sampler counts the records of each level and message in the current tick; it is shared
+// by the handlers derived with WithAttrs and WithGroup
+type sampler struct {
+	cfg Sampling
+
+	mu     sync.Mutex
+	start  time.Time
+	counts map[sampleKey]int
+}
+
+func (s *sampler) keep(lvl slog.Level, msg string, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now.Sub(s.start) >= s.cfg.Tick {
+		s.start = now
+		clear(s.counts)
+	}
+
+	key := sampleKey{lvl, msg}
+	s.counts[key]++
+	n := s.counts[key]
+	if n <= s.cfg.First {
+		return true
+	}
+	return s.cfg.Thereafter > 0 && (n-s.cfg.First)%s.cfg.Thereafter == 0
+}
@@ -0,0 +1,68 @@
+// Package metrics exposes process metrics through expvar (/debug/vars).
+// Metric constructors are idempotent so packages can declare the metrics they
+// use at init time without coordinating names.
+package metrics
+
+import (
+	"expvar"
+	"fmt"
+	"sync"
+)
+
+var mu sync.Mutex
+
+// Map returns the keyed metric with the given name, creating it on first use.
+// Keys typically identify a label value such as a method or target.
+func Map(name string) *expvar.Map {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if v := expvar.Get(name); v != nil {
+		return mustBe[*expvar.Map](name, v)
+	}
+	return expvar.NewMap(name)
+}
+
+// Int returns the integer metric with the given name, creating it on first use
+func Int(name string) *expvar.Int {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if v := expvar.Get(name); v != nil {
+		return mustBe[*expvar.Int](name, v)
+	}
+	return expvar.NewInt(name)
+}
+
+// Float returns the float metric with the given name, creating it on first use
+func Float(name string) *expvar.Float {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if v := expvar.Get(name); v != nil {
+		return mustBe[*expvar.Float](name, v)
+	}
+	return expvar.NewFloat(name)
+}
+
+// SetFloat sets a keyed float value in a map metric, e.g. a gauge per target
+func SetFloat(m *expvar.Map, key string, value float64) {
+	f := new(expvar.Float)
+	f.Set(value)
+	m.Set(key, f)
+}
+
+// SetString sets a keyed string value in a map metric, e.g. a state per target
+func SetString(m *expvar.Map, key string, value string) {
+	s := new(expvar.String)
+	s.Set(value)
+	m.Set(key, s)
+}
+
+func mustBe[T expvar.Var](name string, v expvar.Var) T {
+	t, ok := v.(T)
+	if !ok {
+		panic(fmt.Sprintf("metrics: %s is already registered as %T", name, v))
+	}
+	return t
+}
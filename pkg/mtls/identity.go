@@ -0,0 +1,72 @@
+package mtls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"strings"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// Identity is the verified identity of a peer presenting a client certificate
+type Identity struct {
+	SPIFFEID     string   // URI SAN with the spiffe scheme, if any
+	DNSNames     []string // DNS SANs
+	CommonName   string
+	Organization []string
+	Certificate  *x509.Certificate
+}
+
+// IdentityFromCertificate extracts the identity fields from a certificate
+func IdentityFromCertificate(cert *x509.Certificate) *Identity {
+	id := &Identity{
+		DNSNames:     cert.DNSNames,
+		CommonName:   cert.Subject.CommonName,
+		Organization: cert.Subject.Organization,
+		Certificate:  cert,
+	}
+	for _, uri := range cert.URIs {
+		if strings.EqualFold(uri.Scheme, "spiffe") {
+			id.SPIFFEID = uri.String()
+			break
+		}
+	}
+	return id
+}
+
+// IdentityFromTLS returns the identity of the verified client certificate of a connection.
+// Only certificates verified against the configured client CAs are trusted.
+func IdentityFromTLS(state *tls.ConnectionState) (*Identity, bool) {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil, false
+	}
+	return IdentityFromCertificate(state.VerifiedChains[0][0]), true
+}
+
+// IdentityFromPeer returns the identity of the gRPC peer in ctx
+func IdentityFromPeer(ctx context.Context) (*Identity, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.AuthInfo == nil {
+		return nil, false
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return nil, false
+	}
+	return IdentityFromTLS(&tlsInfo.State)
+}
+
+type identityKey struct{}
+
+// NewContext returns a context carrying the peer identity
+func NewContext(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// FromContext returns the peer identity stored by the interceptors or middleware
+func FromContext(ctx context.Context) (*Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(*Identity)
+	return id, ok && id != nil
+}
@@ -0,0 +1,102 @@
+package mtls
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	grpcpkg "github.com/LucasPluta/GoMicroserviceFramework/pkg/grpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor stores the peer identity in the context and enforces the policy.
+// A nil policy only extracts the identity.
+func UnaryServerInterceptor(policy *Policy) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authorize(ctx, policy, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart of UnaryServerInterceptor
+func StreamServerInterceptor(policy *Policy) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authorize(ss.Context(), policy, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// ServerOptions returns the interceptors as server options for grpc.NewServer
+func ServerOptions(policy *Policy) []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(UnaryServerInterceptor(policy)),
+		grpc.ChainStreamInterceptor(StreamServerInterceptor(policy)),
+	}
+}
+
+// Middleware applies the same identity extraction and policy to Connect handlers.
+// Connect procedures are served at their fully-qualified method path.
+func Middleware(policy *Policy, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		id, ok := IdentityFromTLS(r.TLS)
+		if ok {
+			ctx = NewContext(ctx, id)
+		}
+
+		if policy != nil && !policy.Authorize(r.URL.Path, id) {
+			logDenied(r.URL.Path, id)
+			if id == nil {
+				grpcpkg.WriteError(w, r, status.Error(codes.Unauthenticated, "client certificate required"))
+				return
+			}
+			grpcpkg.WriteError(w, r, status.Errorf(codes.PermissionDenied, "peer is not allowed to call %s", r.URL.Path))
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func authorize(ctx context.Context, policy *Policy, method string) (context.Context, error) {
+	id, ok := IdentityFromPeer(ctx)
+	if ok {
+		ctx = NewContext(ctx, id)
+	}
+
+	if policy != nil && !policy.Authorize(method, id) {
+		logDenied(method, id)
+		if id == nil {
+			return nil, status.Error(codes.Unauthenticated, "client certificate required")
+		}
+		return nil, status.Errorf(codes.PermissionDenied, "peer is not allowed to call %s", method)
+	}
+
+	return ctx, nil
+}
+
+func logDenied(method string, id *Identity) {
+	if id == nil {
+		log.Printf("mTLS policy denied %s: no verified client certificate", method)
+		return
+	}
+	log.Printf("mTLS policy denied %s for peer (spiffe_id=%q, cn=%q)", method, id.SPIFFEID, id.CommonName)
+}
+
+// contextStream overrides the context of a server stream
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,139 @@
+package mtls
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+)
+
+// Policy maps peer identities to the fully-qualified methods they may call.
+//
+// Example (JSON):
+//
+//	{
+//	  "default": "deny",
+//	  "rules": [
+//	    {"methods": ["/grpc.reflection.v1.ServerReflection/*"], "public": true},
+//	    {"methods": ["/healthservice.HealthServiceService/*"],
+//	     "allow": [{"spiffe_id": "spiffe://example.org/user-service"}]}
+//	  ]
+//	}
+type Policy struct {
+	Default string `json:"default"` // "allow" or "deny" for methods no rule matches (default: deny)
+	Rules   []Rule `json:"rules"`
+}
+
+// Rule grants access to a set of methods. The first rule matching a method decides.
+type Rule struct {
+	Methods []string  `json:"methods"` // Patterns such as "/pkg.Service/Method", "/pkg.Service/*" or "*"
+	Public  bool      `json:"public"`  // Allow callers without a client certificate
+	Allow   []Matcher `json:"allow"`   // Identities allowed; empty allows any verified identity
+}
+
+// Matcher selects identities. Every non-empty field must match; fields support
+// path.Match patterns such as "spiffe://example.org/*".
+type Matcher struct {
+	SPIFFEID     string `json:"spiffe_id,omitempty"`
+	DNSName      string `json:"dns_name,omitempty"`
+	CommonName   string `json:"common_name,omitempty"`
+	Organization string `json:"organization,omitempty"`
+}
+
+// LoadPolicy reads a JSON policy file
+func LoadPolicy(file string) (*Policy, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mTLS policy: %w", err)
+	}
+
+	var policy Policy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse mTLS policy %s: %w", file, err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+// Validate checks the policy for malformed patterns
+func (p *Policy) Validate() error {
+	if p.Default != "" && p.Default != "allow" && p.Default != "deny" {
+		return fmt.Errorf("mTLS policy: default must be allow or deny, got %q", p.Default)
+	}
+	for i, rule := range p.Rules {
+		if len(rule.Methods) == 0 {
+			return fmt.Errorf("mTLS policy: rule %d has no methods", i)
+		}
+		patterns := slices.Clone(rule.Methods)
+		for _, m := range rule.Allow {
+			patterns = append(patterns, m.SPIFFEID, m.DNSName, m.CommonName, m.Organization)
+		}
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("mTLS policy: rule %d has malformed pattern %q", i, pattern)
+			}
+		}
+	}
+	return nil
+}
+
+// Authorize reports whether the identity (nil for callers without a verified
+// certificate) may call the fully-qualified method
+func (p *Policy) Authorize(method string, id *Identity) bool {
+	for _, rule := range p.Rules {
+		if !rule.matchesMethod(method) {
+			continue
+		}
+		if rule.Public {
+			return true
+		}
+		if id == nil {
+			return false
+		}
+		if len(rule.Allow) == 0 {
+			return true
+		}
+		for _, m := range rule.Allow {
+			if m.matches(id) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return p.Default == "allow"
+}
+
+func (r Rule) matchesMethod(method string) bool {
+	for _, pattern := range r.Methods {
+		if pattern == "*" || match(pattern, method) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m Matcher) matches(id *Identity) bool {
+	if m.SPIFFEID != "" && !match(m.SPIFFEID, id.SPIFFEID) {
+		return false
+	}
+	if m.CommonName != "" && !match(m.CommonName, id.CommonName) {
+		return false
+	}
+	if m.DNSName != "" && !slices.ContainsFunc(id.DNSNames, func(name string) bool { return match(m.DNSName, name) }) {
+		return false
+	}
+	if m.Organization != "" && !slices.ContainsFunc(id.Organization, func(org string) bool { return match(m.Organization, org) }) {
+		return false
+	}
+	return true
+}
+
+func match(pattern, value string) bool {
+	ok, err := path.Match(pattern, value)
+	return err == nil && ok
+}
@@ -0,0 +1,129 @@
+package nats
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+)
+
+// EmbeddedConfig holds configuration for an in-process NATS server
+type EmbeddedConfig struct {
+	Host         string        // Interface to listen on (default: 127.0.0.1)
+	Port         int           // Client port (0 picks a random free port)
+	StoreDir     string        // JetStream store directory (default: a temp dir, removed on Shutdown)
+	ReadyTimeout time.Duration // How long to wait for the server to accept connections (default: 5s)
+}
+
+// EmbeddedServer is an in-process NATS server with JetStream enabled
+type EmbeddedServer struct {
+	server      *server.Server
+	storeDir    string
+	removeStore bool
+}
+
+// StartEmbeddedServer starts an in-process NATS server with JetStream enabled
+// and waits until it is ready to accept client connections
+func StartEmbeddedServer(cfg EmbeddedConfig) (*EmbeddedServer, error) {
+	host := cfg.Host
+	if host == "" {
+		host = "127.0.0.1"
+	}
+
+	port := cfg.Port
+	if port == 0 {
+		port = server.RANDOM_PORT
+	}
+
+	readyTimeout := cfg.ReadyTimeout
+	if readyTimeout == 0 {
+		readyTimeout = 5 * time.Second
+	}
+
+	storeDir := cfg.StoreDir
+	removeStore := false
+	if storeDir == "" {
+		dir, err := os.MkdirTemp("", "nats-js-")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create JetStream store directory: %w", err)
+		}
+		storeDir = dir
+		removeStore = true
+	}
+
+	opts := &server.Options{
+		Host:      host,
+		Port:      port,
+		JetStream: true,
+		StoreDir:  storeDir,
+		NoLog:     true,
+		NoSigs:    true,
+	}
+
+	ns, err := server.NewServer(opts)
+	if err != nil {
+		if removeStore {
+			os.RemoveAll(storeDir)
+		}
+		return nil, fmt.Errorf("failed to create embedded NATS server: %w", err)
+	}
+
+	go ns.Start()
+
+	if !ns.ReadyForConnections(readyTimeout) {
+		ns.Shutdown()
+		if removeStore {
+			os.RemoveAll(storeDir)
+		}
+		return nil, fmt.Errorf("embedded NATS server not ready after %s", readyTimeout)
+	}
+
+	log.Printf("Embedded NATS server listening on %s (JetStream store: %s)", ns.ClientURL(), storeDir)
+	return &EmbeddedServer{
+		server:      ns,
+		storeDir:    storeDir,
+		removeStore: removeStore,
+	}, nil
+}
+
+// ClientURL returns the URL clients should use to connect to the server
+func (s *EmbeddedServer) ClientURL() string {
+	return s.server.ClientURL()
+}
+
+// Connect opens a new client connection to the embedded server
+func (s *EmbeddedServer) Connect() (*nats.Conn, error) {
+	return NewNATSConnection(Config{URL: s.ClientURL()})
+}
+
+// Shutdown stops the server and removes the temporary store directory if one was created
+func (s *EmbeddedServer) Shutdown() {
+	s.server.Shutdown()
+	s.server.WaitForShutdown()
+
+	if s.removeStore {
+		if err := os.RemoveAll(s.storeDir); err != nil {
+			log.Printf("Failed to remove JetStream store directory %s: %v", s.storeDir, err)
+		}
+	}
+}
+
+// NewEmbeddedNATSConnection starts an embedded server and returns a ready connection to it.
+// The caller is responsible for closing the connection and shutting down the server.
+func NewEmbeddedNATSConnection(cfg EmbeddedConfig) (*nats.Conn, *EmbeddedServer, error) {
+	srv, err := StartEmbeddedServer(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nc, err := srv.Connect()
+	if err != nil {
+		srv.Shutdown()
+		return nil, nil, err
+	}
+
+	return nc, srv, nil
+}
@@ -0,0 +1,45 @@
+package nats
+
+import (
+	"os"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+)
+
+func TestEmbeddedServerRemovesTempStoreOnShutdown(t *testing.T) {
+	srv, err := StartEmbeddedServer(EmbeddedConfig{})
+	if err != nil {
+		t.Fatalf("StartEmbeddedServer: %v", err)
+	}
+	nc, err := srv.Connect()
+	if err != nil {
+		srv.Shutdown()
+		t.Fatalf("Connect: %v", err)
+	}
+	js, err := nc.JetStream()
+	if err != nil {
+		t.Fatalf("JetStream: %v", err)
+	}
+	if _, err := js.CreateKeyValue(&nats.KeyValueConfig{Bucket: "scratch"}); err != nil {
+		t.Fatalf("CreateKeyValue: %v", err)
+	}
+	nc.Close()
+
+	srv.Shutdown()
+	if _, err := os.Stat(srv.storeDir); !os.IsNotExist(err) {
+		t.Errorf("store directory %s still exists after Shutdown (%v)", srv.storeDir, err)
+	}
+}
+
+func TestEmbeddedServerKeepsConfiguredStore(t *testing.T) {
+	dir := t.TempDir()
+	srv, err := StartEmbeddedServer(EmbeddedConfig{StoreDir: dir})
+	if err != nil {
+		t.Fatalf("StartEmbeddedServer: %v", err)
+	}
+	srv.Shutdown()
+	if _, err := os.Stat(dir); err != nil {
+		t.Errorf("configured store directory was removed: %v", err)
+	}
+}
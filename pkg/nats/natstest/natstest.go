@@ -0,0 +1,43 @@
+// Package natstest provides helpers for tests that need a NATS server
+package natstest
+
+import (
+	"testing"
+
+	natspkg "github.com/LucasPluta/GoMicroserviceFramework/pkg/nats"
+	"github.com/nats-io/nats.go"
+)
+
+// NewConnection starts an embedded NATS server with JetStream on a random port
+// and a temporary store directory, and returns a ready connection to it.
+// The connection and server are torn down when the test finishes.
+func NewConnection(tb testing.TB) *nats.Conn {
+	tb.Helper()
+
+	nc, srv, err := natspkg.NewEmbeddedNATSConnection(natspkg.EmbeddedConfig{
+		StoreDir: tb.TempDir(),
+	})
+	if err != nil {
+		tb.Fatalf("failed to start embedded NATS server: %v", err)
+	}
+
+	tb.Cleanup(func() {
+		nc.Close()
+		srv.Shutdown()
+	})
+
+	return nc
+}
+
+// NewJetStream is like NewConnection but returns a JetStream context as well
+func NewJetStream(tb testing.TB) (*nats.Conn, nats.JetStreamContext) {
+	tb.Helper()
+
+	nc := NewConnection(tb)
+	js, err := nc.JetStream()
+	if err != nil {
+		tb.Fatalf("failed to create JetStream context: %v", err)
+	}
+
+	return nc, js
+}
@@ -0,0 +1,73 @@
+package natstest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+func TestPublishSubscribe(t *testing.T) {
+	nc := NewConnection(t)
+
+	sub, err := nc.SubscribeSync("greetings.>")
+	if err != nil {
+		t.Fatalf("SubscribeSync: %v", err)
+	}
+	if err := nc.Publish("greetings.en", []byte("hello")); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	msg, err := sub.NextMsg(2 * time.Second)
+	if err != nil {
+		t.Fatalf("NextMsg: %v", err)
+	}
+	if msg.Subject != "greetings.en" || string(msg.Data) != "hello" {
+		t.Errorf("received %s %q, want greetings.en %q", msg.Subject, msg.Data, "hello")
+	}
+}
+
+func TestJetStreamRoundTrip(t *testing.T) {
+	_, js := NewJetStream(t)
+
+	if _, err := js.AddStream(&nats.StreamConfig{Name: "ORDERS", Subjects: []string{"orders.>"}}); err != nil {
+		t.Fatalf("AddStream: %v", err)
+	}
+	ack, err := js.Publish("orders.created", []byte("42"), nats.MsgId("order-42"))
+	if err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if ack.Stream != "ORDERS" || ack.Sequence != 1 {
+		t.Errorf("ack = %s #%d, want ORDERS #1", ack.Stream, ack.Sequence)
+	}
+
+	// The message is persisted: a consumer created afterwards still receives it
+	sub, err := js.SubscribeSync("orders.>", nats.DeliverAll())
+	if err != nil {
+		t.Fatalf("SubscribeSync: %v", err)
+	}
+	msg, err := sub.NextMsg(2 * time.Second)
+	if err != nil {
+		t.Fatalf("NextMsg: %v", err)
+	}
+	if string(msg.Data) != "42" {
+		t.Errorf("received %q, want %q", msg.Data, "42")
+	}
+	if err := msg.Ack(); err != nil {
+		t.Errorf("Ack: %v", err)
+	}
+
+	kv, err := js.CreateKeyValue(&nats.KeyValueConfig{Bucket: "settings"})
+	if err != nil {
+		t.Fatalf("CreateKeyValue: %v", err)
+	}
+	if _, err := kv.PutString("mode", "fast"); err != nil {
+		t.Fatalf("PutString: %v", err)
+	}
+	entry, err := kv.Get("mode")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if string(entry.Value()) != "fast" {
+		t.Errorf("mode = %q, want %q", entry.Value(), "fast")
+	}
+}
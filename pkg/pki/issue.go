@@ -0,0 +1,98 @@
+package pki
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultTrustDomain is the SPIFFE trust domain used for development client certificates
+const DefaultTrustDomain = "gomicroservice.local"
+
+// ServerRequest describes a server certificate
+type ServerRequest struct {
+	CommonName string
+	Hosts      []string      // DNS names and IP addresses for the SANs
+	Validity   time.Duration // Default: DefaultValidity
+}
+
+// ClientRequest describes a client certificate for mTLS
+type ClientRequest struct {
+	CommonName string
+	SPIFFEID   string        // URI SAN, e.g. spiffe://gomicroservice.local/example-service
+	Validity   time.Duration // Default: DefaultValidity
+}
+
+// ServiceHosts returns the SANs a service needs in development: its docker-compose
+// hostname plus localhost, so the same certificate works in and out of containers
+func ServiceHosts(services ...string) []string {
+	hosts := make([]string, 0, len(services)+4)
+	hosts = append(hosts, services...)
+	return append(hosts, "localhost", "*.localhost", "127.0.0.1", "::1")
+}
+
+// SPIFFEID returns the SPIFFE ID of a workload in a trust domain
+func SPIFFEID(trustDomain, workload string) string {
+	if trustDomain == "" {
+		trustDomain = DefaultTrustDomain
+	}
+	return "spiffe://" + trustDomain + "/" + strings.TrimPrefix(workload, "/")
+}
+
+// IssueServer issues a server certificate for the requested hosts
+func (ca *CA) IssueServer(req ServerRequest) (*Certificate, error) {
+	if len(req.Hosts) == 0 {
+		return nil, fmt.Errorf("server certificate needs at least one host")
+	}
+
+	template := &x509.Certificate{
+		Subject: pkix.Name{
+			Organization: []string{DefaultOrganization},
+			CommonName:   req.CommonName,
+		},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if template.Subject.CommonName == "" {
+		template.Subject.CommonName = req.Hosts[0]
+	}
+	for _, host := range req.Hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	return ca.issue(template, req.Validity)
+}
+
+// IssueClient issues a client certificate carrying an optional SPIFFE ID
+func (ca *CA) IssueClient(req ClientRequest) (*Certificate, error) {
+	if req.CommonName == "" && req.SPIFFEID == "" {
+		return nil, fmt.Errorf("client certificate needs a common name or SPIFFE ID")
+	}
+
+	template := &x509.Certificate{
+		Subject: pkix.Name{
+			Organization: []string{DefaultOrganization},
+			CommonName:   req.CommonName,
+		},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if req.SPIFFEID != "" {
+		id, err := url.Parse(req.SPIFFEID)
+		if err != nil || id.Scheme != "spiffe" || id.Host == "" {
+			return nil, fmt.Errorf("invalid SPIFFE ID %q", req.SPIFFEID)
+		}
+		template.URIs = []*url.URL{id}
+		if template.Subject.CommonName == "" {
+			template.Subject.CommonName = strings.TrimPrefix(id.Path, "/")
+		}
+	}
+
+	return ca.issue(template, req.Validity)
+}
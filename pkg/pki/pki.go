@@ -0,0 +1,227 @@
+package pki
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// DefaultOrganization is the subject organization of certificates issued by the dev CA
+	DefaultOrganization = "GoMicroserviceFramework"
+
+	// DefaultCAValidity is the lifetime of a new CA
+	DefaultCAValidity = 5 * 365 * 24 * time.Hour
+
+	// DefaultValidity is the lifetime of issued leaf certificates
+	DefaultValidity = 90 * 24 * time.Hour
+
+	// Backdate NotBefore so freshly issued certificates are valid despite small clock drift
+	clockSkew = 5 * time.Minute
+)
+
+// ErrNotCA is returned when loading a certificate that cannot sign others as a CA
+var ErrNotCA = errors.New("certificate is not a CA")
+
+// Certificate is a certificate together with its private key
+type Certificate struct {
+	Cert *x509.Certificate
+	Key  crypto.Signer
+}
+
+// CertPEM returns the PEM encoded certificate
+func (c *Certificate) CertPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Cert.Raw})
+}
+
+// KeyPEM returns the PEM encoded PKCS#8 private key
+func (c *Certificate) KeyPEM() ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(c.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal private key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// TLSCertificate returns the certificate for use in a tls.Config
+func (c *Certificate) TLSCertificate() tls.Certificate {
+	return tls.Certificate{
+		Certificate: [][]byte{c.Cert.Raw},
+		PrivateKey:  c.Key,
+		Leaf:        c.Cert,
+	}
+}
+
+// Save writes the certificate and key as PEM files. The key file is only readable by the owner.
+func (c *Certificate) Save(certFile, keyFile string) error {
+	keyPEM, err := c.KeyPEM()
+	if err != nil {
+		return err
+	}
+	if err := writeFile(keyFile, keyPEM, 0600); err != nil {
+		return err
+	}
+	return writeFile(certFile, c.CertPEM(), 0644)
+}
+
+// Load reads a PEM certificate and key pair
+func Load(certFile, keyFile string) (*Certificate, error) {
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load certificate %s: %w", certFile, err)
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate %s: %w", certFile, err)
+	}
+	key, ok := pair.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type in %s", keyFile)
+	}
+	return &Certificate{Cert: cert, Key: key}, nil
+}
+
+// CA is a development certificate authority that issues server and client certificates
+type CA struct {
+	Certificate
+}
+
+// NewCA creates a self-signed CA in memory. The CA is only meant for development and tests.
+func NewCA(commonName string, validity time.Duration) (*CA, error) {
+	if validity <= 0 {
+		validity = DefaultCAValidity
+	}
+
+	key, err := newKey()
+	if err != nil {
+		return nil, err
+	}
+	serial, err := newSerial()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			Organization:       []string{DefaultOrganization},
+			OrganizationalUnit: []string{"Development CA"},
+			CommonName:         commonName,
+		},
+		NotBefore:             now.Add(-clockSkew),
+		NotAfter:              now.Add(validity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+
+	cert, err := sign(template, template, key.Public(), key)
+	if err != nil {
+		return nil, err
+	}
+	return &CA{Certificate{Cert: cert, Key: key}}, nil
+}
+
+// LoadCA reads a CA certificate and key written by Save
+func LoadCA(certFile, keyFile string) (*CA, error) {
+	c, err := Load(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	if !c.Cert.IsCA {
+		return nil, fmt.Errorf("%w: %s", ErrNotCA, certFile)
+	}
+	return &CA{*c}, nil
+}
+
+// CertPool returns a pool trusting only this CA
+func (ca *CA) CertPool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Cert)
+	return pool
+}
+
+func (ca *CA) issue(template *x509.Certificate, validity time.Duration) (*Certificate, error) {
+	if validity <= 0 {
+		validity = DefaultValidity
+	}
+
+	key, err := newKey()
+	if err != nil {
+		return nil, err
+	}
+	serial, err := newSerial()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	template.SerialNumber = serial
+	template.NotBefore = now.Add(-clockSkew)
+	template.NotAfter = now.Add(validity)
+	if template.NotAfter.After(ca.Cert.NotAfter) {
+		// A leaf outliving its CA would fail verification anyway
+		template.NotAfter = ca.Cert.NotAfter
+	}
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.BasicConstraintsValid = true
+
+	cert, err := sign(template, ca.Cert, key.Public(), ca.Key)
+	if err != nil {
+		return nil, err
+	}
+	return &Certificate{Cert: cert, Key: key}, nil
+}
+
+func sign(template, parent *x509.Certificate, pub crypto.PublicKey, signer crypto.Signer) (*x509.Certificate, error) {
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate: %w", err)
+	}
+	return x509.ParseCertificate(der)
+}
+
+func newKey() (*ecdsa.PrivateKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	return key, nil
+}
+
+func newSerial() (*big.Int, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+	return serial, nil
+}
+
+// writeFile writes atomically so a reloading server never reads a half-written file
+func writeFile(name string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", name, err)
+	}
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, data, perm); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if err := os.Rename(tmp, name); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
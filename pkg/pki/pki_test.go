@@ -0,0 +1,93 @@
+package pki
+
+import (
+	"crypto/tls"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestInMemoryMutualTLS(t *testing.T) {
+	ca, err := NewCA("Test CA", time.Hour)
+	if err != nil {
+		t.Fatalf("NewCA: %v", err)
+	}
+	server, err := ca.IssueServer(ServerRequest{Hosts: ServiceHosts("example-service")})
+	if err != nil {
+		t.Fatalf("IssueServer: %v", err)
+	}
+	id := SPIFFEID("", "gateway-service")
+	client, err := ca.IssueClient(ClientRequest{SPIFFEID: id})
+	if err != nil {
+		t.Fatalf("IssueClient: %v", err)
+	}
+	if client.Cert.Subject.CommonName != "gateway-service" {
+		t.Errorf("client CN = %q, want gateway-service", client.Cert.Subject.CommonName)
+	}
+
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	errc := make(chan error, 1)
+	var peerURI string
+	go func() {
+		conn := tls.Server(serverConn, &tls.Config{
+			Certificates: []tls.Certificate{server.TLSCertificate()},
+			ClientCAs:    ca.CertPool(),
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+		})
+		err := conn.Handshake()
+		if err == nil {
+			peerURI = conn.ConnectionState().PeerCertificates[0].URIs[0].String()
+		}
+		errc <- err
+	}()
+
+	conn := tls.Client(clientConn, &tls.Config{
+		Certificates: []tls.Certificate{client.TLSCertificate()},
+		RootCAs:      ca.CertPool(),
+		ServerName:   "example-service",
+	})
+	if err := conn.Handshake(); err != nil {
+		t.Fatalf("client handshake: %v", err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatalf("server handshake: %v", err)
+	}
+	if peerURI != id {
+		t.Errorf("server saw peer %q, want %q", peerURI, id)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	ca, err := NewCA("Test CA", time.Hour)
+	if err != nil {
+		t.Fatalf("NewCA: %v", err)
+	}
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "ca-cert.pem"), filepath.Join(dir, "ca-key.pem")
+	if err := ca.Save(certFile, keyFile); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	loaded, err := LoadCA(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("LoadCA: %v", err)
+	}
+	if !loaded.Cert.Equal(ca.Cert) {
+		t.Error("LoadCA returned a different certificate")
+	}
+
+	leaf, err := loaded.IssueServer(ServerRequest{Hosts: []string{"127.0.0.1"}})
+	if err != nil {
+		t.Fatalf("IssueServer: %v", err)
+	}
+	if err := leaf.Save(certFile, keyFile); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if _, err := LoadCA(certFile, keyFile); err == nil {
+		t.Error("LoadCA accepted a leaf certificate")
+	}
+}
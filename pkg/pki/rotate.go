@@ -0,0 +1,188 @@
+package pki
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"slices"
+	"time"
+)
+
+// DefaultRenewBefore is how long before expiry Ensure* functions reissue a certificate
+const DefaultRenewBefore = 30 * 24 * time.Hour
+
+// ReadCertificate reads the first certificate of a PEM file
+func ReadCertificate(certFile string) (*x509.Certificate, error) {
+	data, err := os.ReadFile(certFile)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("no PEM certificate in %s", certFile)
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// Expiry returns when the certificate in certFile expires
+func Expiry(certFile string) (time.Time, error) {
+	cert, err := ReadCertificate(certFile)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return cert.NotAfter, nil
+}
+
+// EnsureCA loads the CA from the given files, creating it if neither exists. If only one
+// of them exists it fails rather than replace a CA whose other half was lost or misplaced.
+func EnsureCA(certFile, keyFile, commonName string) (*CA, bool, error) {
+	certExists, err := fileExists(certFile)
+	if err != nil {
+		return nil, false, err
+	}
+	keyExists, err := fileExists(keyFile)
+	if err != nil {
+		return nil, false, err
+	}
+	switch {
+	case certExists && keyExists:
+		ca, err := LoadCA(certFile, keyFile)
+		if err != nil {
+			return nil, false, err
+		}
+		return ca, false, nil
+	case certExists:
+		return nil, false, fmt.Errorf("CA certificate %s exists but its key %s does not", certFile, keyFile)
+	case keyExists:
+		return nil, false, fmt.Errorf("CA key %s exists but its certificate %s does not", keyFile, certFile)
+	}
+
+	ca, err := NewCA(commonName, DefaultCAValidity)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := ca.Save(certFile, keyFile); err != nil {
+		return nil, false, err
+	}
+	return ca, true, nil
+}
+
+// fileExists reports whether name exists; errors other than its absence are returned
+func fileExists(name string) (bool, error) {
+	_, err := os.Stat(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// EnsureServer issues a server certificate into the given files unless a valid one
+// signed by ca, covering the requested hosts and not expiring within renewBefore exists.
+// It reports whether a new certificate was written.
+func (ca *CA) EnsureServer(certFile, keyFile string, req ServerRequest, renewBefore time.Duration) (bool, error) {
+	cert, err := ca.current(certFile, renewBefore)
+	if err != nil {
+		return false, err
+	}
+	if cert != nil && coversHosts(cert, req.Hosts) {
+		return false, nil
+	}
+
+	issued, err := ca.IssueServer(req)
+	if err != nil {
+		return false, err
+	}
+	return true, issued.Save(certFile, keyFile)
+}
+
+// EnsureClient is the client certificate counterpart of EnsureServer
+func (ca *CA) EnsureClient(certFile, keyFile string, req ClientRequest, renewBefore time.Duration) (bool, error) {
+	cert, err := ca.current(certFile, renewBefore)
+	if err != nil {
+		return false, err
+	}
+	if cert != nil && (req.SPIFFEID == "" || hasURI(cert, req.SPIFFEID)) {
+		return false, nil
+	}
+
+	issued, err := ca.IssueClient(req)
+	if err != nil {
+		return false, err
+	}
+	return true, issued.Save(certFile, keyFile)
+}
+
+// Renew reissues the certificate in certFile with the same subject, SANs and usage
+// and a fresh validity period, replacing both files
+func (ca *CA) Renew(certFile, keyFile string, validity time.Duration) error {
+	cert, err := ReadCertificate(certFile)
+	if err != nil {
+		return err
+	}
+
+	var issued *Certificate
+	if slices.Contains(cert.ExtKeyUsage, x509.ExtKeyUsageServerAuth) {
+		hosts := slices.Clone(cert.DNSNames)
+		for _, ip := range cert.IPAddresses {
+			hosts = append(hosts, ip.String())
+		}
+		issued, err = ca.IssueServer(ServerRequest{CommonName: cert.Subject.CommonName, Hosts: hosts, Validity: validity})
+	} else {
+		req := ClientRequest{CommonName: cert.Subject.CommonName, Validity: validity}
+		if len(cert.URIs) > 0 {
+			req.SPIFFEID = cert.URIs[0].String()
+		}
+		issued, err = ca.IssueClient(req)
+	}
+	if err != nil {
+		return err
+	}
+	return issued.Save(certFile, keyFile)
+}
+
+// current returns the certificate in certFile if it was issued by ca and is not due
+// for renewal, or nil if it must be (re)issued
+func (ca *CA) current(certFile string, renewBefore time.Duration) (*x509.Certificate, error) {
+	if renewBefore <= 0 {
+		renewBefore = DefaultRenewBefore
+	}
+
+	cert, err := ReadCertificate(certFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if time.Until(cert.NotAfter) < renewBefore || cert.CheckSignatureFrom(ca.Cert) != nil {
+		return nil, nil
+	}
+	return cert, nil
+}
+
+func coversHosts(cert *x509.Certificate, hosts []string) bool {
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			if !slices.ContainsFunc(cert.IPAddresses, ip.Equal) {
+				return false
+			}
+		} else if !slices.Contains(cert.DNSNames, host) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasURI(cert *x509.Certificate, uri string) bool {
+	for _, u := range cert.URIs {
+		if u.String() == uri {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package pki
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEnsureCA(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "ca-cert.pem"), filepath.Join(dir, "ca-key.pem")
+
+	ca, created, err := EnsureCA(certFile, keyFile, "Test CA")
+	if err != nil || !created {
+		t.Fatalf("EnsureCA = %v, created %v; want a new CA", err, created)
+	}
+	loaded, created, err := EnsureCA(certFile, keyFile, "Test CA")
+	if err != nil || created {
+		t.Fatalf("EnsureCA = %v, created %v; want the existing CA", err, created)
+	}
+	if !loaded.Cert.Equal(ca.Cert) {
+		t.Error("EnsureCA loaded a different CA")
+	}
+
+	// A CA missing one of its files is an error, and the other file is left alone
+	certPEM, err := os.ReadFile(certFile)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if err := os.Remove(keyFile); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, _, err := EnsureCA(certFile, keyFile, "Test CA"); err == nil {
+		t.Error("EnsureCA succeeded without the CA key")
+	}
+	if after, _ := os.ReadFile(certFile); string(after) != string(certPEM) {
+		t.Error("EnsureCA overwrote the CA certificate")
+	}
+	if _, err := os.Stat(keyFile); !os.IsNotExist(err) {
+		t.Error("EnsureCA wrote a key for the existing certificate")
+	}
+
+	if err := os.Remove(certFile); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if err := os.WriteFile(keyFile, []byte("key"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, _, err := EnsureCA(certFile, keyFile, "Test CA"); err == nil {
+		t.Error("EnsureCA succeeded without the CA certificate")
+	}
+}
@@ -0,0 +1,62 @@
+package registry
+
+import (
+	"math/rand/v2"
+	"sync/atomic"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+)
+
+// Load balancing policies for grpc.ClientConfig.LoadBalancing
+const (
+	RoundRobin   = "round_robin"   // gRPC's built-in round robin
+	LeastRequest = "least_request" // Fewest outstanding requests of two random instances
+)
+
+func init() {
+	balancer.Register(base.NewBalancerBuilder(LeastRequest, leastRequestPickerBuilder{}, base.Config{HealthCheck: true}))
+}
+
+type leastRequestPickerBuilder struct{}
+
+func (leastRequestPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+	p := &leastRequestPicker{}
+	for sc := range info.ReadySCs {
+		p.subConns = append(p.subConns, &countedSubConn{SubConn: sc})
+	}
+	return p
+}
+
+// leastRequestPicker picks the less loaded of two random subconns ("power of two
+// choices"), which tracks the least loaded instance without scanning them all and
+// avoids herding onto a single instance the way always picking the minimum would
+type leastRequestPicker struct {
+	subConns []*countedSubConn
+}
+
+type countedSubConn struct {
+	balancer.SubConn
+	outstanding atomic.Int64
+}
+
+func (p *leastRequestPicker) Pick(balancer.PickInfo) (balancer.PickResult, error) {
+	sc := p.subConns[rand.IntN(len(p.subConns))]
+	if len(p.subConns) > 1 {
+		other := p.subConns[rand.IntN(len(p.subConns))]
+		if other.outstanding.Load() < sc.outstanding.Load() {
+			sc = other
+		}
+	}
+
+	sc.outstanding.Add(1)
+	return balancer.PickResult{
+		SubConn: sc.SubConn,
+		Done: func(balancer.DoneInfo) {
+			sc.outstanding.Add(-1)
+		},
+	}, nil
+}
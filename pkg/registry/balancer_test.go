@@ -0,0 +1,65 @@
+package registry
+
+import (
+	"errors"
+	"testing"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+)
+
+type subConn struct {
+	balancer.SubConn
+	name string
+}
+
+func TestLeastRequestPicker(t *testing.T) {
+	empty := leastRequestPickerBuilder{}.Build(base.PickerBuildInfo{})
+	if _, err := empty.Pick(balancer.PickInfo{}); !errors.Is(err, balancer.ErrNoSubConnAvailable) {
+		t.Errorf("Pick without subconns = %v, want ErrNoSubConnAvailable", err)
+	}
+
+	busy, idle := &subConn{name: "busy"}, &subConn{name: "idle"}
+	picker := leastRequestPickerBuilder{}.Build(base.PickerBuildInfo{
+		ReadySCs: map[balancer.SubConn]base.SubConnInfo{busy: {}, idle: {}},
+	}).(*leastRequestPicker)
+
+	// Hold ten calls on busy
+	var held []func(balancer.DoneInfo)
+	for len(held) < 10 {
+		result, err := picker.Pick(balancer.PickInfo{})
+		if err != nil {
+			t.Fatalf("Pick: %v", err)
+		}
+		if result.SubConn == busy {
+			held = append(held, result.Done)
+		} else {
+			result.Done(balancer.DoneInfo{})
+		}
+	}
+
+	// Busy is only picked when both random choices land on it: a quarter of the time
+	const n = 2000
+	picks := map[string]int{}
+	for i := 0; i < n; i++ {
+		result, err := picker.Pick(balancer.PickInfo{})
+		if err != nil {
+			t.Fatalf("Pick: %v", err)
+		}
+		picks[result.SubConn.(*subConn).name]++
+		result.Done(balancer.DoneInfo{})
+	}
+	if picks["busy"] > n*35/100 {
+		t.Errorf("busy picked %d of %d times, want about a quarter", picks["busy"], n)
+	}
+
+	// Finished calls are no longer counted
+	for _, done := range held {
+		done(balancer.DoneInfo{})
+	}
+	for _, sc := range picker.subConns {
+		if got := sc.outstanding.Load(); got != 0 {
+			t.Errorf("%s has %d outstanding calls after all finished", sc.SubConn.(*subConn).name, got)
+		}
+	}
+}
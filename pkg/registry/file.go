@@ -0,0 +1,87 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// FileBackend serves a static instance list from a JSON file, re-read on every check so
+// edits apply without restarts. It is read-only: services cannot register in it.
+//
+// Example:
+//
+//	{
+//	  "user-service": [
+//	    {"id": "user-1", "address": "user-service-1:50051", "version": "1.4.0"},
+//	    {"id": "user-2", "address": "user-service-2:50051", "version": "1.5.0-rc1",
+//	     "metadata": {"track": "canary"}}
+//	  ]
+//	}
+type FileBackend struct {
+	file     string
+	interval time.Duration
+}
+
+// NewFileBackend creates a backend for a file, checked for changes every interval (default: 5s)
+func NewFileBackend(file string, interval time.Duration) (*FileBackend, error) {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	b := &FileBackend{file: file, interval: interval}
+	if _, err := b.load(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *FileBackend) load() (map[string][]Instance, error) {
+	data, err := os.ReadFile(b.file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registry file: %w", err)
+	}
+	var services map[string][]Instance
+	if err := json.Unmarshal(data, &services); err != nil {
+		return nil, fmt.Errorf("failed to parse registry file %s: %w", b.file, err)
+	}
+	for service, instances := range services {
+		for i := range instances {
+			instances[i].Service = service
+			if instances[i].ID == "" {
+				instances[i].ID = instances[i].Address
+			}
+			if instances[i].Health == "" {
+				instances[i].Health = HealthServing
+			}
+		}
+	}
+	return services, nil
+}
+
+// Put is not supported
+func (b *FileBackend) Put(ctx context.Context, inst Instance) error {
+	return ErrReadOnly
+}
+
+// Delete is not supported
+func (b *FileBackend) Delete(ctx context.Context, service, id string) error {
+	return ErrReadOnly
+}
+
+// List returns the instances of a service listed in the file
+func (b *FileBackend) List(ctx context.Context, service string) ([]Instance, error) {
+	services, err := b.load()
+	if err != nil {
+		return nil, err
+	}
+	return services[service], nil
+}
+
+// Watch polls the file for the instances of a service
+func (b *FileBackend) Watch(ctx context.Context, service string) (<-chan []Instance, error) {
+	return poll(ctx, b.interval, func(ctx context.Context) ([]Instance, error) {
+		return b.List(ctx, service)
+	}), nil
+}
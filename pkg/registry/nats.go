@@ -0,0 +1,181 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// DefaultBucket is the NATS KV bucket of the registry
+const DefaultBucket = "service-registry"
+
+// validKey matches the service and ID parts of KV keys
+var validKey = regexp.MustCompile(`^[-/_=a-zA-Z0-9]+$`)
+
+// NATSBackend stores instances in a NATS KV bucket under "<service>.<id>". Watches are
+// pushed by the bucket; entries older than the bucket's max age are removed by NATS.
+type NATSBackend struct {
+	kv nats.KeyValue
+}
+
+// NewNATSBackend opens the registry bucket, creating it if needed. maxAge removes
+// instances that stopped sending heartbeats long ago (default: 10 × DefaultTTL).
+func NewNATSBackend(js nats.JetStreamContext, bucket string, maxAge time.Duration) (*NATSBackend, error) {
+	if bucket == "" {
+		bucket = DefaultBucket
+	}
+	if maxAge <= 0 {
+		maxAge = 10 * DefaultTTL
+	}
+	kv, err := js.KeyValue(bucket)
+	if errors.Is(err, nats.ErrBucketNotFound) {
+		kv, err = js.CreateKeyValue(&nats.KeyValueConfig{
+			Bucket:      bucket,
+			Description: "Service registry",
+			History:     1,
+			TTL:         maxAge,
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open registry bucket %s: %w", bucket, err)
+	}
+	return &NATSBackend{kv: kv}, nil
+}
+
+func natsKey(service, id string) (string, error) {
+	if !validKey.MatchString(service) || !validKey.MatchString(id) {
+		return "", fmt.Errorf("invalid service %q or instance ID %q for a NATS key", service, id)
+	}
+	return service + "." + id, nil
+}
+
+// Put registers or refreshes an instance
+func (b *NATSBackend) Put(ctx context.Context, inst Instance) error {
+	key, err := natsKey(inst.Service, inst.ID)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(inst)
+	if err != nil {
+		return err
+	}
+	_, err = b.kv.Put(key, data)
+	return err
+}
+
+// Delete deregisters an instance
+func (b *NATSBackend) Delete(ctx context.Context, service, id string) error {
+	key, err := natsKey(service, id)
+	if err != nil {
+		return err
+	}
+	return b.kv.Delete(key)
+}
+
+// List returns the instances of a service
+func (b *NATSBackend) List(ctx context.Context, service string) ([]Instance, error) {
+	keys, err := b.kv.Keys(nats.Context(ctx))
+	if errors.Is(err, nats.ErrNoKeysFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var instances []Instance
+	prefix := service + "."
+	for _, key := range keys {
+		if len(key) <= len(prefix) || key[:len(prefix)] != prefix {
+			continue
+		}
+		entry, err := b.kv.Get(key)
+		if errors.Is(err, nats.ErrKeyNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var inst Instance
+		if err := json.Unmarshal(entry.Value(), &inst); err != nil {
+			log.Printf("Registry: ignoring malformed instance %s: %v", key, err)
+			continue
+		}
+		instances = append(instances, inst)
+	}
+	return instances, nil
+}
+
+// Watch sends the instances of a service when an instance changes, and when one expires
+func (b *NATSBackend) Watch(ctx context.Context, service string) (<-chan []Instance, error) {
+	if !validKey.MatchString(service) {
+		return nil, fmt.Errorf("invalid service %q for a NATS key", service)
+	}
+	watcher, err := b.kv.Watch(service + ".*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch %s: %w", service, err)
+	}
+
+	updates := make(chan []Instance, 1)
+	go func() {
+		defer close(updates)
+		defer watcher.Stop()
+
+		// Expiry produces no KV event, so it is checked on a timer
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+
+		instances := map[string]Instance{}
+		var last []Instance
+		initialized := false
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case entry, ok := <-watcher.Updates():
+				if !ok {
+					return
+				}
+				if entry == nil {
+					initialized = true // Initial values delivered
+					break
+				}
+				if entry.Operation() != nats.KeyValuePut {
+					delete(instances, entry.Key())
+					break
+				}
+				var inst Instance
+				if err := json.Unmarshal(entry.Value(), &inst); err != nil {
+					log.Printf("Registry: ignoring malformed instance %s: %v", entry.Key(), err)
+					break
+				}
+				instances[entry.Key()] = inst
+			case <-ticker.C:
+			}
+			if !initialized {
+				continue
+			}
+
+			list := make([]Instance, 0, len(instances))
+			for _, inst := range instances {
+				list = append(list, inst)
+			}
+			serving := Serving(list, time.Now())
+			if last != nil && sameInstances(serving, last) {
+				continue
+			}
+			last = serving
+			select {
+			case updates <- list:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return updates, nil
+}
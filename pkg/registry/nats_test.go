@@ -0,0 +1,95 @@
+package registry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/nats/natstest"
+)
+
+func TestNATSBackendWatch(t *testing.T) {
+	_, js := natstest.NewJetStream(t)
+	backend, err := NewNATSBackend(js, "", 0)
+	if err != nil {
+		t.Fatalf("NewNATSBackend: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	updates, err := backend.Watch(ctx, "svc")
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	next := func(what string) []Instance {
+		t.Helper()
+		select {
+		case instances := <-updates:
+			return Serving(instances, time.Now())
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no update after %s", what)
+			return nil
+		}
+	}
+	if got := next("start"); len(got) != 0 {
+		t.Errorf("initial list = %v, want empty", got)
+	}
+
+	a := Instance{Service: "svc", ID: "a", Address: "10.0.0.1:50051", Health: HealthServing, ExpiresAt: time.Now().Add(time.Hour)}
+	if err := backend.Put(ctx, a); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if got := next("a put"); len(got) != 1 || got[0].Address != a.Address {
+		t.Errorf("list = %v, want a", got)
+	}
+
+	// Another service's instances are not watched; the short-lived one expires without
+	// any KV event, on the watch's timer
+	if err := backend.Put(ctx, Instance{Service: "other", ID: "x", Address: "10.0.0.9:50051", Health: HealthServing}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	b := Instance{Service: "svc", ID: "b", Address: "10.0.0.2:50051", Health: HealthServing, ExpiresAt: time.Now().Add(1500 * time.Millisecond)}
+	if err := backend.Put(ctx, b); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if got := next("b put"); len(got) != 2 || got[0].ID != "a" || got[1].ID != "b" {
+		t.Errorf("list = %v, want a and b", got)
+	}
+	if got := next("b expired"); len(got) != 1 || got[0].ID != "a" {
+		t.Errorf("list after b expired = %v, want a", got)
+	}
+
+	if err := backend.Delete(ctx, "svc", "a"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if got := next("a deleted"); len(got) != 0 {
+		t.Errorf("list after delete = %v, want empty", got)
+	}
+
+	listed, err := backend.List(ctx, "svc")
+	if err != nil || len(listed) != 1 || listed[0].ID != "b" {
+		t.Errorf("List = %v, %v; want the expired b, left for callers to filter", listed, err)
+	}
+}
+
+func TestNATSBackendRegisterFQDN(t *testing.T) {
+	_, js := natstest.NewJetStream(t)
+	backend, err := NewNATSBackend(js, "", 0)
+	if err != nil {
+		t.Fatalf("NewNATSBackend: %v", err)
+	}
+	ctx := context.Background()
+
+	// IDs taken from a fully qualified hostname are valid keys
+	reg, err := Register(ctx, backend, Instance{Service: "svc", ID: hostID("web-1.prod.example.com"), Address: "10.0.0.1:50051"}, time.Minute)
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := reg.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if err := backend.Put(ctx, Instance{Service: "svc", ID: "web-1.prod", Address: "10.0.0.1:50051"}); err == nil {
+		t.Error("Put accepted an ID with a dot")
+	}
+}
@@ -0,0 +1,101 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisBackend stores instances in a Redis hash per service, with a key per instance
+// holding its TTL so expired instances are cleaned up. Watches poll the hash.
+type RedisBackend struct {
+	client   *redis.Client
+	prefix   string
+	interval time.Duration
+}
+
+// NewRedisBackend creates a backend using keys under prefix (default "registry:").
+// Watches check for changes every interval (default: 2s).
+func NewRedisBackend(client *redis.Client, prefix string, interval time.Duration) *RedisBackend {
+	if prefix == "" {
+		prefix = "registry:"
+	}
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+	return &RedisBackend{client: client, prefix: prefix, interval: interval}
+}
+
+func (b *RedisBackend) serviceKey(service string) string {
+	return b.prefix + service
+}
+
+func (b *RedisBackend) aliveKey(service, id string) string {
+	return b.prefix + service + ":" + id
+}
+
+// Put registers or refreshes an instance
+func (b *RedisBackend) Put(ctx context.Context, inst Instance) error {
+	data, err := json.Marshal(inst)
+	if err != nil {
+		return err
+	}
+	var ttl time.Duration
+	if !inst.ExpiresAt.IsZero() {
+		ttl = time.Until(inst.ExpiresAt)
+	}
+
+	_, err = b.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, b.serviceKey(inst.Service), inst.ID, data)
+		pipe.Set(ctx, b.aliveKey(inst.Service, inst.ID), 1, ttl)
+		return nil
+	})
+	return err
+}
+
+// Delete deregisters an instance
+func (b *RedisBackend) Delete(ctx context.Context, service, id string) error {
+	_, err := b.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HDel(ctx, b.serviceKey(service), id)
+		pipe.Del(ctx, b.aliveKey(service, id))
+		return nil
+	})
+	return err
+}
+
+// List returns the instances of a service, removing the ones whose TTL key has expired
+func (b *RedisBackend) List(ctx context.Context, service string) ([]Instance, error) {
+	fields, err := b.client.HGetAll(ctx, b.serviceKey(service)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", service, err)
+	}
+
+	var instances []Instance
+	for id, data := range fields {
+		var inst Instance
+		if err := json.Unmarshal([]byte(data), &inst); err != nil {
+			log.Printf("Registry: ignoring malformed instance %s/%s: %v", service, id, err)
+			continue
+		}
+		if !inst.ExpiresAt.IsZero() && time.Now().After(inst.ExpiresAt) {
+			// Remove the entry unless a heartbeat has just renewed it
+			if n, err := b.client.Exists(ctx, b.aliveKey(service, id)).Result(); err == nil && n == 0 {
+				b.client.HDel(ctx, b.serviceKey(service), id)
+			}
+			continue
+		}
+		instances = append(instances, inst)
+	}
+	return instances, nil
+}
+
+// Watch polls the instances of a service
+func (b *RedisBackend) Watch(ctx context.Context, service string) (<-chan []Instance, error) {
+	return poll(ctx, b.interval, func(ctx context.Context) ([]Instance, error) {
+		return b.List(ctx, service)
+	}), nil
+}
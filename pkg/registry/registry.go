@@ -0,0 +1,249 @@
+// Package registry lets services register their instances and find each other. Instances
+// are stored in a pluggable backend (NATS KV, Redis or a static file) with a TTL kept
+// alive by heartbeats, and gRPC clients dial "registry:///service-name" to get the live
+// endpoint list through the resolver.
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultTTL is how long an instance stays registered without a heartbeat
+const DefaultTTL = 15 * time.Second
+
+// ErrReadOnly is returned when registering in a backend that cannot be written, such as a file
+var ErrReadOnly = errors.New("registry backend is read-only")
+
+// Health is the serving status of an instance
+type Health string
+
+const (
+	HealthServing    Health = "SERVING"
+	HealthNotServing Health = "NOT_SERVING" // Registered but not taking traffic, e.g. while draining
+)
+
+// Instance is a registered instance of a service
+type Instance struct {
+	Service  string            `json:"service"`
+	ID       string            `json:"id"`
+	Address  string            `json:"address"` // host:port reachable by other services
+	Version  string            `json:"version,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Health   Health            `json:"health"`
+
+	// ExpiresAt is when the instance is dropped without a heartbeat; zero never expires
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+// Serving reports whether the instance takes traffic at the given time
+func (i Instance) Serving(now time.Time) bool {
+	return i.Health == HealthServing && (i.ExpiresAt.IsZero() || now.Before(i.ExpiresAt))
+}
+
+// Backend stores instances
+type Backend interface {
+	// Put registers or refreshes an instance until its ExpiresAt
+	Put(ctx context.Context, inst Instance) error
+	// Delete deregisters an instance
+	Delete(ctx context.Context, service, id string) error
+	// List returns the registered instances of a service, including expired and
+	// unhealthy ones; callers filter with Instance.Serving
+	List(ctx context.Context, service string) ([]Instance, error)
+	// Watch sends the instance list of a service whenever it may have changed, until ctx is done
+	Watch(ctx context.Context, service string) (<-chan []Instance, error)
+}
+
+// Serving returns the instances taking traffic, sorted by ID for stable ordering
+func Serving(instances []Instance, now time.Time) []Instance {
+	serving := make([]Instance, 0, len(instances))
+	for _, inst := range instances {
+		if inst.Serving(now) {
+			serving = append(serving, inst)
+		}
+	}
+	sort.Slice(serving, func(a, b int) bool { return serving[a].ID < serving[b].ID })
+	return serving
+}
+
+// Registration keeps an instance registered with heartbeats until it is closed
+type Registration struct {
+	backend Backend
+	ttl     time.Duration
+
+	mu   sync.Mutex
+	inst Instance
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Register adds an instance to the registry and refreshes it every third of the TTL.
+// The ID defaults to the hostname, see hostID, and the health to SERVING.
+func Register(ctx context.Context, backend Backend, inst Instance, ttl time.Duration) (*Registration, error) {
+	if inst.Service == "" || inst.Address == "" {
+		return nil, fmt.Errorf("registry: instance needs a service and an address")
+	}
+	if inst.ID == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("registry: instance has no ID: %w", err)
+		}
+		inst.ID = hostID(hostname)
+	}
+	if inst.Health == "" {
+		inst.Health = HealthServing
+	}
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
+	r := &Registration{backend: backend, ttl: ttl, inst: inst, done: make(chan struct{})}
+	if err := r.put(ctx); err != nil {
+		return nil, err
+	}
+
+	heartbeatCtx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	go r.heartbeat(heartbeatCtx)
+
+	log.Printf("Registered %s/%s at %s", inst.Service, inst.ID, inst.Address)
+	return r, nil
+}
+
+// invalidIDChars matches the characters of a hostname a key of the NATS backend cannot hold
+var invalidIDChars = regexp.MustCompile(`[^-_=a-zA-Z0-9]`)
+
+// hostID turns a hostname into an instance ID valid in every backend: the dots of a fully
+// qualified name, and anything else outside letters, digits, '-', '_' and '=', become '-'
+func hostID(hostname string) string {
+	return invalidIDChars.ReplaceAllString(hostname, "-")
+}
+
+func (r *Registration) put(ctx context.Context) error {
+	r.mu.Lock()
+	inst := r.inst
+	r.mu.Unlock()
+
+	inst.ExpiresAt = time.Now().Add(r.ttl)
+	if err := r.backend.Put(ctx, inst); err != nil {
+		return fmt.Errorf("registry: failed to register %s/%s: %w", inst.Service, inst.ID, err)
+	}
+	return nil
+}
+
+func (r *Registration) heartbeat(ctx context.Context) {
+	defer close(r.done)
+	ticker := time.NewTicker(r.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			putCtx, cancel := context.WithTimeout(ctx, r.ttl/3)
+			if err := r.put(putCtx); err != nil && ctx.Err() == nil {
+				log.Printf("Registry heartbeat failed: %v", err)
+			}
+			cancel()
+		}
+	}
+}
+
+// Instance returns the registered instance
+func (r *Registration) Instance() Instance {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.inst
+}
+
+// SetHealth changes the instance's health and publishes it immediately
+func (r *Registration) SetHealth(ctx context.Context, health Health) error {
+	r.mu.Lock()
+	r.inst.Health = health
+	r.mu.Unlock()
+	return r.put(ctx)
+}
+
+// Close stops the heartbeats and deregisters the instance
+func (r *Registration) Close(ctx context.Context) error {
+	r.cancel()
+	<-r.done
+	inst := r.Instance()
+	if err := r.backend.Delete(ctx, inst.Service, inst.ID); err != nil {
+		return fmt.Errorf("registry: failed to deregister %s/%s: %w", inst.Service, inst.ID, err)
+	}
+	log.Printf("Deregistered %s/%s", inst.Service, inst.ID)
+	return nil
+}
+
+// poll implements Watch for backends without change notifications: the list is sent
+// on start and whenever it differs from the previous one, checking every interval.
+// Expiry is evaluated on every check, so expired instances disappear from the list.
+func poll(ctx context.Context, interval time.Duration, list func(context.Context) ([]Instance, error)) <-chan []Instance {
+	updates := make(chan []Instance, 1)
+	go func() {
+		defer close(updates)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var last []Instance
+		first := true
+		for {
+			instances, err := list(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				log.Printf("Registry: failed to list instances: %v", err)
+			} else if serving := Serving(instances, time.Now()); first || !sameInstances(serving, last) {
+				first = false
+				last = serving
+				select {
+				case updates <- instances:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return updates
+}
+
+func sameInstances(a, b []Instance) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].ID != b[i].ID || a[i].Address != b[i].Address || a[i].Version != b[i].Version ||
+			a[i].Health != b[i].Health || !sameMetadata(a[i].Metadata, b[i].Metadata) {
+			return false
+		}
+	}
+	return true
+}
+
+func sameMetadata(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
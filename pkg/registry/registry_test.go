@@ -0,0 +1,196 @@
+package registry
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memBackend is an in-memory Backend that polls for watches
+type memBackend struct {
+	mu        sync.Mutex
+	instances map[string]Instance
+	puts      int
+}
+
+func newMemBackend() *memBackend {
+	return &memBackend{instances: map[string]Instance{}}
+}
+
+func (b *memBackend) Put(ctx context.Context, inst Instance) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.instances[inst.Service+"/"+inst.ID] = inst
+	b.puts++
+	return nil
+}
+
+func (b *memBackend) Delete(ctx context.Context, service, id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.instances, service+"/"+id)
+	return nil
+}
+
+func (b *memBackend) List(ctx context.Context, service string) ([]Instance, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var instances []Instance
+	for _, inst := range b.instances {
+		if inst.Service == service {
+			instances = append(instances, inst)
+		}
+	}
+	return instances, nil
+}
+
+func (b *memBackend) Watch(ctx context.Context, service string) (<-chan []Instance, error) {
+	return poll(ctx, 10*time.Millisecond, func(ctx context.Context) ([]Instance, error) {
+		return b.List(ctx, service)
+	}), nil
+}
+
+func (b *memBackend) get(service, id string) (Instance, bool, int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	inst, ok := b.instances[service+"/"+id]
+	return inst, ok, b.puts
+}
+
+func TestHostID(t *testing.T) {
+	tests := map[string]string{
+		"web-1":                  "web-1",
+		"web-1.prod.example.com": "web-1-prod-example-com",
+		"pod_7=a":                "pod_7=a",
+		"host:name/with space":   "host-name-with-space",
+	}
+	for hostname, want := range tests {
+		if got := hostID(hostname); got != want {
+			t.Errorf("hostID(%q) = %q, want %q", hostname, got, want)
+		}
+		if !validKey.MatchString(hostID(hostname)) {
+			t.Errorf("hostID(%q) is not a valid NATS key part", hostname)
+		}
+	}
+}
+
+func TestRegistrationHeartbeatAndExpiry(t *testing.T) {
+	backend := newMemBackend()
+	ctx := context.Background()
+	const ttl = 150 * time.Millisecond
+
+	reg, err := Register(ctx, backend, Instance{Service: "svc", ID: "a", Address: "10.0.0.1:50051"}, ttl)
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	inst, ok, _ := backend.get("svc", "a")
+	if !ok || inst.Health != HealthServing || inst.ExpiresAt.IsZero() {
+		t.Fatalf("registered %+v, %v", inst, ok)
+	}
+
+	// Heartbeats keep the instance serving well past one TTL
+	time.Sleep(3 * ttl)
+	inst, _, puts := backend.get("svc", "a")
+	if !inst.Serving(time.Now()) || puts < 3 {
+		t.Errorf("after %d puts the instance expires at %v", puts, inst.ExpiresAt)
+	}
+
+	if err := reg.SetHealth(ctx, HealthNotServing); err != nil {
+		t.Fatalf("SetHealth: %v", err)
+	}
+	if inst, _, _ := backend.get("svc", "a"); inst.Serving(time.Now()) {
+		t.Error("a NOT_SERVING instance is serving")
+	}
+
+	if err := reg.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, ok, _ := backend.get("svc", "a"); ok {
+		t.Error("Close left the instance registered")
+	}
+
+	// Without heartbeats, the instance stops serving once its TTL passes
+	expiring := Instance{Service: "svc", ID: "b", Health: HealthServing, ExpiresAt: time.Now().Add(ttl)}
+	if !expiring.Serving(time.Now()) || expiring.Serving(time.Now().Add(2*ttl)) {
+		t.Error("Serving ignores ExpiresAt")
+	}
+	if got := Serving([]Instance{expiring, {ID: "c", Health: HealthServing}}, time.Now().Add(2*ttl)); len(got) != 1 || got[0].ID != "c" {
+		t.Errorf("Serving after the TTL = %v, want only the instance that never expires", got)
+	}
+}
+
+func TestRegisterDefaultsID(t *testing.T) {
+	backend := newMemBackend()
+	reg, err := Register(context.Background(), backend, Instance{Service: "svc", Address: "10.0.0.1:50051"}, time.Minute)
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	defer reg.Close(context.Background())
+	if id := reg.Instance().ID; id == "" || !validKey.MatchString(id) {
+		t.Errorf("default ID %q is not a valid key", id)
+	}
+
+	if _, err := Register(context.Background(), backend, Instance{Service: "svc"}, 0); err == nil {
+		t.Error("Register accepted an instance without an address")
+	}
+}
+
+func TestPollDetectsChanges(t *testing.T) {
+	backend := newMemBackend()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updates, _ := backend.Watch(ctx, "svc")
+
+	next := func(what string) []Instance {
+		t.Helper()
+		select {
+		case instances := <-updates:
+			return Serving(instances, time.Now())
+		case <-time.After(2 * time.Second):
+			t.Fatalf("no update after %s", what)
+			return nil
+		}
+	}
+	quiet := func(what string) {
+		t.Helper()
+		select {
+		case instances := <-updates:
+			t.Errorf("update after %s: %v", what, instances)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	if got := next("start"); len(got) != 0 {
+		t.Errorf("initial list = %v, want empty", got)
+	}
+	quiet("no change")
+
+	inst := Instance{Service: "svc", ID: "a", Address: "10.0.0.1:50051", Health: HealthServing, ExpiresAt: time.Now().Add(time.Hour)}
+	backend.Put(ctx, inst)
+	if got := next("a put"); len(got) != 1 || got[0].ID != "a" {
+		t.Errorf("list = %v, want a", got)
+	}
+
+	// A heartbeat only moves ExpiresAt, which is not a change
+	inst.ExpiresAt = time.Now().Add(2 * time.Hour)
+	backend.Put(ctx, inst)
+	quiet("a heartbeat")
+
+	inst.Metadata = map[string]string{"track": "canary"}
+	backend.Put(ctx, inst)
+	if got := next("a metadata change"); len(got) != 1 || got[0].Metadata["track"] != "canary" {
+		t.Errorf("list = %v, want a on the canary track", got)
+	}
+
+	// Expiry shows up on the next check, without any write
+	inst.ExpiresAt = time.Now().Add(100 * time.Millisecond)
+	backend.Put(ctx, inst)
+	if got := next("the TTL passed"); len(got) != 0 {
+		t.Errorf("list after expiry = %v, want empty", got)
+	}
+
+	cancel()
+	for range updates {
+	}
+}
@@ -0,0 +1,98 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/attributes"
+	"google.golang.org/grpc/resolver"
+)
+
+// Scheme is the gRPC target scheme of the registry: "registry:///user-service"
+const Scheme = "registry"
+
+// NewResolverBuilder returns a gRPC resolver for the registry scheme, backed by a
+// registry backend. Pass it to a single client with grpc.WithResolvers, or register it
+// for every client with RegisterResolver.
+func NewResolverBuilder(backend Backend) resolver.Builder {
+	return &resolverBuilder{backend: backend}
+}
+
+// RegisterResolver makes "registry:///service" targets resolve through a backend in every
+// client. Call it once at startup, before dialing.
+func RegisterResolver(backend Backend) {
+	resolver.Register(NewResolverBuilder(backend))
+}
+
+type resolverBuilder struct {
+	backend Backend
+}
+
+func (b *resolverBuilder) Scheme() string {
+	return Scheme
+}
+
+func (b *resolverBuilder) Build(target resolver.Target, cc resolver.ClientConn, opts resolver.BuildOptions) (resolver.Resolver, error) {
+	service := strings.TrimPrefix(target.Endpoint(), "/")
+	if service == "" {
+		return nil, fmt.Errorf("registry: target %s names no service", target.URL.String())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	updates, err := b.backend.Watch(ctx, service)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("registry: failed to watch %s: %w", service, err)
+	}
+
+	go func() {
+		for instances := range updates {
+			serving := Serving(instances, time.Now())
+			if len(serving) == 0 {
+				cc.ReportError(fmt.Errorf("registry: no serving instances of %s", service))
+				continue
+			}
+			addresses := make([]resolver.Address, 0, len(serving))
+			for _, inst := range serving {
+				addresses = append(addresses, resolver.Address{
+					Addr:               inst.Address,
+					BalancerAttributes: attributes.New(instanceKey{}, instanceAttr{inst}),
+				})
+			}
+			cc.UpdateState(resolver.State{Addresses: addresses})
+		}
+	}()
+	return &registryResolver{cancel: cancel}, nil
+}
+
+type registryResolver struct {
+	cancel context.CancelFunc
+}
+
+// ResolveNow does nothing: the backend pushes changes
+func (r *registryResolver) ResolveNow(resolver.ResolveNowOptions) {}
+
+func (r *registryResolver) Close() {
+	r.cancel()
+}
+
+type instanceKey struct{}
+
+// instanceAttr wraps an Instance so attributes can compare it
+type instanceAttr struct {
+	Instance
+}
+
+func (a instanceAttr) Equal(o any) bool {
+	other, ok := o.(instanceAttr)
+	return ok && sameInstances([]Instance{a.Instance}, []Instance{other.Instance})
+}
+
+// InstanceFromAddress returns the instance behind a resolved address, for balancers and
+// interceptors that route by version or metadata
+func InstanceFromAddress(addr resolver.Address) (Instance, bool) {
+	attr, ok := addr.BalancerAttributes.Value(instanceKey{}).(instanceAttr)
+	return attr.Instance, ok
+}
@@ -0,0 +1,73 @@
+package registry
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/resolver"
+)
+
+// clientConn records what a resolver reports
+type clientConn struct {
+	resolver.ClientConn
+	states chan resolver.State
+	errs   chan error
+}
+
+func (c *clientConn) UpdateState(state resolver.State) error {
+	c.states <- state
+	return nil
+}
+
+func (c *clientConn) ReportError(err error) {
+	c.errs <- err
+}
+
+func TestResolver(t *testing.T) {
+	backend := newMemBackend()
+	ctx := context.Background()
+	expires := time.Now().Add(time.Hour)
+	backend.Put(ctx, Instance{Service: "svc", ID: "b", Address: "10.0.0.2:50051", Health: HealthServing, ExpiresAt: expires})
+	backend.Put(ctx, Instance{Service: "svc", ID: "a", Address: "10.0.0.1:50051", Version: "1.2.0", Health: HealthServing, ExpiresAt: expires})
+	backend.Put(ctx, Instance{Service: "svc", ID: "c", Address: "10.0.0.3:50051", Health: HealthNotServing, ExpiresAt: expires})
+
+	if _, err := NewResolverBuilder(backend).Build(resolver.Target{URL: url.URL{Scheme: Scheme, Path: "/"}}, &clientConn{}, resolver.BuildOptions{}); err == nil {
+		t.Error("Build accepted a target without a service")
+	}
+
+	cc := &clientConn{states: make(chan resolver.State, 10), errs: make(chan error, 10)}
+	r, err := NewResolverBuilder(backend).Build(resolver.Target{URL: url.URL{Scheme: Scheme, Path: "/svc"}}, cc, resolver.BuildOptions{})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	defer r.Close()
+
+	// Serving instances only, sorted by ID, each carrying its instance
+	select {
+	case state := <-cc.states:
+		if len(state.Addresses) != 2 || state.Addresses[0].Addr != "10.0.0.1:50051" || state.Addresses[1].Addr != "10.0.0.2:50051" {
+			t.Fatalf("addresses = %v, want a and b", state.Addresses)
+		}
+		inst, ok := InstanceFromAddress(state.Addresses[0])
+		if !ok || inst.ID != "a" || inst.Version != "1.2.0" {
+			t.Errorf("InstanceFromAddress = %+v, %v; want a at 1.2.0", inst, ok)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no state reported")
+	}
+
+	backend.Delete(ctx, "svc", "a")
+	backend.Delete(ctx, "svc", "b")
+	select {
+	case err := <-cc.errs:
+		if err == nil {
+			t.Error("nil error reported")
+		}
+	case state := <-cc.states:
+		t.Errorf("state %v reported without serving instances, want an error", state)
+	case <-time.After(2 * time.Second):
+		t.Fatal("losing every instance was not reported")
+	}
+}
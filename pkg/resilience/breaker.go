@@ -0,0 +1,219 @@
+package resilience
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/metrics"
+)
+
+// State is the state of a circuit breaker
+type State string
+
+const (
+	StateClosed   State = "closed"    // Calls flow normally
+	StateOpen     State = "open"      // Calls fail fast until OpenTimeout elapses
+	StateHalfOpen State = "half_open" // A limited number of probe calls test recovery
+)
+
+// ErrCircuitOpen is returned for calls rejected by an open breaker
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+var (
+	breakerStateMetric       = metrics.Map("circuit_breaker_state")
+	breakerTransitionsMetric = metrics.Map("circuit_breaker_transitions_total")
+	breakerRejectedMetric    = metrics.Map("circuit_breaker_rejected_total")
+)
+
+// BreakerConfig holds circuit breaker settings
+type BreakerConfig struct {
+	FailureThreshold int           // Consecutive failures that open the breaker (default: 5)
+	OpenTimeout      time.Duration // Time spent open before probing (default: 10s)
+	HalfOpenProbes   int           // Successful probes needed to close again (default: 1)
+}
+
+func (c BreakerConfig) withDefaults() BreakerConfig {
+	if c.FailureThreshold <= 0 {
+		c.FailureThreshold = 5
+	}
+	if c.OpenTimeout <= 0 {
+		c.OpenTimeout = 10 * time.Second
+	}
+	if c.HalfOpenProbes <= 0 {
+		c.HalfOpenProbes = 1
+	}
+	return c
+}
+
+// StateChange describes a breaker transition
+type StateChange struct {
+	Target string    `json:"target"`
+	From   State     `json:"from"`
+	To     State     `json:"to"`
+	At     time.Time `json:"at"`
+}
+
+// Breaker is a consecutive-failure circuit breaker with half-open probing
+type Breaker struct {
+	target string
+	cfg    BreakerConfig
+	events chan StateChange // Delivered in order to onChange by a single goroutine
+
+	mu        sync.Mutex
+	state     State
+	failures  int
+	openedAt  time.Time
+	inFlight  int // Probes in flight while half-open
+	successes int // Successful probes while half-open
+}
+
+// NewBreaker creates a closed breaker for a target. onChange, if set, is called in order
+// for every transition, from a separate goroutine.
+func NewBreaker(target string, cfg BreakerConfig, onChange func(StateChange)) *Breaker {
+	b := &Breaker{
+		target: target,
+		cfg:    cfg.withDefaults(),
+		state:  StateClosed,
+	}
+	if onChange != nil {
+		b.events = make(chan StateChange, 64)
+		go func() {
+			for change := range b.events {
+				onChange(change)
+			}
+		}()
+	}
+	metrics.SetString(breakerStateMetric, target, string(StateClosed))
+	return b
+}
+
+// State returns the current state
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.currentState(time.Now())
+}
+
+// Allow reserves a call. It returns ErrCircuitOpen if the call must fail fast;
+// otherwise the caller must report the outcome through done.
+func (b *Breaker) Allow() (done func(success bool), err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	switch b.currentState(now) {
+	case StateOpen:
+		breakerRejectedMetric.Add(b.target, 1)
+		return nil, ErrCircuitOpen
+	case StateHalfOpen:
+		if b.inFlight >= b.cfg.HalfOpenProbes {
+			breakerRejectedMetric.Add(b.target, 1)
+			return nil, ErrCircuitOpen
+		}
+		b.inFlight++
+		return b.doneFunc(true), nil
+	default:
+		return b.doneFunc(false), nil
+	}
+}
+
+func (b *Breaker) doneFunc(probe bool) func(bool) {
+	var once sync.Once
+	return func(success bool) {
+		once.Do(func() { b.record(probe, success) })
+	}
+}
+
+func (b *Breaker) record(probe, success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if probe {
+		b.inFlight--
+	}
+
+	switch b.currentState(now) {
+	case StateHalfOpen:
+		if !probe {
+			// Outcome of a call started before the breaker opened
+			return
+		}
+		if !success {
+			b.transition(StateOpen, now)
+			return
+		}
+		b.successes++
+		if b.successes >= b.cfg.HalfOpenProbes {
+			b.transition(StateClosed, now)
+		}
+	case StateClosed:
+		if success {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.cfg.FailureThreshold {
+			b.transition(StateOpen, now)
+		}
+	}
+}
+
+// currentState moves an open breaker to half-open once its timeout has elapsed. Callers hold mu.
+func (b *Breaker) currentState(now time.Time) State {
+	if b.state == StateOpen && now.Sub(b.openedAt) >= b.cfg.OpenTimeout {
+		b.transition(StateHalfOpen, now)
+	}
+	return b.state
+}
+
+// transition changes state and emits the change. Callers hold mu.
+func (b *Breaker) transition(to State, now time.Time) {
+	from := b.state
+	b.state = to
+	b.failures = 0
+	b.successes = 0
+	b.inFlight = 0
+	if to == StateOpen {
+		b.openedAt = now
+	}
+
+	metrics.SetString(breakerStateMetric, b.target, string(to))
+	breakerTransitionsMetric.Add(b.target+" "+string(to), 1)
+	log.Printf("Circuit breaker for %s: %s -> %s", b.target, from, to)
+
+	if b.events != nil {
+		// Never block calls on a slow handler (e.g. publishing); drop instead
+		select {
+		case b.events <- StateChange{Target: b.target, From: from, To: to, At: now}:
+		default:
+			log.Printf("Dropped circuit breaker event for %s: handler is falling behind", b.target)
+		}
+	}
+}
+
+// breakers holds one breaker per target
+type breakers struct {
+	cfg      BreakerConfig
+	onChange func(StateChange)
+
+	mu sync.Mutex
+	m  map[string]*Breaker
+}
+
+func (bs *breakers) get(target string) *Breaker {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	if b, ok := bs.m[target]; ok {
+		return b
+	}
+	if bs.m == nil {
+		bs.m = map[string]*Breaker{}
+	}
+	b := NewBreaker(target, bs.cfg, bs.onChange)
+	bs.m[target] = b
+	return b
+}
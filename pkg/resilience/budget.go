@@ -0,0 +1,76 @@
+package resilience
+
+import (
+	"sync"
+	"time"
+
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/metrics"
+)
+
+var budgetExhaustedMetric = metrics.Map("retry_budget_exhausted_total")
+
+// BudgetConfig holds retry budget settings
+type BudgetConfig struct {
+	// Ratio of retries to original requests allowed, e.g. 0.1 permits one retry per ten calls
+	Ratio float64
+	// MinPerSecond retries are always allowed, so low-traffic clients can still retry
+	MinPerSecond float64
+}
+
+// DefaultBudget allows retries for 10% of requests plus 10 per second
+var DefaultBudget = BudgetConfig{Ratio: 0.1, MinPerSecond: 10}
+
+// RetryBudget is a token bucket limiting retries (and hedges) to a fraction of the traffic,
+// so a failing dependency doesn't turn retries into a storm
+type RetryBudget struct {
+	name string
+	cfg  BudgetConfig
+
+	mu       sync.Mutex
+	tokens   float64
+	max      float64
+	lastFill time.Time
+}
+
+// NewRetryBudget creates a budget; name labels its metrics
+func NewRetryBudget(name string, cfg BudgetConfig) *RetryBudget {
+	max := cfg.MinPerSecond * 10
+	if max < 10 {
+		max = 10
+	}
+	return &RetryBudget{
+		name:     name,
+		cfg:      cfg,
+		tokens:   max,
+		max:      max,
+		lastFill: time.Now(),
+	}
+}
+
+// Deposit credits the budget for an original request
+func (b *RetryBudget) Deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.fill(time.Now())
+	b.tokens = min(b.max, b.tokens+b.cfg.Ratio)
+}
+
+// Withdraw reports whether a retry may be sent, consuming a token if so
+func (b *RetryBudget) Withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.fill(time.Now())
+	if b.tokens < 1 {
+		budgetExhaustedMetric.Add(b.name, 1)
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// fill adds the time-based allowance. Callers hold mu.
+func (b *RetryBudget) fill(now time.Time) {
+	elapsed := now.Sub(b.lastFill).Seconds()
+	b.lastFill = now
+	b.tokens = min(b.max, b.tokens+elapsed*b.cfg.MinPerSecond)
+}
@@ -0,0 +1,28 @@
+package resilience
+
+import (
+	"encoding/json"
+	"log"
+
+	natslib "github.com/nats-io/nats.go"
+)
+
+// DefaultEventSubject is the NATS subject breaker transitions are published to
+const DefaultEventSubject = "resilience.breaker.state"
+
+// PublishStateChanges returns an OnStateChange handler publishing transitions as JSON to NATS
+func PublishStateChanges(nc *natslib.Conn, subject string) func(StateChange) {
+	if subject == "" {
+		subject = DefaultEventSubject
+	}
+	return func(change StateChange) {
+		data, err := json.Marshal(change)
+		if err != nil {
+			log.Printf("Failed to encode breaker state change: %v", err)
+			return
+		}
+		if err := nc.Publish(subject, data); err != nil {
+			log.Printf("Failed to publish breaker state change: %v", err)
+		}
+	}
+}
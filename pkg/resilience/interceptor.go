@@ -0,0 +1,180 @@
+package resilience
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Client applies circuit breakers, budgeted retries and hedging to outgoing calls
+type Client struct {
+	cfg      Config
+	breakers *breakers
+	budget   *RetryBudget
+}
+
+// New creates a client with one breaker per target and a shared retry budget
+func New(name string, cfg Config) *Client {
+	budget := cfg.Budget
+	if budget == (BudgetConfig{}) {
+		budget = DefaultBudget
+	}
+	return &Client{
+		cfg:      cfg,
+		breakers: &breakers{cfg: cfg.Breaker, onChange: cfg.OnStateChange},
+		budget:   NewRetryBudget(name, budget),
+	}
+}
+
+// Breaker returns the breaker of a target
+func (c *Client) Breaker(target string) *Breaker {
+	return c.breakers.get(target)
+}
+
+// DialOptions returns the interceptors as dial options. gRPC's own retries are disabled
+// so they don't multiply with the budgeted retries of this client.
+func (c *Client) DialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithDisableRetry(),
+		grpc.WithChainUnaryInterceptor(c.UnaryClientInterceptor()),
+		grpc.WithChainStreamInterceptor(c.StreamClientInterceptor()),
+	}
+}
+
+// UnaryClientInterceptor applies the method's policy to unary calls
+func (c *Client) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		policy := c.cfg.policy(method)
+		call := func(ctx context.Context, reply interface{}) (bool, error) {
+			return c.guarded(cc.Target(), policy, func() error {
+				return invoker(ctx, method, req, reply, cc, opts...)
+			})
+		}
+
+		c.budget.Deposit()
+
+		attempts := 1
+		if policy.Retry != nil && policy.Retry.MaxAttempts > 1 {
+			attempts = policy.Retry.MaxAttempts
+		}
+
+		for attempt := 0; ; attempt++ {
+			var rejected bool
+			var err error
+			if msg, ok := reply.(proto.Message); ok && policy.Hedge != nil {
+				rejected, err = c.hedged(ctx, policy, msg, call)
+			} else {
+				rejected, err = call(ctx, reply)
+			}
+
+			if err == nil || rejected || attempt+1 >= attempts || !retryable(policy.Retry, err) {
+				return err
+			}
+			if !c.budget.Withdraw() {
+				return err
+			}
+
+			timer := time.NewTimer(policy.Retry.backoff(attempt))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return status.FromContextError(ctx.Err()).Err()
+			case <-timer.C:
+			}
+		}
+	}
+}
+
+// StreamClientInterceptor applies the target's breaker to stream creation.
+// Streams are neither retried nor hedged since messages may already have been consumed.
+func (c *Client) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		var stream grpc.ClientStream
+		_, err := c.guarded(cc.Target(), c.cfg.policy(method), func() error {
+			var err error
+			stream, err = streamer(ctx, desc, cc, method, opts...)
+			return err
+		})
+		return stream, err
+	}
+}
+
+// guarded runs fn through the target's breaker. It reports whether the breaker rejected the call.
+func (c *Client) guarded(target string, policy Policy, fn func() error) (bool, error) {
+	if policy.DisableBreaker {
+		return false, fn()
+	}
+
+	done, err := c.breakers.get(target).Allow()
+	if err != nil {
+		return true, status.Errorf(codes.Unavailable, "%v for %s", err, target)
+	}
+	err = fn()
+	// Hedges cancelled because another copy succeeded count as successes: the target answered
+	done(!isFailure(err))
+	return false, err
+}
+
+type hedgeResult struct {
+	reply    proto.Message
+	err      error
+	rejected bool
+}
+
+// hedged sends the call and, while no response has arrived, up to MaxHedges more copies
+// spaced by the hedge delay. The first success is copied into reply and the rest cancelled.
+func (c *Client) hedged(ctx context.Context, policy Policy, reply proto.Message, call func(context.Context, interface{}) (bool, error)) (bool, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	maxCalls := 1 + max(policy.Hedge.MaxHedges, 1)
+	results := make(chan hedgeResult, maxCalls)
+	launch := func() {
+		r := reply.ProtoReflect().New().Interface()
+		rejected, err := call(ctx, r)
+		results <- hedgeResult{reply: r, err: err, rejected: rejected}
+	}
+
+	go launch()
+	launched, received := 1, 0
+	timer := time.NewTimer(policy.Hedge.Delay)
+	defer timer.Stop()
+
+	var last hedgeResult
+	for {
+		select {
+		case <-timer.C:
+			if launched < maxCalls && c.budget.Withdraw() {
+				go launch()
+				launched++
+				timer.Reset(policy.Hedge.Delay)
+			}
+		case r := <-results:
+			received++
+			if r.err == nil {
+				proto.Reset(reply)
+				proto.Merge(reply, r.reply)
+				return false, nil
+			}
+			last = r
+			if !r.rejected && !retryable(policy.Retry, r.err) {
+				return false, r.err
+			}
+			if received == launched {
+				// Every copy failed; send the next hedge right away instead of waiting
+				if launched < maxCalls && !r.rejected && c.budget.Withdraw() {
+					go launch()
+					launched++
+					continue
+				}
+				return last.rejected, last.err
+			}
+		case <-ctx.Done():
+			return false, status.FromContextError(ctx.Err()).Err()
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package resilience
+
+import (
+	"math/rand"
+	"path"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RetryPolicy retries failed calls with exponential backoff and full jitter
+type RetryPolicy struct {
+	MaxAttempts    int           // Including the first attempt
+	InitialBackoff time.Duration // Default: 50ms
+	MaxBackoff     time.Duration // Default: 1s
+	Multiplier     float64       // Default: 2
+	RetryableCodes []codes.Code  // Default: Unavailable
+}
+
+// HedgePolicy sends additional copies of a call that hasn't answered within Delay.
+// Only configure it for idempotent methods; the first successful response wins.
+type HedgePolicy struct {
+	Delay     time.Duration
+	MaxHedges int // Extra copies beyond the original call (default: 1)
+}
+
+// Policy is the resilience policy of a method
+type Policy struct {
+	Retry          *RetryPolicy
+	Hedge          *HedgePolicy
+	DisableBreaker bool
+}
+
+// Config holds the resilience settings of a client
+type Config struct {
+	Default Policy
+	// Methods overrides the default per method; keys are full method names or
+	// patterns such as "/users.UserService/*". The longest matching key wins.
+	Methods map[string]Policy
+
+	Breaker       BreakerConfig
+	Budget        BudgetConfig       // Default: DefaultBudget
+	OnStateChange func(StateChange) // Called on every breaker transition, e.g. PublishStateChanges
+}
+
+// policy returns the policy for a full method name
+func (c *Config) policy(method string) Policy {
+	if p, ok := c.Methods[method]; ok {
+		return p
+	}
+	best, found := "", false
+	for pattern := range c.Methods {
+		if ok, err := path.Match(pattern, method); err == nil && ok && len(pattern) > len(best) {
+			best, found = pattern, true
+		}
+	}
+	if found {
+		return c.Methods[best]
+	}
+	return c.Default
+}
+
+func (p *RetryPolicy) backoff(retry int) time.Duration {
+	initial, max, multiplier := p.InitialBackoff, p.MaxBackoff, p.Multiplier
+	if initial <= 0 {
+		initial = 50 * time.Millisecond
+	}
+	if max <= 0 {
+		max = time.Second
+	}
+	if multiplier < 1 {
+		multiplier = 2
+	}
+
+	d := float64(initial)
+	for i := 0; i < retry; i++ {
+		d *= multiplier
+		if d >= float64(max) {
+			d = float64(max)
+			break
+		}
+	}
+	return time.Duration(rand.Int63n(int64(d) + 1))
+}
+
+// retryable reports whether err may be retried or hedged under the policy
+func retryable(p *RetryPolicy, err error) bool {
+	code := status.Code(err)
+	if p == nil || len(p.RetryableCodes) == 0 {
+		return code == codes.Unavailable
+	}
+	for _, c := range p.RetryableCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// isFailure reports whether err indicates an unhealthy target rather than a bad request
+func isFailure(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted,
+		codes.Internal, codes.Unknown, codes.DataLoss:
+		return true
+	}
+	return false
+}
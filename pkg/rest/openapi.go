@@ -0,0 +1,249 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// errorSchema is the Connect error body returned for failed calls
+var errorSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"code":    map[string]any{"type": "string"},
+		"message": map[string]any{"type": "string"},
+		"details": map[string]any{"type": "array", "items": map[string]any{"type": "object"}},
+	},
+}
+
+// wellKnownSchemas are the JSON forms of the well-known types
+var wellKnownSchemas = map[protoreflect.FullName]map[string]any{
+	"google.protobuf.Timestamp":   {"type": "string", "format": "date-time"},
+	"google.protobuf.Duration":    {"type": "string", "example": "1.5s"},
+	"google.protobuf.FieldMask":   {"type": "string"},
+	"google.protobuf.Empty":       {"type": "object"},
+	"google.protobuf.Struct":      {"type": "object", "additionalProperties": true},
+	"google.protobuf.Value":       {},
+	"google.protobuf.ListValue":   {"type": "array", "items": map[string]any{}},
+	"google.protobuf.Any":         {"type": "object", "properties": map[string]any{"@type": map[string]any{"type": "string"}}, "additionalProperties": true},
+	"google.protobuf.StringValue": {"type": "string"},
+	"google.protobuf.BytesValue":  {"type": "string", "format": "byte"},
+	"google.protobuf.BoolValue":   {"type": "boolean"},
+	"google.protobuf.Int32Value":  {"type": "integer", "format": "int32"},
+	"google.protobuf.UInt32Value": {"type": "integer", "format": "int64"},
+	"google.protobuf.Int64Value":  {"type": "string", "format": "int64"},
+	"google.protobuf.UInt64Value": {"type": "string", "format": "uint64"},
+	"google.protobuf.FloatValue":  {"type": "number", "format": "float"},
+	"google.protobuf.DoubleValue": {"type": "number", "format": "double"},
+}
+
+// OpenAPI returns an OpenAPI 3 document describing the REST routes
+func (t *Transcoder) OpenAPI() map[string]any {
+	g := &openAPIGenerator{schemas: map[string]any{}}
+	paths := map[string]map[string]any{}
+	for _, r := range t.routes {
+		p := r.template.openAPIPath()
+		if paths[p] == nil {
+			paths[p] = map[string]any{}
+		}
+		method := strings.ToLower(r.method)
+		if r.method == "*" {
+			method = "post"
+		}
+		paths[p][method] = g.operation(r)
+	}
+	g.schemas["Error"] = errorSchema
+
+	var titles []string
+	for _, sd := range t.services {
+		titles = append(titles, string(sd.FullName()))
+	}
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   strings.Join(titles, ", "),
+			"version": "v1",
+		},
+		"paths":      paths,
+		"components": map[string]any{"schemas": g.schemas},
+	}
+}
+
+func (t *Transcoder) serveOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(t.OpenAPI())
+}
+
+type openAPIGenerator struct {
+	schemas map[string]any
+}
+
+func (g *openAPIGenerator) operation(r *route) map[string]any {
+	md := r.desc
+	op := map[string]any{
+		"operationId": string(md.Parent().Name()) + "_" + string(md.Name()),
+		"tags":        []string{string(md.Parent().Name())},
+	}
+	if comments := md.ParentFile().SourceLocations().ByDescriptor(md).LeadingComments; comments != "" {
+		op["description"] = strings.TrimSpace(comments)
+	}
+
+	bound := map[string]bool{}
+	var params []any
+	for _, v := range r.template.variables {
+		bound[v.field] = true
+		fd, _ := findField(md.Input(), v.field)
+		params = append(params, map[string]any{
+			"name":     v.field,
+			"in":       "path",
+			"required": true,
+			"schema":   g.fieldSchema(fd),
+		})
+	}
+
+	switch r.body {
+	case "":
+		params = append(params, g.queryParams(md.Input(), "", bound)...)
+	case "*":
+		op["requestBody"] = g.requestBody(g.messageSchema(md.Input()))
+	default:
+		fd, _ := findField(md.Input(), r.body)
+		bound[r.body] = true
+		op["requestBody"] = g.requestBody(g.fieldSchema(fd))
+		params = append(params, g.queryParams(md.Input(), "", bound)...)
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+
+	response := g.messageSchema(md.Output())
+	if r.responseBody != "" {
+		fd, _ := findField(md.Output(), r.responseBody)
+		response = g.fieldSchema(fd)
+	}
+	op["responses"] = map[string]any{
+		"200": map[string]any{
+			"description": "OK",
+			"content":     map[string]any{"application/json": map[string]any{"schema": response}},
+		},
+		"default": map[string]any{
+			"description": "Error",
+			"content": map[string]any{"application/json": map[string]any{
+				"schema": map[string]any{"$ref": "#/components/schemas/Error"},
+			}},
+		},
+	}
+	return op
+}
+
+func (g *openAPIGenerator) requestBody(schema map[string]any) map[string]any {
+	return map[string]any{
+		"required": true,
+		"content":  map[string]any{"application/json": map[string]any{"schema": schema}},
+	}
+}
+
+// queryParams lists the fields that can be set from the query string: scalars, enums,
+// repeated scalars and the fields of nested messages, as dotted paths
+func (g *openAPIGenerator) queryParams(md protoreflect.MessageDescriptor, prefix string, bound map[string]bool) []any {
+	var params []any
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		name := prefix + string(fd.Name())
+		if bound[name] || fd.IsMap() {
+			continue
+		}
+		if fd.Message() != nil {
+			if _, ok := wellKnownSchemas[fd.Message().FullName()]; !ok {
+				// Bounded depth keeps recursive messages finite
+				if !fd.IsList() && strings.Count(prefix, ".") < 3 {
+					params = append(params, g.queryParams(fd.Message(), name+".", bound)...)
+				}
+				continue
+			}
+		}
+		params = append(params, map[string]any{
+			"name":   name,
+			"in":     "query",
+			"schema": g.fieldSchema(fd),
+		})
+	}
+	return params
+}
+
+// messageSchema returns a reference to the component schema of a message, generating it once
+func (g *openAPIGenerator) messageSchema(md protoreflect.MessageDescriptor) map[string]any {
+	if schema, ok := wellKnownSchemas[md.FullName()]; ok {
+		return schema
+	}
+	name := string(md.FullName())
+	ref := map[string]any{"$ref": "#/components/schemas/" + name}
+	if _, ok := g.schemas[name]; ok {
+		return ref
+	}
+	schema := map[string]any{"type": "object"}
+	g.schemas[name] = schema // Registered first so recursive messages terminate
+
+	properties := map[string]any{}
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		properties[fd.JSONName()] = g.fieldSchema(fd)
+	}
+	if len(properties) > 0 {
+		schema["properties"] = properties
+	}
+	if comments := md.ParentFile().SourceLocations().ByDescriptor(md).LeadingComments; comments != "" {
+		schema["description"] = strings.TrimSpace(comments)
+	}
+	return ref
+}
+
+// fieldSchema returns the schema of a field's JSON value
+func (g *openAPIGenerator) fieldSchema(fd protoreflect.FieldDescriptor) map[string]any {
+	if fd.IsMap() {
+		return map[string]any{"type": "object", "additionalProperties": g.valueSchema(fd.MapValue())}
+	}
+	if fd.IsList() {
+		return map[string]any{"type": "array", "items": g.valueSchema(fd)}
+	}
+	return g.valueSchema(fd)
+}
+
+// valueSchema returns the schema of a single value of a field, following the protojson mapping
+func (g *openAPIGenerator) valueSchema(fd protoreflect.FieldDescriptor) map[string]any {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return map[string]any{"type": "string"}
+	case protoreflect.BytesKind:
+		return map[string]any{"type": "string", "format": "byte"}
+	case protoreflect.BoolKind:
+		return map[string]any{"type": "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return map[string]any{"type": "integer", "format": "int32"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		// protojson encodes 64-bit integers as strings
+		return map[string]any{"type": "string", "format": "int64"}
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return map[string]any{"type": "string", "format": "uint64"}
+	case protoreflect.FloatKind:
+		return map[string]any{"type": "number", "format": "float"}
+	case protoreflect.DoubleKind:
+		return map[string]any{"type": "number", "format": "double"}
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		names := make([]string, 0, values.Len())
+		for i := 0; i < values.Len(); i++ {
+			names = append(names, string(values.Get(i).Name()))
+		}
+		return map[string]any{"type": "string", "enum": names}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return g.messageSchema(fd.Message())
+	}
+	return map[string]any{}
+}
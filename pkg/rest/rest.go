@@ -0,0 +1,491 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	grpcpkg "github.com/LucasPluta/GoMicroserviceFramework/pkg/grpc"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// OpenAPIPath is where Mount serves the OpenAPI document
+const OpenAPIPath = "/openapi.json"
+
+// route is one HTTP binding of a unary method
+type route struct {
+	method       string // HTTP method, or "*" for any
+	template     *template
+	body         string // "", "*" or a field path
+	responseBody string
+	procedure    string
+	desc         protoreflect.MethodDescriptor
+}
+
+// Transcoder serves REST/JSON routes declared with google.api.http options by calling
+// the methods through a Connect handler, so the calls pass through the same middleware
+// and interceptors (authentication, authorization, deadlines, load shedding) as RPCs.
+// Only unary methods can be transcoded, and request bodies are limited to the RPC
+// message size (grpc.MaxMessageSize).
+//
+// Errors are Connect JSON error bodies with the matching HTTP status, not the
+// google.rpc.Status shape of other transcoders: the code is a lowercase string and the
+// details carry their type and base64 value:
+//
+//	{"code": "not_found", "message": "user 42 not found", "details": [{"type": "google.rpc.ErrorInfo", "value": "..."}]}
+type Transcoder struct {
+	next     http.Handler
+	services []protoreflect.ServiceDescriptor
+	routes   []*route
+}
+
+// NewTranscoder builds the routes of the named services (e.g. "users.UserService") from
+// their registered descriptors. next serves the methods over Connect: typically the
+// grpc.Mux, or the Connect handler given to it.
+func NewTranscoder(next http.Handler, services ...string) (*Transcoder, error) {
+	t := &Transcoder{next: next}
+	for _, name := range services {
+		d, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(name))
+		if err != nil {
+			return nil, fmt.Errorf("service %s is not registered: %w", name, err)
+		}
+		sd, ok := d.(protoreflect.ServiceDescriptor)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a service", name)
+		}
+		t.services = append(t.services, sd)
+
+		methods := sd.Methods()
+		for i := 0; i < methods.Len(); i++ {
+			if err := t.addMethod(methods.Get(i)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Match literal segments before wildcards, whatever the declaration order
+	sort.SliceStable(t.routes, func(i, j int) bool {
+		return literalCount(t.routes[i].template) > literalCount(t.routes[j].template)
+	})
+	return t, nil
+}
+
+func (t *Transcoder) addMethod(md protoreflect.MethodDescriptor) error {
+	rule, ok := proto.GetExtension(md.Options(), annotations.E_Http).(*annotations.HttpRule)
+	if !ok || rule == nil {
+		return nil
+	}
+	if md.IsStreamingClient() || md.IsStreamingServer() {
+		return fmt.Errorf("%s: only unary methods can have google.api.http rules", md.FullName())
+	}
+
+	procedure := fmt.Sprintf("/%s/%s", md.Parent().FullName(), md.Name())
+	for _, binding := range append([]*annotations.HttpRule{rule}, rule.AdditionalBindings...) {
+		r, err := newRoute(md, procedure, binding)
+		if err != nil {
+			return fmt.Errorf("%s: %w", md.FullName(), err)
+		}
+		t.routes = append(t.routes, r)
+	}
+	return nil
+}
+
+func newRoute(md protoreflect.MethodDescriptor, procedure string, rule *annotations.HttpRule) (*route, error) {
+	var method, path string
+	switch pattern := rule.Pattern.(type) {
+	case *annotations.HttpRule_Get:
+		method, path = http.MethodGet, pattern.Get
+	case *annotations.HttpRule_Put:
+		method, path = http.MethodPut, pattern.Put
+	case *annotations.HttpRule_Post:
+		method, path = http.MethodPost, pattern.Post
+	case *annotations.HttpRule_Delete:
+		method, path = http.MethodDelete, pattern.Delete
+	case *annotations.HttpRule_Patch:
+		method, path = http.MethodPatch, pattern.Patch
+	case *annotations.HttpRule_Custom:
+		method, path = pattern.Custom.GetKind(), pattern.Custom.GetPath()
+	default:
+		return nil, fmt.Errorf("http rule without a pattern")
+	}
+
+	tmpl, err := parseTemplate(path)
+	if err != nil {
+		return nil, err
+	}
+	// Routes are mounted under their literal prefix; a leading variable would capture every path
+	if len(tmpl.segments) == 0 || tmpl.segments[0].kind != segmentLiteral {
+		return nil, fmt.Errorf("path template %q must start with a literal segment", path)
+	}
+
+	r := &route{
+		method:       method,
+		template:     tmpl,
+		body:         rule.Body,
+		responseBody: rule.ResponseBody,
+		procedure:    procedure,
+		desc:         md,
+	}
+	for _, v := range tmpl.variables {
+		if _, err := findField(md.Input(), v.field); err != nil {
+			return nil, fmt.Errorf("path variable: %w", err)
+		}
+	}
+	if r.body != "" && r.body != "*" {
+		if _, err := findField(md.Input(), r.body); err != nil {
+			return nil, fmt.Errorf("body: %w", err)
+		}
+	}
+	if r.responseBody != "" {
+		if _, err := findField(md.Output(), r.responseBody); err != nil {
+			return nil, fmt.Errorf("response_body: %w", err)
+		}
+	}
+	return r, nil
+}
+
+func literalCount(t *template) int {
+	n := 0
+	for _, seg := range t.segments {
+		if seg.kind == segmentLiteral {
+			n++
+		}
+	}
+	return n
+}
+
+// Mount registers the routes and the OpenAPI document on a mux, such as grpc.Mux,
+// under the literal prefix of each path template
+func (t *Transcoder) Mount(mux interface{ Handle(string, http.Handler) }) {
+	mounted := map[string]bool{}
+	for _, r := range t.routes {
+		prefix, _ := r.template.literalPrefix()
+		pattern := prefix
+		if r.method != "*" {
+			pattern = r.method + " " + prefix
+		}
+		if !mounted[pattern] {
+			mounted[pattern] = true
+			mux.Handle(pattern, t)
+		}
+	}
+	mux.Handle("GET "+OpenAPIPath, http.HandlerFunc(t.serveOpenAPI))
+}
+
+// ServeHTTP transcodes a REST request to its method and the response back
+func (t *Transcoder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	route, vars := t.match(r)
+	if route == nil {
+		writeError(w, http.StatusNotFound, "not_found", "no route for "+r.Method+" "+r.URL.Path)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, grpcpkg.MaxMessageSize)
+	req, err := route.request(r, vars)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, "resource_exhausted", err.Error())
+			return
+		}
+		writeError(w, http.StatusBadRequest, "invalid_argument", err.Error())
+		return
+	}
+	body, err := protojson.Marshal(req)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal", err.Error())
+		return
+	}
+
+	// Call the method as a Connect unary JSON request. The caller's headers, such as
+	// Authorization, are kept; Origin is dropped since CORS was handled for the REST request.
+	inner := r.Clone(r.Context())
+	inner.Method = http.MethodPost
+	inner.URL.Path, inner.URL.RawPath, inner.URL.RawQuery = route.procedure, "", ""
+	inner.RequestURI = ""
+	inner.Body = io.NopCloser(bytes.NewReader(body))
+	inner.ContentLength = int64(len(body))
+	inner.Header.Set("Content-Type", "application/json")
+	inner.Header.Set("Connect-Protocol-Version", "1")
+	inner.Header.Del("Content-Length")
+	inner.Header.Del("Content-Encoding")
+	inner.Header.Del("Accept-Encoding")
+	inner.Header.Del("Origin")
+
+	rec := newRecorder()
+	t.next.ServeHTTP(rec, inner)
+
+	for key, values := range rec.header {
+		if key != "Content-Length" {
+			w.Header()[key] = values
+		}
+	}
+	out := rec.body.Bytes()
+	if rec.status == http.StatusOK && route.responseBody != "" {
+		if out, err = route.extractResponse(out); err != nil {
+			writeError(w, http.StatusInternalServerError, "internal", err.Error())
+			return
+		}
+	}
+	w.WriteHeader(rec.status)
+	w.Write(out)
+}
+
+func (t *Transcoder) match(r *http.Request) (*route, map[string]string) {
+	path := r.URL.EscapedPath()
+	method := r.Method
+	if method == http.MethodHead {
+		// As the mux does, GET routes serve HEAD; the server discards the body
+		method = http.MethodGet
+	}
+	for _, route := range t.routes {
+		if route.method != "*" && route.method != method {
+			continue
+		}
+		if vars, ok := route.template.match(path); ok {
+			return route, vars
+		}
+	}
+	return nil, nil
+}
+
+// request builds the method's request message from the body, then the path variables,
+// then the query parameters
+func (rt *route) request(r *http.Request, vars map[string]string) (proto.Message, error) {
+	mt, err := protoregistry.GlobalTypes.FindMessageByName(rt.desc.Input().FullName())
+	if err != nil {
+		return nil, err
+	}
+	msg := mt.New().Interface()
+
+	if rt.body != "" {
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read body: %w", err)
+		}
+		if len(bytes.TrimSpace(data)) > 0 {
+			if rt.body != "*" {
+				// Decode {"field": body} so protojson handles every field type
+				fd, _ := findField(rt.desc.Input(), rt.body)
+				data = wrapJSON(strings.Split(rt.body, "."), fd, data)
+			}
+			if err := protojson.Unmarshal(data, msg); err != nil {
+				return nil, fmt.Errorf("invalid body: %w", err)
+			}
+		}
+	}
+
+	bound := map[string]bool{}
+	for field, value := range vars {
+		if err := setField(msg, field, []string{value}); err != nil {
+			return nil, err
+		}
+		bound[field] = true
+	}
+
+	if rt.body == "*" {
+		return msg, nil
+	}
+	for key, values := range r.URL.Query() {
+		if bound[key] || (rt.body != "" && (key == rt.body || strings.HasPrefix(key, rt.body+"."))) {
+			continue
+		}
+		if err := setField(msg, key, values); err != nil {
+			return nil, err
+		}
+	}
+	return msg, nil
+}
+
+// extractResponse returns the response_body field of a JSON response
+func (rt *route) extractResponse(data []byte) ([]byte, error) {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	output := rt.desc.Output()
+	for _, name := range strings.Split(rt.responseBody, ".") {
+		fields, ok := v.(map[string]any)
+		if !ok {
+			return []byte("null"), nil
+		}
+		fd, err := findField(output, name)
+		if err != nil {
+			return nil, err
+		}
+		value, present := fields[fd.JSONName()]
+		if !present {
+			// protojson omits default values
+			return []byte("null"), nil
+		}
+		v = value
+		if fd.Message() != nil {
+			output = fd.Message()
+		}
+	}
+	return json.Marshal(v)
+}
+
+// wrapJSON nests data under the JSON names of a field path
+func wrapJSON(path []string, fd protoreflect.FieldDescriptor, data []byte) []byte {
+	for i := len(path) - 1; i >= 0; i-- {
+		name := path[i]
+		if i == len(path)-1 {
+			name = fd.JSONName()
+		}
+		data = append(append([]byte(`{"`+name+`":`), data...), '}')
+	}
+	return data
+}
+
+// findField resolves a dotted field path of proto field names in a message
+func findField(md protoreflect.MessageDescriptor, path string) (protoreflect.FieldDescriptor, error) {
+	var fd protoreflect.FieldDescriptor
+	for i, name := range strings.Split(path, ".") {
+		if i > 0 {
+			if fd.Message() == nil || fd.IsList() || fd.IsMap() {
+				return nil, fmt.Errorf("%s: %s is not a message field", path, fd.Name())
+			}
+			md = fd.Message()
+		}
+		fd = md.Fields().ByName(protoreflect.Name(name))
+		if fd == nil {
+			fd = md.Fields().ByJSONName(name)
+		}
+		if fd == nil {
+			return nil, fmt.Errorf("unknown field %q in %s", name, md.FullName())
+		}
+	}
+	return fd, nil
+}
+
+// setField sets a field from path or query parameter values, creating parent messages
+func setField(msg proto.Message, path string, values []string) error {
+	m := msg.ProtoReflect()
+	names := strings.Split(path, ".")
+	for _, name := range names[:len(names)-1] {
+		fd, err := findField(m.Descriptor(), name)
+		if err != nil {
+			return err
+		}
+		if fd.Message() == nil || fd.IsList() || fd.IsMap() {
+			return fmt.Errorf("%s: %s is not a message field", path, name)
+		}
+		m = m.Mutable(fd).Message()
+	}
+	fd, err := findField(m.Descriptor(), names[len(names)-1])
+	if err != nil {
+		return err
+	}
+	if fd.IsMap() {
+		return fmt.Errorf("%s: map fields cannot be set from the URL", path)
+	}
+
+	if fd.IsList() {
+		list := m.Mutable(fd).List()
+		for _, s := range values {
+			v, err := parseValue(fd, list.NewElement(), s)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			list.Append(v)
+		}
+		return nil
+	}
+	if len(values) != 1 {
+		return fmt.Errorf("%s: expected a single value", path)
+	}
+	var zero protoreflect.Value
+	if fd.Message() != nil {
+		zero = m.NewField(fd)
+	}
+	v, err := parseValue(fd, zero, values[0])
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	m.Set(fd, v)
+	return nil
+}
+
+// parseValue parses a scalar, enum or well-known message (Timestamp, Duration, wrappers)
+// from its URL form. empty is a new message value for message fields.
+func parseValue(fd protoreflect.FieldDescriptor, empty protoreflect.Value, s string) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(s), nil
+	case protoreflect.BytesKind:
+		var b []byte
+		if err := json.Unmarshal([]byte(strconv.Quote(s)), &b); err != nil {
+			return protoreflect.Value{}, fmt.Errorf("invalid base64 bytes %q", s)
+		}
+		return protoreflect.ValueOfBytes(b), nil
+	case protoreflect.BoolKind:
+		b, err := strconv.ParseBool(s)
+		return protoreflect.ValueOfBool(b), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		n, err := strconv.ParseInt(s, 10, 32)
+		return protoreflect.ValueOfInt32(int32(n)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		n, err := strconv.ParseInt(s, 10, 64)
+		return protoreflect.ValueOfInt64(n), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		n, err := strconv.ParseUint(s, 10, 32)
+		return protoreflect.ValueOfUint32(uint32(n)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		n, err := strconv.ParseUint(s, 10, 64)
+		return protoreflect.ValueOfUint64(n), err
+	case protoreflect.FloatKind:
+		f, err := strconv.ParseFloat(s, 32)
+		return protoreflect.ValueOfFloat32(float32(f)), err
+	case protoreflect.DoubleKind:
+		f, err := strconv.ParseFloat(s, 64)
+		return protoreflect.ValueOfFloat64(f), err
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByName(protoreflect.Name(s)); ev != nil {
+			return protoreflect.ValueOfEnum(ev.Number()), nil
+		}
+		n, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("invalid %s value %q", fd.Enum().FullName(), s)
+		}
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(n)), nil
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		// Well-known types have a JSON string form, e.g. "2024-01-02T15:04:05Z" or "1.5s"
+		if err := protojson.Unmarshal([]byte(strconv.Quote(s)), empty.Message().Interface()); err != nil {
+			return protoreflect.Value{}, fmt.Errorf("invalid %s value %q", fd.Message().FullName(), s)
+		}
+		return empty, nil
+	}
+	return protoreflect.Value{}, fmt.Errorf("unsupported field kind %s", fd.Kind())
+}
+
+// recorder buffers the response of the transcoded call
+type recorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newRecorder() *recorder {
+	return &recorder{header: http.Header{}, status: http.StatusOK}
+}
+
+func (r *recorder) Header() http.Header         { return r.header }
+func (r *recorder) Write(b []byte) (int, error) { return r.body.Write(b) }
+func (r *recorder) WriteHeader(status int)      { r.status = status }
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"code": code, "message": message})
+}
@@ -0,0 +1,402 @@
+package rest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	_ "google.golang.org/genproto/googleapis/api/annotations" // Resolves [google.api.http] below
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	_ "google.golang.org/protobuf/types/known/timestamppb" // Dependency of the test proto
+)
+
+// libraryProto is the descriptor of a small annotated service:
+//
+//	enum View { VIEW_UNSPECIFIED = 0; BASIC = 1; FULL = 2; }
+//	message Author { string name = 1; }
+//	message Book { string name = 1; string title = 2; int32 pages = 3; Author author = 4; }
+//	message GetBookRequest { string name = 1; View view = 2; }
+//	message CreateBookRequest { string parent = 1; Book book = 2; string request_id = 3; }
+//	message ListBooksRequest {
+//	  string parent = 1; int32 page_size = 2; repeated string tags = 3; Author author = 4;
+//	  google.protobuf.Timestamp published_after = 5;
+//	}
+//	message ListBooksResponse { repeated Book books = 1; string next_page_token = 2; }
+//	message UpdateBookRequest { Book book = 1; }
+//
+//	service Library {
+//	  rpc GetBook(GetBookRequest) returns (Book) { get: "/v1/{name=shelves/*/books/*}" }
+//	  rpc GetLatestBook(GetBookRequest) returns (Book) { get: "/v1/{name=shelves/*}/books/latest" }
+//	  rpc CreateBook(CreateBookRequest) returns (Book) { post: "/v1/{parent=shelves/*}/books" body: "book" }
+//	  rpc ListBooks(ListBooksRequest) returns (ListBooksResponse) { get: "/v1/{parent=shelves/*}/books" response_body: "books" }
+//	  rpc UpdateBook(UpdateBookRequest) returns (Book) { patch: "/v1/{book.name=shelves/*/books/*}" body: "*" }
+//	  rpc ArchiveBook(GetBookRequest) returns (Book) { post: "/v1/{name=shelves/*/books/*}:archive" body: "*" }
+//	}
+const libraryProto = `
+name: "resttest/library.proto"
+package: "resttest"
+syntax: "proto3"
+dependency: "google/protobuf/timestamp.proto"
+enum_type {
+  name: "View"
+  value { name: "VIEW_UNSPECIFIED" number: 0 }
+  value { name: "BASIC" number: 1 }
+  value { name: "FULL" number: 2 }
+}
+message_type {
+  name: "Author"
+  field { name: "name" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "name" }
+}
+message_type {
+  name: "Book"
+  field { name: "name" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "name" }
+  field { name: "title" number: 2 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "title" }
+  field { name: "pages" number: 3 label: LABEL_OPTIONAL type: TYPE_INT32 json_name: "pages" }
+  field { name: "author" number: 4 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".resttest.Author" json_name: "author" }
+}
+message_type {
+  name: "GetBookRequest"
+  field { name: "name" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "name" }
+  field { name: "view" number: 2 label: LABEL_OPTIONAL type: TYPE_ENUM type_name: ".resttest.View" json_name: "view" }
+}
+message_type {
+  name: "CreateBookRequest"
+  field { name: "parent" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "parent" }
+  field { name: "book" number: 2 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".resttest.Book" json_name: "book" }
+  field { name: "request_id" number: 3 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "requestId" }
+}
+message_type {
+  name: "ListBooksRequest"
+  field { name: "parent" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "parent" }
+  field { name: "page_size" number: 2 label: LABEL_OPTIONAL type: TYPE_INT32 json_name: "pageSize" }
+  field { name: "tags" number: 3 label: LABEL_REPEATED type: TYPE_STRING json_name: "tags" }
+  field { name: "author" number: 4 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".resttest.Author" json_name: "author" }
+  field { name: "published_after" number: 5 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".google.protobuf.Timestamp" json_name: "publishedAfter" }
+}
+message_type {
+  name: "ListBooksResponse"
+  field { name: "books" number: 1 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".resttest.Book" json_name: "books" }
+  field { name: "next_page_token" number: 2 label: LABEL_OPTIONAL type: TYPE_STRING json_name: "nextPageToken" }
+}
+message_type {
+  name: "UpdateBookRequest"
+  field { name: "book" number: 1 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".resttest.Book" json_name: "book" }
+}
+service {
+  name: "Library"
+  method {
+    name: "GetBook" input_type: ".resttest.GetBookRequest" output_type: ".resttest.Book"
+    options { [google.api.http] { get: "/v1/{name=shelves/*/books/*}" } }
+  }
+  method {
+    name: "GetLatestBook" input_type: ".resttest.GetBookRequest" output_type: ".resttest.Book"
+    options { [google.api.http] { get: "/v1/{name=shelves/*}/books/latest" } }
+  }
+  method {
+    name: "CreateBook" input_type: ".resttest.CreateBookRequest" output_type: ".resttest.Book"
+    options { [google.api.http] { post: "/v1/{parent=shelves/*}/books" body: "book" } }
+  }
+  method {
+    name: "ListBooks" input_type: ".resttest.ListBooksRequest" output_type: ".resttest.ListBooksResponse"
+    options { [google.api.http] { get: "/v1/{parent=shelves/*}/books" response_body: "books" } }
+  }
+  method {
+    name: "UpdateBook" input_type: ".resttest.UpdateBookRequest" output_type: ".resttest.Book"
+    options { [google.api.http] { patch: "/v1/{book.name=shelves/*/books/*}" body: "*" } }
+  }
+  method {
+    name: "ArchiveBook" input_type: ".resttest.GetBookRequest" output_type: ".resttest.Book"
+    options { [google.api.http] { post: "/v1/{name=shelves/*/books/*}:archive" body: "*" } }
+  }
+}
+`
+
+// The test proto is registered once, as generated code would be
+func init() {
+	var fdp descriptorpb.FileDescriptorProto
+	if err := prototext.Unmarshal([]byte(libraryProto), &fdp); err != nil {
+		panic(err)
+	}
+	fd, err := protodesc.NewFile(&fdp, protoregistry.GlobalFiles)
+	if err != nil {
+		panic(err)
+	}
+	if err := protoregistry.GlobalFiles.RegisterFile(fd); err != nil {
+		panic(err)
+	}
+	for i := 0; i < fd.Messages().Len(); i++ {
+		if err := protoregistry.GlobalTypes.RegisterMessage(dynamicpb.NewMessageType(fd.Messages().Get(i))); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func TestParseTemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		path     string
+		want     map[string]string // nil: no match
+	}{
+		{"/v1/books", "/v1/books", map[string]string{}},
+		{"/v1/books", "/v1/books/1", nil},
+		{"/v1/books/{id}", "/v1/books/42", map[string]string{"id": "42"}},
+		{"/v1/books/{id}", "/v1/books/", nil},
+		{"/v1/{name=shelves/*/books/*}", "/v1/shelves/s1/books/b1", map[string]string{"name": "shelves/s1/books/b1"}},
+		{"/v1/{name=shelves/*/books/*}", "/v1/shelves/s1/authors/a1", nil},
+		{"/v1/{book.name=shelves/*}/x", "/v1/shelves/s1/x", map[string]string{"book.name": "shelves/s1"}},
+		{"/v1/{name=files/**}", "/v1/files/a/b/c.txt", map[string]string{"name": "files/a/b/c.txt"}},
+		{"/v1/{name=books/*}:publish", "/v1/books/1:publish", map[string]string{"name": "books/1"}},
+		{"/v1/{name=books/*}:publish", "/v1/books/1", nil},
+		{"/v1/books/{id}", "/v1/books/a%20b", map[string]string{"id": "a b"}},
+		{"/v1/*/books", "/v1/any/books", map[string]string{}},
+	}
+	for _, tt := range tests {
+		tmpl, err := parseTemplate(tt.template)
+		if err != nil {
+			t.Errorf("parseTemplate(%q): %v", tt.template, err)
+			continue
+		}
+		got, ok := tmpl.match(tt.path)
+		if tt.want == nil {
+			if ok {
+				t.Errorf("%q matched %q: %v", tt.template, tt.path, got)
+			}
+			continue
+		}
+		if !ok || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q match %q = %v, %v; want %v", tt.template, tt.path, got, ok, tt.want)
+		}
+	}
+
+	for _, invalid := range []string{"v1/books", "/v1/{id", "/v1/**/books", "/v1/{=*}", "/v1/bo*ks", "/v1//books"} {
+		if _, err := parseTemplate(invalid); err == nil {
+			t.Errorf("parseTemplate(%q) succeeded", invalid)
+		}
+	}
+}
+
+// call is a Connect call received by the fake service
+type call struct {
+	procedure string
+	request   map[string]any
+}
+
+// newLibrary mounts the Library routes on a mux in front of a fake Connect service that
+// records the calls and answers with a book, or a book list for ListBooks
+func newLibrary(t *testing.T) (*http.ServeMux, *[]call) {
+	t.Helper()
+	var calls []call
+	service := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]any
+		data, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(data, &req); err != nil {
+			t.Errorf("transcoded request is not JSON: %q", data)
+		}
+		calls = append(calls, call{procedure: r.URL.Path, request: req})
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("transcoded Content-Type = %q", r.Header.Get("Content-Type"))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case req["name"] == "shelves/s1/books/missing":
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"code":"not_found","message":"no such book"}`)
+		case strings.HasSuffix(r.URL.Path, "/ListBooks"):
+			io.WriteString(w, `{"books":[{"name":"shelves/s1/books/b1"}],"nextPageToken":"next"}`)
+		default:
+			io.WriteString(w, `{"name":"shelves/s1/books/b1","title":"Go"}`)
+		}
+	})
+
+	transcoder, err := NewTranscoder(service, "resttest.Library")
+	if err != nil {
+		t.Fatalf("NewTranscoder: %v", err)
+	}
+	mux := http.NewServeMux()
+	transcoder.Mount(mux)
+	return mux, &calls
+}
+
+func TestTranscoder(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+		wantCall   string // Method called, "" for none
+		wantReq    string // JSON of the request message
+		wantResp   string // JSON of the response, "" to skip
+	}{
+		{
+			name: "path variable and query", method: http.MethodGet, target: "/v1/shelves/s1/books/b1?view=FULL",
+			wantStatus: http.StatusOK, wantCall: "GetBook", wantReq: `{"name":"shelves/s1/books/b1","view":"FULL"}`,
+			wantResp: `{"name":"shelves/s1/books/b1","title":"Go"}`,
+		},
+		{
+			name: "HEAD of a GET route", method: http.MethodHead, target: "/v1/shelves/s1/books/b1",
+			wantStatus: http.StatusOK, wantCall: "GetBook", wantReq: `{"name":"shelves/s1/books/b1"}`,
+		},
+		{
+			name: "literal route before a wildcard declared first", method: http.MethodGet, target: "/v1/shelves/s1/books/latest",
+			wantStatus: http.StatusOK, wantCall: "GetLatestBook", wantReq: `{"name":"shelves/s1"}`,
+		},
+		{
+			name: "body field", method: http.MethodPost, target: "/v1/shelves/s1/books?request_id=r1", body: `{"title":"Go","pages":300}`,
+			wantStatus: http.StatusOK, wantCall: "CreateBook",
+			wantReq: `{"parent":"shelves/s1","book":{"title":"Go","pages":300},"requestId":"r1"}`,
+		},
+		{
+			name: "repeated, nested and well-known query parameters", method: http.MethodGet,
+			target:     "/v1/shelves/s1/books?pageSize=10&tags=a&tags=b&author.name=Ann&published_after=2024-01-02T15:04:05Z",
+			wantStatus: http.StatusOK, wantCall: "ListBooks",
+			wantReq:  `{"parent":"shelves/s1","pageSize":10,"tags":["a","b"],"author":{"name":"Ann"},"publishedAfter":"2024-01-02T15:04:05Z"}`,
+			wantResp: `[{"name":"shelves/s1/books/b1"}]`,
+		},
+		{
+			name: "whole body with a nested path variable", method: http.MethodPatch, target: "/v1/shelves/s1/books/b1", body: `{"book":{"title":"New"}}`,
+			wantStatus: http.StatusOK, wantCall: "UpdateBook", wantReq: `{"book":{"name":"shelves/s1/books/b1","title":"New"}}`,
+		},
+		{
+			name: "custom verb", method: http.MethodPost, target: "/v1/shelves/s1/books/b1:archive",
+			wantStatus: http.StatusOK, wantCall: "ArchiveBook", wantReq: `{"name":"shelves/s1/books/b1"}`,
+		},
+		{
+			name: "error status of the method", method: http.MethodGet, target: "/v1/shelves/s1/books/missing",
+			wantStatus: http.StatusNotFound, wantCall: "GetBook", wantReq: `{"name":"shelves/s1/books/missing"}`,
+			wantResp: `{"code":"not_found","message":"no such book"}`,
+		},
+		{name: "unknown query parameter", method: http.MethodGet, target: "/v1/shelves/s1/books/b1?color=red", wantStatus: http.StatusBadRequest},
+		{name: "invalid query value", method: http.MethodGet, target: "/v1/shelves/s1/books?page_size=many", wantStatus: http.StatusBadRequest},
+		{name: "invalid body", method: http.MethodPost, target: "/v1/shelves/s1/books", body: `{"pages":"many"}`, wantStatus: http.StatusBadRequest},
+		{name: "no route for the method", method: http.MethodDelete, target: "/v1/shelves/s1/books/b1", wantStatus: http.StatusMethodNotAllowed},
+		{name: "no route for the path", method: http.MethodGet, target: "/v1/shelves/s1/authors/a1", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux, calls := newLibrary(t)
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantCall == "" {
+				if len(*calls) > 0 {
+					t.Errorf("called %s", (*calls)[0].procedure)
+				}
+				return
+			}
+			if len(*calls) != 1 || (*calls)[0].procedure != "/resttest.Library/"+tt.wantCall {
+				t.Fatalf("calls = %v, want one to %s", *calls, tt.wantCall)
+			}
+			var want map[string]any
+			if err := json.Unmarshal([]byte(tt.wantReq), &want); err != nil {
+				t.Fatalf("bad wantReq: %v", err)
+			}
+			if got := (*calls)[0].request; !reflect.DeepEqual(got, want) {
+				t.Errorf("request = %v, want %v", got, want)
+			}
+			if tt.wantResp != "" && strings.TrimSpace(rec.Body.String()) != tt.wantResp {
+				t.Errorf("response = %s, want %s", rec.Body, tt.wantResp)
+			}
+		})
+	}
+}
+
+func TestTranscoderRejectsBadRules(t *testing.T) {
+	if _, err := NewTranscoder(http.NotFoundHandler(), "resttest.Missing"); err == nil {
+		t.Error("NewTranscoder accepted an unregistered service")
+	}
+	if _, err := NewTranscoder(http.NotFoundHandler(), "resttest.Book"); err == nil {
+		t.Error("NewTranscoder accepted a message as a service")
+	}
+}
+
+func TestOpenAPI(t *testing.T) {
+	transcoder, err := NewTranscoder(http.NotFoundHandler(), "resttest.Library")
+	if err != nil {
+		t.Fatalf("NewTranscoder: %v", err)
+	}
+	// Round trip through JSON, as served
+	data, err := json.Marshal(transcoder.OpenAPI())
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var doc struct {
+		Paths map[string]map[string]struct {
+			OperationID string `json:"operationId"`
+			Parameters  []struct {
+				Name, In string
+				Schema   map[string]any
+			}
+			RequestBody struct {
+				Content map[string]struct{ Schema map[string]any }
+			} `json:"requestBody"`
+			Responses map[string]struct {
+				Content map[string]struct{ Schema map[string]any }
+			}
+		}
+		Components struct {
+			Schemas map[string]map[string]any
+		}
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	wantOps := map[string]string{
+		"get /v1/{name}":              "Library_GetBook",
+		"get /v1/{name}/books/latest": "Library_GetLatestBook",
+		"post /v1/{parent}/books":     "Library_CreateBook",
+		"get /v1/{parent}/books":      "Library_ListBooks",
+		"patch /v1/{book.name}":       "Library_UpdateBook",
+		"post /v1/{name}:archive":     "Library_ArchiveBook",
+	}
+	for key, want := range wantOps {
+		method, path, _ := strings.Cut(key, " ")
+		if got := doc.Paths[path][method].OperationID; got != want {
+			t.Errorf("%s operationId = %q, want %q", key, got, want)
+		}
+	}
+
+	list := doc.Paths["/v1/{parent}/books"]["get"]
+	params := map[string]string{}
+	for _, p := range list.Parameters {
+		params[p.Name] = p.In
+	}
+	wantParams := map[string]string{"parent": "path", "page_size": "query", "tags": "query", "author.name": "query", "published_after": "query"}
+	if !reflect.DeepEqual(params, wantParams) {
+		t.Errorf("ListBooks parameters = %v, want %v", params, wantParams)
+	}
+	schema := list.Responses["200"].Content["application/json"].Schema
+	if schema["type"] != "array" || !reflect.DeepEqual(schema["items"], map[string]any{"$ref": "#/components/schemas/resttest.Book"}) {
+		t.Errorf("ListBooks response_body schema = %v, want an array of Book", schema)
+	}
+
+	create := doc.Paths["/v1/{parent}/books"]["post"]
+	if got := create.RequestBody.Content["application/json"].Schema["$ref"]; got != "#/components/schemas/resttest.Book" {
+		t.Errorf("CreateBook body schema = %v, want Book", got)
+	}
+	for _, p := range create.Parameters {
+		if p.Name == "book.title" {
+			t.Error("CreateBook lists a body field as a query parameter")
+		}
+	}
+
+	for _, name := range []string{"resttest.Book", "resttest.Author", "Error"} {
+		if doc.Components.Schemas[name] == nil {
+			t.Errorf("schema %s is missing", name)
+		}
+	}
+}
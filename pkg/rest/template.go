@@ -0,0 +1,246 @@
+package rest
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// segment kinds of a path template
+const (
+	segmentLiteral = iota
+	segmentWildcard
+	segmentDeepWildcard // "**", matching the rest of the path
+)
+
+type segment struct {
+	kind    int
+	literal string
+}
+
+// variable binds the path segments [start, end) to a request field. end is -1 when the
+// variable ends with "**".
+type variable struct {
+	field      string
+	start, end int
+}
+
+// template is a parsed google.api.http path template, such as
+// "/v1/{name=shelves/*/books/*}:publish"
+type template struct {
+	raw       string
+	segments  []segment
+	variables []variable
+	verb      string
+}
+
+// parseTemplate parses a path template:
+//
+//	Template = "/" Segments [ Verb ] ;
+//	Segments = Segment { "/" Segment } ;
+//	Segment  = "*" | "**" | LITERAL | Variable ;
+//	Variable = "{" FieldPath [ "=" Segments ] "}" ;
+//	Verb     = ":" LITERAL ;
+func parseTemplate(raw string) (*template, error) {
+	if !strings.HasPrefix(raw, "/") {
+		return nil, fmt.Errorf("path template %q must start with /", raw)
+	}
+	t := &template{raw: raw}
+
+	// The verb follows the last ':' outside a variable
+	path, depth := raw[1:], 0
+	for i := len(path) - 1; i >= 0; i-- {
+		switch path[i] {
+		case '}':
+			depth++
+		case '{':
+			depth--
+		case '/':
+			if depth == 0 {
+				i = 0
+			}
+		case ':':
+			if depth == 0 {
+				path, t.verb = path[:i], path[i+1:]
+				i = 0
+			}
+		}
+	}
+
+	for len(path) > 0 {
+		var part string
+		if path[0] == '{' {
+			end := strings.IndexByte(path, '}')
+			if end < 0 {
+				return nil, fmt.Errorf("path template %q has an unterminated variable", raw)
+			}
+			part, path = path[:end+1], path[end+1:]
+			if err := t.addVariable(part[1 : len(part)-1]); err != nil {
+				return nil, fmt.Errorf("path template %q: %w", raw, err)
+			}
+		} else {
+			end := strings.IndexByte(path, '/')
+			if end < 0 {
+				end = len(path)
+			}
+			part, path = path[:end], path[end:]
+			if err := t.addSegment(part); err != nil {
+				return nil, fmt.Errorf("path template %q: %w", raw, err)
+			}
+		}
+		if len(path) > 0 {
+			if path[0] != '/' {
+				return nil, fmt.Errorf("path template %q: expected / after %q", raw, part)
+			}
+			path = path[1:]
+		}
+	}
+	return t, nil
+}
+
+func (t *template) addSegment(s string) error {
+	if len(t.segments) > 0 && t.segments[len(t.segments)-1].kind == segmentDeepWildcard {
+		return fmt.Errorf("** must be the last segment")
+	}
+	switch s {
+	case "":
+		return fmt.Errorf("empty segment")
+	case "*":
+		t.segments = append(t.segments, segment{kind: segmentWildcard})
+	case "**":
+		t.segments = append(t.segments, segment{kind: segmentDeepWildcard})
+	default:
+		if strings.ContainsAny(s, "{}*") {
+			return fmt.Errorf("invalid literal %q", s)
+		}
+		t.segments = append(t.segments, segment{kind: segmentLiteral, literal: s})
+	}
+	return nil
+}
+
+func (t *template) addVariable(s string) error {
+	field, pattern, found := strings.Cut(s, "=")
+	if field == "" {
+		return fmt.Errorf("variable without a field")
+	}
+	if !found {
+		pattern = "*"
+	}
+	v := variable{field: field, start: len(t.segments)}
+	for _, part := range strings.Split(pattern, "/") {
+		if err := t.addSegment(part); err != nil {
+			return err
+		}
+	}
+	v.end = len(t.segments)
+	if t.segments[v.end-1].kind == segmentDeepWildcard {
+		v.end = -1
+	}
+	t.variables = append(t.variables, v)
+	return nil
+}
+
+// match matches a request path (escaped, as in URL.EscapedPath) against the template,
+// returning the variable values keyed by field path
+func (t *template) match(path string) (map[string]string, bool) {
+	path = strings.TrimPrefix(path, "/")
+	if t.verb != "" {
+		var ok bool
+		if path, ok = strings.CutSuffix(path, ":"+t.verb); !ok {
+			return nil, false
+		}
+	}
+	parts := strings.Split(path, "/")
+
+	for i, seg := range t.segments {
+		switch seg.kind {
+		case segmentDeepWildcard:
+			parts = append(parts[:i:i], strings.Join(parts[i:], "/"))
+		case segmentWildcard:
+			if i >= len(parts) || parts[i] == "" {
+				return nil, false
+			}
+		case segmentLiteral:
+			if i >= len(parts) || parts[i] != seg.literal {
+				return nil, false
+			}
+		}
+	}
+	if len(parts) != len(t.segments) {
+		return nil, false
+	}
+
+	values := make(map[string]string, len(t.variables))
+	for _, v := range t.variables {
+		end := v.end
+		if end < 0 {
+			end = len(parts)
+		}
+		decoded := make([]string, 0, end-v.start)
+		for _, part := range parts[v.start:end] {
+			// Multi-segment values keep their separators, so only segments are unescaped
+			for _, p := range strings.Split(part, "/") {
+				unescaped, err := url.PathUnescape(p)
+				if err != nil {
+					return nil, false
+				}
+				decoded = append(decoded, unescaped)
+			}
+		}
+		values[v.field] = strings.Join(decoded, "/")
+	}
+	return values, true
+}
+
+// literalPrefix returns the literal segments before the first wildcard or variable, and
+// whether the whole template is literal
+func (t *template) literalPrefix() (string, bool) {
+	var b strings.Builder
+	for _, seg := range t.segments {
+		if seg.kind != segmentLiteral {
+			return b.String() + "/", false
+		}
+		b.WriteString("/" + seg.literal)
+	}
+	if t.verb != "" {
+		b.WriteString(":" + t.verb)
+	}
+	return b.String(), true
+}
+
+// openAPIPath renders the template as an OpenAPI path, such as "/v1/{name}:publish"
+func (t *template) openAPIPath() string {
+	var parts []string
+	for i := 0; i < len(t.segments); i++ {
+		if v, ok := t.variableAt(i); ok {
+			parts = append(parts, "{"+v.field+"}")
+			if v.end < 0 {
+				break
+			}
+			i = v.end - 1
+			continue
+		}
+		switch t.segments[i].kind {
+		case segmentWildcard:
+			parts = append(parts, "*")
+		case segmentDeepWildcard:
+			parts = append(parts, "**")
+		default:
+			parts = append(parts, t.segments[i].literal)
+		}
+	}
+	path := "/" + strings.Join(parts, "/")
+	if t.verb != "" {
+		path += ":" + t.verb
+	}
+	return path
+}
+
+func (t *template) variableAt(i int) (variable, bool) {
+	for _, v := range t.variables {
+		if v.start == i {
+			return v, true
+		}
+	}
+	return variable{}, false
+}
@@ -0,0 +1,117 @@
+package saga
+
+import (
+	"context"
+	"errors"
+
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/saga/sagapb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AdminServer implements the SagaAdminService RPCs on top of an Orchestrator
+type AdminServer struct {
+	sagapb.UnimplementedSagaAdminServiceServer
+	orchestrator *Orchestrator
+}
+
+// NewAdminServer creates an admin server for the given orchestrator
+func NewAdminServer(o *Orchestrator) *AdminServer {
+	return &AdminServer{
+		orchestrator: o,
+	}
+}
+
+// RegisterAdminServer registers the saga admin RPCs on a gRPC server or ConnectAdapter
+func RegisterAdminServer(server grpc.ServiceRegistrar, o *Orchestrator) {
+	sagapb.RegisterSagaAdminServiceServer(server, NewAdminServer(o))
+}
+
+// GetSaga returns a single saga instance
+func (s *AdminServer) GetSaga(ctx context.Context, req *sagapb.GetSagaRequest) (*sagapb.Saga, error) {
+	if req.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	inst, err := s.orchestrator.Get(ctx, req.Id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return s.toProto(inst), nil
+}
+
+// ListSagas returns saga instances filtered by status
+func (s *AdminServer) ListSagas(ctx context.Context, req *sagapb.ListSagasRequest) (*sagapb.ListSagasResponse, error) {
+	statuses := make([]Status, len(req.Statuses))
+	for i, st := range req.Statuses {
+		statuses[i] = Status(st)
+	}
+
+	instances, err := s.orchestrator.List(ctx, statuses...)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &sagapb.ListSagasResponse{}
+	for _, inst := range instances {
+		resp.Sagas = append(resp.Sagas, s.toProto(inst))
+	}
+	return resp, nil
+}
+
+// RetrySaga resumes a saga in its current direction
+func (s *AdminServer) RetrySaga(ctx context.Context, req *sagapb.RetrySagaRequest) (*sagapb.Saga, error) {
+	if req.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	inst, err := s.orchestrator.Retry(ctx, req.Id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return s.toProto(inst), nil
+}
+
+// CompensateSaga aborts a saga and undoes its completed steps
+func (s *AdminServer) CompensateSaga(ctx context.Context, req *sagapb.CompensateSagaRequest) (*sagapb.Saga, error) {
+	if req.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	inst, err := s.orchestrator.Compensate(ctx, req.Id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return s.toProto(inst), nil
+}
+
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, ErrVersionConflict):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, ErrFinished), errors.Is(err, ErrLeased):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func (s *AdminServer) toProto(inst *Instance) *sagapb.Saga {
+	return &sagapb.Saga{
+		Id:          inst.ID,
+		Workflow:    inst.Workflow,
+		Status:      string(inst.Status),
+		CurrentStep: int32(inst.CurrentStep),
+		StepName:    s.orchestrator.StepName(inst),
+		Data:        inst.Data,
+		Error:       inst.Error,
+		CreatedAt:   inst.CreatedAt.Unix(),
+		UpdatedAt:   inst.UpdatedAt.Unix(),
+	}
+}
@@ -0,0 +1,130 @@
+package saga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/nats-io/nats.go"
+)
+
+// DefaultBucket is the JetStream KV bucket used by KVStore when none is configured
+const DefaultBucket = "sagas"
+
+// KVStore persists saga instances in a JetStream KV bucket, keyed by saga ID
+type KVStore struct {
+	kv nats.KeyValue
+}
+
+// NewKVStore creates a KV-backed store, creating the bucket if needed
+func NewKVStore(js nats.JetStreamContext, bucket string) (*KVStore, error) {
+	if bucket == "" {
+		bucket = DefaultBucket
+	}
+
+	kv, err := js.KeyValue(bucket)
+	if errors.Is(err, nats.ErrBucketNotFound) {
+		kv, err = js.CreateKeyValue(&nats.KeyValueConfig{
+			Bucket:      bucket,
+			Description: "Saga instances",
+			History:     1,
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open saga bucket %s: %w", bucket, err)
+	}
+
+	return &KVStore{kv: kv}, nil
+}
+
+// Create stores a new instance
+func (s *KVStore) Create(ctx context.Context, inst *Instance) error {
+	data, err := json.Marshal(inst)
+	if err != nil {
+		return fmt.Errorf("failed to encode saga: %w", err)
+	}
+
+	rev, err := s.kv.Create(inst.ID, data)
+	if err != nil {
+		return fmt.Errorf("failed to store saga: %w", err)
+	}
+
+	inst.Version = rev
+	return nil
+}
+
+// Update saves an instance if its KV revision is unchanged since it was read
+func (s *KVStore) Update(ctx context.Context, inst *Instance) error {
+	data, err := json.Marshal(inst)
+	if err != nil {
+		return fmt.Errorf("failed to encode saga: %w", err)
+	}
+
+	rev, err := s.kv.Update(inst.ID, data, inst.Version)
+	if err != nil {
+		var apiErr *nats.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode == nats.JSErrCodeStreamWrongLastSequence {
+			return ErrVersionConflict
+		}
+		return fmt.Errorf("failed to update saga: %w", err)
+	}
+
+	inst.Version = rev
+	return nil
+}
+
+// Get loads an instance by ID
+func (s *KVStore) Get(ctx context.Context, id string) (*Instance, error) {
+	entry, err := s.kv.Get(id)
+	if errors.Is(err, nats.ErrKeyNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read saga: %w", err)
+	}
+	return decodeEntry(entry)
+}
+
+// List returns instances with the given statuses (all when empty), oldest first
+func (s *KVStore) List(ctx context.Context, statuses ...Status) ([]*Instance, error) {
+	keys, err := s.kv.Keys()
+	if errors.Is(err, nats.ErrNoKeysFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sagas: %w", err)
+	}
+
+	wanted := map[Status]bool{}
+	for _, status := range statuses {
+		wanted[status] = true
+	}
+
+	var instances []*Instance
+	for _, key := range keys {
+		inst, err := s.Get(ctx, key)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(wanted) == 0 || wanted[inst.Status] {
+			instances = append(instances, inst)
+		}
+	}
+
+	sort.Slice(instances, func(i, j int) bool { return instances[i].CreatedAt.Before(instances[j].CreatedAt) })
+	return instances, nil
+}
+
+func decodeEntry(entry nats.KeyValueEntry) (*Instance, error) {
+	var inst Instance
+	if err := json.Unmarshal(entry.Value(), &inst); err != nil {
+		return nil, fmt.Errorf("failed to decode saga %s: %w", entry.Key(), err)
+	}
+	inst.Version = entry.Revision()
+	return &inst, nil
+}
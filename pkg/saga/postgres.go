@@ -0,0 +1,175 @@
+package saga
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const sagaTableSQL = `CREATE TABLE IF NOT EXISTS saga_instances (
+	id TEXT PRIMARY KEY,
+	workflow TEXT NOT NULL,
+	status TEXT NOT NULL,
+	current_step INTEGER NOT NULL,
+	pending BOOLEAN NOT NULL DEFAULT false,
+	data JSONB NOT NULL,
+	error TEXT NOT NULL DEFAULT '',
+	version BIGINT NOT NULL,
+	owner TEXT NOT NULL DEFAULT '',
+	lease_expires TIMESTAMPTZ,
+	created_at TIMESTAMPTZ NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL
+)`
+
+// sagaMigrationSQL adds the columns of the in-flight step and the lease to tables created
+// before them
+const sagaMigrationSQL = `ALTER TABLE saga_instances
+	ADD COLUMN IF NOT EXISTS pending BOOLEAN NOT NULL DEFAULT false,
+	ADD COLUMN IF NOT EXISTS owner TEXT NOT NULL DEFAULT '',
+	ADD COLUMN IF NOT EXISTS lease_expires TIMESTAMPTZ`
+
+const sagaColumns = `id, workflow, status, current_step, pending, data, error, version, owner, lease_expires, created_at, updated_at`
+
+// PostgresStore persists saga instances in the saga_instances table
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore creates a Postgres-backed store, creating its table if needed
+func NewPostgresStore(ctx context.Context, db *sql.DB) (*PostgresStore, error) {
+	if _, err := db.ExecContext(ctx, sagaTableSQL); err != nil {
+		return nil, fmt.Errorf("failed to create saga table: %w", err)
+	}
+	if _, err := db.ExecContext(ctx, sagaMigrationSQL); err != nil {
+		return nil, fmt.Errorf("failed to migrate saga table: %w", err)
+	}
+	return &PostgresStore{db: db}, nil
+}
+
+// Create inserts a new instance
+func (s *PostgresStore) Create(ctx context.Context, inst *Instance) error {
+	data, err := json.Marshal(inst.Data)
+	if err != nil {
+		return fmt.Errorf("failed to encode saga data: %w", err)
+	}
+
+	inst.Version = 1
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO saga_instances (`+sagaColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		inst.ID, inst.Workflow, string(inst.Status), inst.CurrentStep, inst.Pending, data, inst.Error,
+		inst.Version, inst.Owner, nullTime(inst.LeaseExpires), inst.CreatedAt, inst.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert saga: %w", err)
+	}
+	return nil
+}
+
+// Update saves an instance if nobody else updated it since it was read
+func (s *PostgresStore) Update(ctx context.Context, inst *Instance) error {
+	data, err := json.Marshal(inst.Data)
+	if err != nil {
+		return fmt.Errorf("failed to encode saga data: %w", err)
+	}
+
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE saga_instances
+		SET status = $1, current_step = $2, pending = $3, data = $4, error = $5, version = version + 1,
+			owner = $6, lease_expires = $7, updated_at = $8
+		WHERE id = $9 AND version = $10`,
+		string(inst.Status), inst.CurrentStep, inst.Pending, data, inst.Error,
+		inst.Owner, nullTime(inst.LeaseExpires), inst.UpdatedAt, inst.ID, inst.Version,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update saga: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update saga: %w", err)
+	}
+	if n == 0 {
+		return ErrVersionConflict
+	}
+
+	inst.Version++
+	return nil
+}
+
+// Get loads an instance by ID
+func (s *PostgresStore) Get(ctx context.Context, id string) (*Instance, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+sagaColumns+` FROM saga_instances WHERE id = $1`, id)
+
+	inst, err := scanInstance(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return inst, err
+}
+
+// List returns instances with the given statuses (all when empty), oldest first
+func (s *PostgresStore) List(ctx context.Context, statuses ...Status) ([]*Instance, error) {
+	query := `SELECT ` + sagaColumns + ` FROM saga_instances`
+	var args []interface{}
+	if len(statuses) > 0 {
+		placeholders := make([]string, len(statuses))
+		for i, status := range statuses {
+			placeholders[i] = fmt.Sprintf("$%d", i+1)
+			args = append(args, string(status))
+		}
+		query += " WHERE status IN (" + strings.Join(placeholders, ", ") + ")"
+	}
+	query += " ORDER BY created_at"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sagas: %w", err)
+	}
+	defer rows.Close()
+
+	var instances []*Instance
+	for rows.Next() {
+		inst, err := scanInstance(rows)
+		if err != nil {
+			return nil, err
+		}
+		instances = append(instances, inst)
+	}
+	return instances, rows.Err()
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanInstance(row scanner) (*Instance, error) {
+	var inst Instance
+	var status string
+	var data []byte
+	var leaseExpires sql.NullTime
+	err := row.Scan(&inst.ID, &inst.Workflow, &status, &inst.CurrentStep, &inst.Pending, &data, &inst.Error,
+		&inst.Version, &inst.Owner, &leaseExpires, &inst.CreatedAt, &inst.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to read saga: %w", err)
+	}
+
+	inst.Status = Status(status)
+	inst.LeaseExpires = leaseExpires.Time
+	if err := json.Unmarshal(data, &inst.Data); err != nil {
+		return nil, fmt.Errorf("failed to decode saga data: %w", err)
+	}
+	return &inst, nil
+}
+
+// nullTime stores the zero time as NULL
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
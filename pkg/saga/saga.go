@@ -0,0 +1,505 @@
+package saga
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Status is the lifecycle state of a saga instance
+type Status string
+
+const (
+	StatusRunning      Status = "running"      // Executing steps forward
+	StatusCompensating Status = "compensating" // Undoing completed steps after a failure
+	StatusCompleted    Status = "completed"    // All steps succeeded
+	StatusCompensated  Status = "compensated"  // All completed steps were undone
+	StatusStuck        Status = "stuck"        // A compensation failed; needs an operator
+)
+
+// Terminal reports whether the saga will make no further progress on its own
+func (s Status) Terminal() bool {
+	return s == StatusCompleted || s == StatusCompensated || s == StatusStuck
+}
+
+const defaultStepTimeout = 30 * time.Second
+
+// leaseMargin extends the lease of a saga beyond the time its next step can take, covering
+// the saves around the step and clock skew between replicas
+const leaseMargin = 30 * time.Second
+
+var (
+	// ErrNotFound is returned when a saga instance does not exist
+	ErrNotFound = errors.New("saga not found")
+	// ErrVersionConflict is returned when another process updated the saga first
+	ErrVersionConflict = errors.New("saga was updated concurrently")
+	// ErrFinished is returned when an operator action targets a completed or compensated saga
+	ErrFinished = errors.New("saga already finished")
+	// ErrLeased is returned when an operator action targets a saga another orchestrator drives
+	ErrLeased = errors.New("saga is driven by another orchestrator")
+)
+
+// Data carries workflow input and step outputs between steps.
+// Steps may add entries, e.g. IDs that a compensation needs.
+type Data map[string]string
+
+// Step is one action of a workflow together with the compensation that undoes it.
+// Both must be idempotent: after a crash the step in flight is executed again. When a saga
+// is aborted while a step is in flight, that step is compensated too, so a compensation
+// must also tolerate an action that never ran.
+type Step struct {
+	Name       string
+	Action     func(ctx context.Context, data Data) error
+	Compensate func(ctx context.Context, data Data) error // Optional
+	Timeout    time.Duration                              // Per attempt (default: definition's StepTimeout)
+	Retries    int                                        // Extra attempts before the step counts as failed
+	RetryDelay time.Duration
+}
+
+// Definition describes a workflow
+type Definition struct {
+	Name        string
+	Steps       []Step
+	StepTimeout time.Duration // Default per-step timeout (default: 30s)
+}
+
+// Instance is the persisted state of one saga execution
+type Instance struct {
+	ID          string
+	Workflow    string
+	Status      Status
+	CurrentStep int  // Number of completed steps that have not been compensated
+	Pending     bool // The action of step CurrentStep is in flight and may have taken effect
+	Data        Data
+	Error       string
+	Version     uint64 // Optimistic concurrency token maintained by the Store
+	// Owner is the orchestrator driving the saga until LeaseExpires. Other replicas leave
+	// it alone until then; every save renews the lease for the next step.
+	Owner        string
+	LeaseExpires time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// Store persists saga instances
+type Store interface {
+	Create(ctx context.Context, inst *Instance) error
+	// Update saves inst if its Version matches the stored one and advances Version,
+	// returning ErrVersionConflict otherwise
+	Update(ctx context.Context, inst *Instance) error
+	Get(ctx context.Context, id string) (*Instance, error)
+	List(ctx context.Context, statuses ...Status) ([]*Instance, error)
+}
+
+// Orchestrator executes registered workflows and drives their instances to completion
+type Orchestrator struct {
+	store Store
+	id    string // Owner of the sagas this process drives
+
+	mu          sync.Mutex
+	definitions map[string]Definition
+	active      map[string]bool
+	wg          sync.WaitGroup
+}
+
+// NewOrchestrator creates an orchestrator persisting to the given store
+func NewOrchestrator(store Store) *Orchestrator {
+	id, err := newID()
+	if err != nil {
+		id = fmt.Sprintf("orchestrator-%d", time.Now().UnixNano())
+	}
+	return &Orchestrator{
+		store:       store,
+		id:          id,
+		definitions: map[string]Definition{},
+		active:      map[string]bool{},
+	}
+}
+
+// Register adds a workflow definition
+func (o *Orchestrator) Register(def Definition) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.definitions[def.Name] = def
+}
+
+func (o *Orchestrator) definition(name string) (Definition, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	def, ok := o.definitions[name]
+	if !ok {
+		return Definition{}, fmt.Errorf("workflow %s is not registered", name)
+	}
+	return def, nil
+}
+
+// Start persists a new saga instance and executes it in the background
+func (o *Orchestrator) Start(ctx context.Context, workflow string, data Data) (string, error) {
+	if _, err := o.definition(workflow); err != nil {
+		return "", err
+	}
+
+	id, err := newID()
+	if err != nil {
+		return "", err
+	}
+
+	if data == nil {
+		data = Data{}
+	}
+
+	now := time.Now()
+	inst := &Instance{
+		ID:        id,
+		Workflow:  workflow,
+		Status:    StatusRunning,
+		Data:      data,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := o.store.Create(ctx, inst); err != nil {
+		return "", fmt.Errorf("failed to persist saga: %w", err)
+	}
+
+	o.launch(inst)
+	return id, nil
+}
+
+// Resume continues the running or compensating sagas that no orchestrator holds a lease
+// on, e.g. after a crash. Each saga is claimed with a versioned update first, so when
+// several replicas resume at once, only one drives it. Call it on startup after
+// registering workflows, and periodically to take over the sagas of replicas that died.
+func (o *Orchestrator) Resume(ctx context.Context) error {
+	instances, err := o.store.List(ctx, StatusRunning, StatusCompensating)
+	if err != nil {
+		return fmt.Errorf("failed to list sagas to resume: %w", err)
+	}
+
+	for _, inst := range instances {
+		if o.isActive(inst.ID) || o.leasedElsewhere(inst) {
+			continue
+		}
+		if err := o.save(ctx, inst); err != nil {
+			if !errors.Is(err, ErrVersionConflict) {
+				return err
+			}
+			continue // Claimed by another replica
+		}
+		log.Printf("Resuming saga %s (%s) at step %d", inst.ID, inst.Workflow, inst.CurrentStep)
+		o.launch(inst)
+	}
+
+	return nil
+}
+
+// Retry resumes a saga in its current direction. A stuck saga retries its compensation.
+// Sagas another orchestrator holds a live lease on are already being driven and fail
+// with ErrLeased.
+func (o *Orchestrator) Retry(ctx context.Context, id string) (*Instance, error) {
+	inst, err := o.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	switch inst.Status {
+	case StatusCompleted, StatusCompensated:
+		return nil, fmt.Errorf("%w: saga %s is %s", ErrFinished, id, inst.Status)
+	case StatusStuck:
+		inst.Status = StatusCompensating
+	}
+	if o.leasedElsewhere(inst) {
+		return nil, fmt.Errorf("%w: saga %s is leased by %s until %s", ErrLeased, id, inst.Owner, inst.LeaseExpires.Format(time.RFC3339))
+	}
+
+	inst.Error = ""
+	if err := o.save(ctx, inst); err != nil {
+		return nil, err
+	}
+
+	o.launch(inst)
+	return inst, nil
+}
+
+// Compensate aborts a saga that has not finished and undoes its completed steps. When
+// another orchestrator holds a live lease on the saga, the request is only recorded: the
+// owner's next save conflicts and it continues with the compensation.
+func (o *Orchestrator) Compensate(ctx context.Context, id string) (*Instance, error) {
+	inst, err := o.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if inst.Status == StatusCompleted || inst.Status == StatusCompensated {
+		return nil, fmt.Errorf("%w: saga %s is %s", ErrFinished, id, inst.Status)
+	}
+
+	inst.Status = StatusCompensating
+	inst.Error = "compensation requested by operator"
+	if o.leasedElsewhere(inst) {
+		inst.UpdatedAt = time.Now()
+		if err := o.store.Update(ctx, inst); err != nil {
+			return nil, fmt.Errorf("failed to persist saga %s: %w", inst.ID, err)
+		}
+		return inst, nil
+	}
+	if err := o.save(ctx, inst); err != nil {
+		return nil, err
+	}
+
+	o.launch(inst)
+	return inst, nil
+}
+
+// Get returns a saga instance
+func (o *Orchestrator) Get(ctx context.Context, id string) (*Instance, error) {
+	return o.store.Get(ctx, id)
+}
+
+// List returns saga instances with the given statuses (all when empty)
+func (o *Orchestrator) List(ctx context.Context, statuses ...Status) ([]*Instance, error) {
+	return o.store.List(ctx, statuses...)
+}
+
+// StepName returns the name of the step an instance is positioned at
+func (o *Orchestrator) StepName(inst *Instance) string {
+	def, err := o.definition(inst.Workflow)
+	if err != nil {
+		return ""
+	}
+
+	if idx := stepIndex(inst); idx >= 0 && idx < len(def.Steps) {
+		return def.Steps[idx].Name
+	}
+	return ""
+}
+
+// stepIndex returns the step an instance executes next: the action of step CurrentStep
+// when running, the compensation of the last completed step (or of the step in flight)
+// when compensating
+func stepIndex(inst *Instance) int {
+	if (inst.Status == StatusCompensating || inst.Status == StatusStuck) && !inst.Pending {
+		return inst.CurrentStep - 1
+	}
+	return inst.CurrentStep
+}
+
+// Wait blocks until all sagas executing in this process have stopped
+func (o *Orchestrator) Wait() {
+	o.wg.Wait()
+}
+
+func (o *Orchestrator) isActive(id string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.active[id]
+}
+
+// leasedElsewhere reports whether another orchestrator holds a live lease on an instance
+func (o *Orchestrator) leasedElsewhere(inst *Instance) bool {
+	return inst.Owner != "" && inst.Owner != o.id && time.Now().Before(inst.LeaseExpires)
+}
+
+// launch executes an instance in the background unless this process is already driving it
+func (o *Orchestrator) launch(inst *Instance) {
+	o.mu.Lock()
+	if o.active[inst.ID] {
+		o.mu.Unlock()
+		return
+	}
+	o.active[inst.ID] = true
+	o.mu.Unlock()
+
+	// Work on a private copy so callers can keep reading the instance they passed in
+	inst = inst.clone()
+
+	o.wg.Add(1)
+	go func() {
+		defer o.wg.Done()
+		defer func() {
+			o.mu.Lock()
+			delete(o.active, inst.ID)
+			o.mu.Unlock()
+		}()
+
+		ctx := context.Background()
+		for {
+			err := o.run(ctx, inst)
+			if err == nil {
+				return
+			}
+			if !errors.Is(err, ErrVersionConflict) {
+				log.Printf("Saga %s (%s) stopped: %v", inst.ID, inst.Workflow, err)
+				return
+			}
+
+			// Someone else (usually an operator) changed the saga; continue from its latest state
+			latest, err := o.store.Get(ctx, inst.ID)
+			if err != nil {
+				log.Printf("Saga %s (%s) stopped: %v", inst.ID, inst.Workflow, err)
+				return
+			}
+			if latest.Status.Terminal() {
+				return
+			}
+			if o.leasedElsewhere(latest) {
+				log.Printf("Saga %s (%s) was taken over by %s", inst.ID, inst.Workflow, latest.Owner)
+				return
+			}
+			inst = latest
+		}
+	}()
+}
+
+// run drives an instance until it reaches a terminal status
+func (o *Orchestrator) run(ctx context.Context, inst *Instance) error {
+	def, err := o.definition(inst.Workflow)
+	if err != nil {
+		return err
+	}
+
+	for inst.Status == StatusRunning && inst.CurrentStep < len(def.Steps) {
+		step := def.Steps[inst.CurrentStep]
+		// Record the step as in flight first: if the saga is aborted meanwhile, or the
+		// save after the action conflicts, the compensation still covers it
+		if !inst.Pending {
+			inst.Pending = true
+			if err := o.save(ctx, inst); err != nil {
+				return err
+			}
+		}
+		if err := o.attempt(ctx, def, step, step.Action, inst.Data); err != nil {
+			log.Printf("Saga %s step %s failed, compensating: %v", inst.ID, step.Name, err)
+			inst.Status = StatusCompensating
+			inst.Error = fmt.Sprintf("step %s: %v", step.Name, err)
+		} else {
+			inst.CurrentStep++
+		}
+		inst.Pending = false
+		if err := o.save(ctx, inst); err != nil {
+			return err
+		}
+	}
+
+	if inst.Status == StatusRunning {
+		inst.Status = StatusCompleted
+		return o.save(ctx, inst)
+	}
+
+	for inst.Status == StatusCompensating && (inst.CurrentStep > 0 || inst.Pending) {
+		step := def.Steps[stepIndex(inst)]
+		if step.Compensate != nil {
+			if err := o.attempt(ctx, def, step, step.Compensate, inst.Data); err != nil {
+				log.Printf("Saga %s compensation of %s failed: %v", inst.ID, step.Name, err)
+				inst.Status = StatusStuck
+				inst.Error = fmt.Sprintf("compensate %s: %v", step.Name, err)
+				return o.save(ctx, inst)
+			}
+		}
+		if inst.Pending {
+			inst.Pending = false
+		} else {
+			inst.CurrentStep--
+		}
+		if err := o.save(ctx, inst); err != nil {
+			return err
+		}
+	}
+
+	if inst.Status == StatusCompensating {
+		inst.Status = StatusCompensated
+		return o.save(ctx, inst)
+	}
+
+	return nil
+}
+
+// attempt runs fn with the step's timeout and retry policy
+func (o *Orchestrator) attempt(ctx context.Context, def Definition, step Step, fn func(context.Context, Data) error, data Data) error {
+	timeout := stepTimeout(def, step)
+
+	var err error
+	for i := 0; i <= step.Retries; i++ {
+		if i > 0 && step.RetryDelay > 0 {
+			timer := time.NewTimer(step.RetryDelay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+
+		stepCtx, cancel := context.WithTimeout(ctx, timeout)
+		err = fn(stepCtx, data)
+		if err == nil && stepCtx.Err() != nil {
+			err = stepCtx.Err()
+		}
+		cancel()
+
+		if err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func stepTimeout(def Definition, step Step) time.Duration {
+	timeout := step.Timeout
+	if timeout == 0 {
+		timeout = def.StepTimeout
+	}
+	if timeout == 0 {
+		timeout = defaultStepTimeout
+	}
+	return timeout
+}
+
+// lease returns how long the next step of an instance can take, with all its attempts
+func (o *Orchestrator) lease(inst *Instance) time.Duration {
+	def, err := o.definition(inst.Workflow)
+	idx := stepIndex(inst)
+	if err != nil || idx < 0 || idx >= len(def.Steps) {
+		return leaseMargin
+	}
+	step := def.Steps[idx]
+	attempts := time.Duration(step.Retries + 1)
+	return attempts*stepTimeout(def, step) + (attempts-1)*step.RetryDelay + leaseMargin
+}
+
+// save persists an instance, claiming it for this orchestrator until its next step is
+// done. Finished sagas are released.
+func (o *Orchestrator) save(ctx context.Context, inst *Instance) error {
+	inst.UpdatedAt = time.Now()
+	inst.Owner = o.id
+	inst.LeaseExpires = inst.UpdatedAt.Add(o.lease(inst))
+	if inst.Status.Terminal() {
+		inst.Owner = ""
+		inst.LeaseExpires = time.Time{}
+	}
+	if err := o.store.Update(ctx, inst); err != nil {
+		return fmt.Errorf("failed to persist saga %s: %w", inst.ID, err)
+	}
+	return nil
+}
+
+func (inst *Instance) clone() *Instance {
+	c := *inst
+	c.Data = make(Data, len(inst.Data))
+	for k, v := range inst.Data {
+		c.Data[k] = v
+	}
+	return &c
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate saga ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
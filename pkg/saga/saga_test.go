@@ -0,0 +1,213 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/nats/natstest"
+)
+
+func newKVStore(t *testing.T) *KVStore {
+	t.Helper()
+	_, js := natstest.NewJetStream(t)
+	store, err := NewKVStore(js, "")
+	if err != nil {
+		t.Fatalf("NewKVStore: %v", err)
+	}
+	return store
+}
+
+func waitStatus(t *testing.T, o *Orchestrator, id string, want Status) *Instance {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		inst, err := o.Get(context.Background(), id)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if inst.Status == want {
+			return inst
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("saga status = %s, want %s", inst.Status, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCompensateDuringStepUndoesStepInFlight(t *testing.T) {
+	ctx := context.Background()
+	o := NewOrchestrator(newKVStore(t))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var compensated []string
+	compensate := func(name string) func(context.Context, Data) error {
+		return func(context.Context, Data) error {
+			mu.Lock()
+			defer mu.Unlock()
+			compensated = append(compensated, name)
+			return nil
+		}
+	}
+	o.Register(Definition{Name: "order", Steps: []Step{
+		{Name: "reserve", Action: func(context.Context, Data) error { return nil }, Compensate: compensate("reserve")},
+		{Name: "charge", Action: func(context.Context, Data) error {
+			close(started)
+			<-release
+			return nil
+		}, Compensate: compensate("charge")},
+		{Name: "ship", Action: func(context.Context, Data) error { return nil }, Compensate: compensate("ship")},
+	}})
+
+	id, err := o.Start(ctx, "order", nil)
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	<-started
+	// The operator aborts while "charge" runs; its save after the action then conflicts
+	if _, err := o.Compensate(ctx, id); err != nil {
+		t.Fatalf("Compensate: %v", err)
+	}
+	close(release)
+
+	inst := waitStatus(t, o, id, StatusCompensated)
+	o.Wait()
+	if inst.CurrentStep != 0 || inst.Pending {
+		t.Errorf("CurrentStep = %d, Pending = %v; want 0, false", inst.CurrentStep, inst.Pending)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(compensated) != 2 || compensated[0] != "charge" || compensated[1] != "reserve" {
+		t.Errorf("compensated %v, want [charge reserve]", compensated)
+	}
+}
+
+func TestResumeClaimsSagaOnce(t *testing.T) {
+	ctx := context.Background()
+	store := newKVStore(t)
+
+	var runs atomic.Int32
+	def := Definition{Name: "once", Steps: []Step{{
+		Name: "work",
+		Action: func(context.Context, Data) error {
+			runs.Add(1)
+			time.Sleep(50 * time.Millisecond)
+			return nil
+		},
+	}}}
+
+	// A saga left running by a replica that died without a lease
+	now := time.Now()
+	inst := &Instance{ID: "saga-1", Workflow: "once", Status: StatusRunning, Data: Data{}, CreatedAt: now, UpdatedAt: now}
+	if err := store.Create(ctx, inst); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	replicas := []*Orchestrator{NewOrchestrator(store), NewOrchestrator(store), NewOrchestrator(store)}
+	var wg sync.WaitGroup
+	for _, o := range replicas {
+		o.Register(def)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := o.Resume(ctx); err != nil {
+				t.Errorf("Resume: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	waitStatus(t, replicas[0], "saga-1", StatusCompleted)
+	for _, o := range replicas {
+		o.Wait()
+	}
+	if n := runs.Load(); n != 1 {
+		t.Errorf("step ran %d times, want 1", n)
+	}
+
+	// A live lease keeps other replicas away
+	held := &Instance{ID: "saga-2", Workflow: "once", Status: StatusRunning, Data: Data{},
+		Owner: "elsewhere", LeaseExpires: time.Now().Add(time.Minute), CreatedAt: now, UpdatedAt: now}
+	if err := store.Create(ctx, held); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := replicas[0].Resume(ctx); err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	replicas[0].Wait()
+	if got, _ := store.Get(ctx, "saga-2"); got.Status != StatusRunning || got.Owner != "elsewhere" {
+		t.Errorf("leased saga = %s owned by %q, want running owned by elsewhere", got.Status, got.Owner)
+	}
+}
+
+func TestRetryDelayHonorsContext(t *testing.T) {
+	o := NewOrchestrator(nil)
+	step := Step{
+		Name:       "flaky",
+		Action:     func(context.Context, Data) error { return context.DeadlineExceeded },
+		Retries:    1,
+		RetryDelay: time.Hour,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := o.attempt(ctx, Definition{}, step, step.Action, Data{}); err == nil {
+		t.Fatal("attempt succeeded, want an error")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("attempt took %v, want it to stop with the context", elapsed)
+	}
+}
+
+func TestOperatorActionsRespectLease(t *testing.T) {
+	ctx := context.Background()
+	store := newKVStore(t)
+	o := NewOrchestrator(store)
+	o.Register(Definition{Name: "leased", Steps: []Step{{
+		Name:   "work",
+		Action: func(context.Context, Data) error { return nil },
+	}}})
+
+	now := time.Now()
+	held := &Instance{ID: "saga-1", Workflow: "leased", Status: StatusRunning, Data: Data{}, Pending: true,
+		Owner: "elsewhere", LeaseExpires: now.Add(time.Minute), CreatedAt: now, UpdatedAt: now}
+	if err := store.Create(ctx, held); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	if _, err := o.Retry(ctx, "saga-1"); !errors.Is(err, ErrLeased) {
+		t.Errorf("Retry = %v, want ErrLeased", err)
+	}
+
+	// Compensate only records the request for the owner
+	if _, err := o.Compensate(ctx, "saga-1"); err != nil {
+		t.Fatalf("Compensate: %v", err)
+	}
+	o.Wait()
+	got, err := store.Get(ctx, "saga-1")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Status != StatusCompensating || got.Owner != "elsewhere" || !got.Pending {
+		t.Errorf("saga = %s owned by %q (pending %v), want compensating owned by elsewhere",
+			got.Status, got.Owner, got.Pending)
+	}
+
+	// Once the lease has expired, the operator may take the saga over
+	got.LeaseExpires = time.Now().Add(-time.Second)
+	if err := store.Update(ctx, got); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if _, err := o.Retry(ctx, "saga-1"); err != nil {
+		t.Fatalf("Retry: %v", err)
+	}
+	waitStatus(t, o, "saga-1", StatusCompensated)
+	o.Wait()
+}
@@ -0,0 +1,457 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        v6.31.1
+// source: pkg/saga/sagapb/saga.proto
+
+package sagapb
+
+import (
+	_ "github.com/LucasPluta/GoMicroserviceFramework/pkg/authz/authzpb"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Saga struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Workflow      string                 `protobuf:"bytes,2,opt,name=workflow,proto3" json:"workflow,omitempty"`
+	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	CurrentStep   int32                  `protobuf:"varint,4,opt,name=current_step,json=currentStep,proto3" json:"current_step,omitempty"`
+	StepName      string                 `protobuf:"bytes,5,opt,name=step_name,json=stepName,proto3" json:"step_name,omitempty"`
+	Data          map[string]string      `protobuf:"bytes,6,rep,name=data,proto3" json:"data,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Error         string                 `protobuf:"bytes,7,opt,name=error,proto3" json:"error,omitempty"`
+	CreatedAt     int64                  `protobuf:"varint,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     int64                  `protobuf:"varint,9,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Saga) Reset() {
+	*x = Saga{}
+	mi := &file_pkg_saga_sagapb_saga_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Saga) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Saga) ProtoMessage() {}
+
+func (x *Saga) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_saga_sagapb_saga_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Saga.ProtoReflect.Descriptor instead.
+func (*Saga) Descriptor() ([]byte, []int) {
+	return file_pkg_saga_sagapb_saga_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Saga) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Saga) GetWorkflow() string {
+	if x != nil {
+		return x.Workflow
+	}
+	return ""
+}
+
+func (x *Saga) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Saga) GetCurrentStep() int32 {
+	if x != nil {
+		return x.CurrentStep
+	}
+	return 0
+}
+
+func (x *Saga) GetStepName() string {
+	if x != nil {
+		return x.StepName
+	}
+	return ""
+}
+
+func (x *Saga) GetData() map[string]string {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *Saga) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Saga) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *Saga) GetUpdatedAt() int64 {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return 0
+}
+
+type GetSagaRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSagaRequest) Reset() {
+	*x = GetSagaRequest{}
+	mi := &file_pkg_saga_sagapb_saga_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSagaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSagaRequest) ProtoMessage() {}
+
+func (x *GetSagaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_saga_sagapb_saga_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSagaRequest.ProtoReflect.Descriptor instead.
+func (*GetSagaRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_saga_sagapb_saga_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetSagaRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ListSagasRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Statuses      []string               `protobuf:"bytes,1,rep,name=statuses,proto3" json:"statuses,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSagasRequest) Reset() {
+	*x = ListSagasRequest{}
+	mi := &file_pkg_saga_sagapb_saga_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSagasRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSagasRequest) ProtoMessage() {}
+
+func (x *ListSagasRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_saga_sagapb_saga_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSagasRequest.ProtoReflect.Descriptor instead.
+func (*ListSagasRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_saga_sagapb_saga_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListSagasRequest) GetStatuses() []string {
+	if x != nil {
+		return x.Statuses
+	}
+	return nil
+}
+
+type ListSagasResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sagas         []*Saga                `protobuf:"bytes,1,rep,name=sagas,proto3" json:"sagas,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSagasResponse) Reset() {
+	*x = ListSagasResponse{}
+	mi := &file_pkg_saga_sagapb_saga_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSagasResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSagasResponse) ProtoMessage() {}
+
+func (x *ListSagasResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_saga_sagapb_saga_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSagasResponse.ProtoReflect.Descriptor instead.
+func (*ListSagasResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_saga_sagapb_saga_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListSagasResponse) GetSagas() []*Saga {
+	if x != nil {
+		return x.Sagas
+	}
+	return nil
+}
+
+type RetrySagaRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RetrySagaRequest) Reset() {
+	*x = RetrySagaRequest{}
+	mi := &file_pkg_saga_sagapb_saga_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RetrySagaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetrySagaRequest) ProtoMessage() {}
+
+func (x *RetrySagaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_saga_sagapb_saga_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetrySagaRequest.ProtoReflect.Descriptor instead.
+func (*RetrySagaRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_saga_sagapb_saga_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *RetrySagaRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type CompensateSagaRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CompensateSagaRequest) Reset() {
+	*x = CompensateSagaRequest{}
+	mi := &file_pkg_saga_sagapb_saga_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompensateSagaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompensateSagaRequest) ProtoMessage() {}
+
+func (x *CompensateSagaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_saga_sagapb_saga_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompensateSagaRequest.ProtoReflect.Descriptor instead.
+func (*CompensateSagaRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_saga_sagapb_saga_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CompensateSagaRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+var File_pkg_saga_sagapb_saga_proto protoreflect.FileDescriptor
+
+const file_pkg_saga_sagapb_saga_proto_rawDesc = "" +
+	"\n" +
+	"\x1apkg/saga/sagapb/saga.proto\x12\x04saga\x1a\x1dpkg/authz/authzpb/authz.proto\"\xc1\x02\n" +
+	"\x04Saga\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
+	"\bworkflow\x18\x02 \x01(\tR\bworkflow\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12!\n" +
+	"\fcurrent_step\x18\x04 \x01(\x05R\vcurrentStep\x12\x1b\n" +
+	"\tstep_name\x18\x05 \x01(\tR\bstepName\x12(\n" +
+	"\x04data\x18\x06 \x03(\v2\x14.saga.Saga.DataEntryR\x04data\x12\x14\n" +
+	"\x05error\x18\a \x01(\tR\x05error\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\b \x01(\x03R\tcreatedAt\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\t \x01(\x03R\tupdatedAt\x1a7\n" +
+	"\tDataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\" \n" +
+	"\x0eGetSagaRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\".\n" +
+	"\x10ListSagasRequest\x12\x1a\n" +
+	"\bstatuses\x18\x01 \x03(\tR\bstatuses\"5\n" +
+	"\x11ListSagasResponse\x12 \n" +
+	"\x05sagas\x18\x01 \x03(\v2\n" +
+	".saga.SagaR\x05sagas\"\"\n" +
+	"\x10RetrySagaRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"'\n" +
+	"\x15CompensateSagaRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id2\xb3\x02\n" +
+	"\x10SagaAdminService\x12=\n" +
+	"\aGetSaga\x12\x14.saga.GetSagaRequest\x1a\n" +
+	".saga.Saga\"\x10\xa2\xbb\x18\f\x12\n" +
+	"sagas.read\x12N\n" +
+	"\tListSagas\x12\x16.saga.ListSagasRequest\x1a\x17.saga.ListSagasResponse\"\x10\xa2\xbb\x18\f\x12\n" +
+	"sagas.read\x12B\n" +
+	"\tRetrySaga\x12\x16.saga.RetrySagaRequest\x1a\n" +
+	".saga.Saga\"\x11\xa2\xbb\x18\r\x12\vsagas.write\x12L\n" +
+	"\x0eCompensateSaga\x12\x1b.saga.CompensateSagaRequest\x1a\n" +
+	".saga.Saga\"\x11\xa2\xbb\x18\r\x12\vsagas.writeB?Z=github.com/LucasPluta/GoMicroserviceFramework/pkg/saga/sagapbb\x06proto3"
+
+var (
+	file_pkg_saga_sagapb_saga_proto_rawDescOnce sync.Once
+	file_pkg_saga_sagapb_saga_proto_rawDescData []byte
+)
+
+func file_pkg_saga_sagapb_saga_proto_rawDescGZIP() []byte {
+	file_pkg_saga_sagapb_saga_proto_rawDescOnce.Do(func() {
+		file_pkg_saga_sagapb_saga_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pkg_saga_sagapb_saga_proto_rawDesc), len(file_pkg_saga_sagapb_saga_proto_rawDesc)))
+	})
+	return file_pkg_saga_sagapb_saga_proto_rawDescData
+}
+
+var file_pkg_saga_sagapb_saga_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_pkg_saga_sagapb_saga_proto_goTypes = []any{
+	(*Saga)(nil),                  // 0: saga.Saga
+	(*GetSagaRequest)(nil),        // 1: saga.GetSagaRequest
+	(*ListSagasRequest)(nil),      // 2: saga.ListSagasRequest
+	(*ListSagasResponse)(nil),     // 3: saga.ListSagasResponse
+	(*RetrySagaRequest)(nil),      // 4: saga.RetrySagaRequest
+	(*CompensateSagaRequest)(nil), // 5: saga.CompensateSagaRequest
+	nil,                           // 6: saga.Saga.DataEntry
+}
+var file_pkg_saga_sagapb_saga_proto_depIdxs = []int32{
+	6, // 0: saga.Saga.data:type_name -> saga.Saga.DataEntry
+	0, // 1: saga.ListSagasResponse.sagas:type_name -> saga.Saga
+	1, // 2: saga.SagaAdminService.GetSaga:input_type -> saga.GetSagaRequest
+	2, // 3: saga.SagaAdminService.ListSagas:input_type -> saga.ListSagasRequest
+	4, // 4: saga.SagaAdminService.RetrySaga:input_type -> saga.RetrySagaRequest
+	5, // 5: saga.SagaAdminService.CompensateSaga:input_type -> saga.CompensateSagaRequest
+	0, // 6: saga.SagaAdminService.GetSaga:output_type -> saga.Saga
+	3, // 7: saga.SagaAdminService.ListSagas:output_type -> saga.ListSagasResponse
+	0, // 8: saga.SagaAdminService.RetrySaga:output_type -> saga.Saga
+	0, // 9: saga.SagaAdminService.CompensateSaga:output_type -> saga.Saga
+	6, // [6:10] is the sub-list for method output_type
+	2, // [2:6] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_pkg_saga_sagapb_saga_proto_init() }
+func file_pkg_saga_sagapb_saga_proto_init() {
+	if File_pkg_saga_sagapb_saga_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pkg_saga_sagapb_saga_proto_rawDesc), len(file_pkg_saga_sagapb_saga_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pkg_saga_sagapb_saga_proto_goTypes,
+		DependencyIndexes: file_pkg_saga_sagapb_saga_proto_depIdxs,
+		MessageInfos:      file_pkg_saga_sagapb_saga_proto_msgTypes,
+	}.Build()
+	File_pkg_saga_sagapb_saga_proto = out.File
+	file_pkg_saga_sagapb_saga_proto_goTypes = nil
+	file_pkg_saga_sagapb_saga_proto_depIdxs = nil
+}
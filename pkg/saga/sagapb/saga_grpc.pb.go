@@ -0,0 +1,247 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v6.31.1
+// source: pkg/saga/sagapb/saga.proto
+
+package sagapb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	SagaAdminService_GetSaga_FullMethodName        = "/saga.SagaAdminService/GetSaga"
+	SagaAdminService_ListSagas_FullMethodName      = "/saga.SagaAdminService/ListSagas"
+	SagaAdminService_RetrySaga_FullMethodName      = "/saga.SagaAdminService/RetrySaga"
+	SagaAdminService_CompensateSaga_FullMethodName = "/saga.SagaAdminService/CompensateSaga"
+)
+
+// SagaAdminServiceClient is the client API for SagaAdminService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// SagaAdminService lets operators inspect and unstick saga instances
+type SagaAdminServiceClient interface {
+	// Get a single saga instance
+	GetSaga(ctx context.Context, in *GetSagaRequest, opts ...grpc.CallOption) (*Saga, error)
+	// List saga instances, optionally filtered by status
+	ListSagas(ctx context.Context, in *ListSagasRequest, opts ...grpc.CallOption) (*ListSagasResponse, error)
+	// Resume a saga in its current direction, retrying the step it stopped at
+	RetrySaga(ctx context.Context, in *RetrySagaRequest, opts ...grpc.CallOption) (*Saga, error)
+	// Abort a saga and run the compensations of its completed steps
+	CompensateSaga(ctx context.Context, in *CompensateSagaRequest, opts ...grpc.CallOption) (*Saga, error)
+}
+
+type sagaAdminServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSagaAdminServiceClient(cc grpc.ClientConnInterface) SagaAdminServiceClient {
+	return &sagaAdminServiceClient{cc}
+}
+
+func (c *sagaAdminServiceClient) GetSaga(ctx context.Context, in *GetSagaRequest, opts ...grpc.CallOption) (*Saga, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Saga)
+	err := c.cc.Invoke(ctx, SagaAdminService_GetSaga_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sagaAdminServiceClient) ListSagas(ctx context.Context, in *ListSagasRequest, opts ...grpc.CallOption) (*ListSagasResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListSagasResponse)
+	err := c.cc.Invoke(ctx, SagaAdminService_ListSagas_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sagaAdminServiceClient) RetrySaga(ctx context.Context, in *RetrySagaRequest, opts ...grpc.CallOption) (*Saga, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Saga)
+	err := c.cc.Invoke(ctx, SagaAdminService_RetrySaga_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sagaAdminServiceClient) CompensateSaga(ctx context.Context, in *CompensateSagaRequest, opts ...grpc.CallOption) (*Saga, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Saga)
+	err := c.cc.Invoke(ctx, SagaAdminService_CompensateSaga_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SagaAdminServiceServer is the server API for SagaAdminService service.
+// All implementations must embed UnimplementedSagaAdminServiceServer
+// for forward compatibility.
+//
+// SagaAdminService lets operators inspect and unstick saga instances
+type SagaAdminServiceServer interface {
+	// Get a single saga instance
+	GetSaga(context.Context, *GetSagaRequest) (*Saga, error)
+	// List saga instances, optionally filtered by status
+	ListSagas(context.Context, *ListSagasRequest) (*ListSagasResponse, error)
+	// Resume a saga in its current direction, retrying the step it stopped at
+	RetrySaga(context.Context, *RetrySagaRequest) (*Saga, error)
+	// Abort a saga and run the compensations of its completed steps
+	CompensateSaga(context.Context, *CompensateSagaRequest) (*Saga, error)
+	mustEmbedUnimplementedSagaAdminServiceServer()
+}
+
+// UnimplementedSagaAdminServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedSagaAdminServiceServer struct{}
+
+func (UnimplementedSagaAdminServiceServer) GetSaga(context.Context, *GetSagaRequest) (*Saga, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSaga not implemented")
+}
+func (UnimplementedSagaAdminServiceServer) ListSagas(context.Context, *ListSagasRequest) (*ListSagasResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSagas not implemented")
+}
+func (UnimplementedSagaAdminServiceServer) RetrySaga(context.Context, *RetrySagaRequest) (*Saga, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RetrySaga not implemented")
+}
+func (UnimplementedSagaAdminServiceServer) CompensateSaga(context.Context, *CompensateSagaRequest) (*Saga, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CompensateSaga not implemented")
+}
+func (UnimplementedSagaAdminServiceServer) mustEmbedUnimplementedSagaAdminServiceServer() {}
+func (UnimplementedSagaAdminServiceServer) testEmbeddedByValue()                          {}
+
+// UnsafeSagaAdminServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SagaAdminServiceServer will
+// result in compilation errors.
+type UnsafeSagaAdminServiceServer interface {
+	mustEmbedUnimplementedSagaAdminServiceServer()
+}
+
+func RegisterSagaAdminServiceServer(s grpc.ServiceRegistrar, srv SagaAdminServiceServer) {
+	// If the following call pancis, it indicates UnimplementedSagaAdminServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&SagaAdminService_ServiceDesc, srv)
+}
+
+func _SagaAdminService_GetSaga_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSagaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SagaAdminServiceServer).GetSaga(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SagaAdminService_GetSaga_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SagaAdminServiceServer).GetSaga(ctx, req.(*GetSagaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SagaAdminService_ListSagas_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSagasRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SagaAdminServiceServer).ListSagas(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SagaAdminService_ListSagas_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SagaAdminServiceServer).ListSagas(ctx, req.(*ListSagasRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SagaAdminService_RetrySaga_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RetrySagaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SagaAdminServiceServer).RetrySaga(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SagaAdminService_RetrySaga_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SagaAdminServiceServer).RetrySaga(ctx, req.(*RetrySagaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SagaAdminService_CompensateSaga_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompensateSagaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SagaAdminServiceServer).CompensateSaga(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SagaAdminService_CompensateSaga_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SagaAdminServiceServer).CompensateSaga(ctx, req.(*CompensateSagaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// SagaAdminService_ServiceDesc is the grpc.ServiceDesc for SagaAdminService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SagaAdminService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "saga.SagaAdminService",
+	HandlerType: (*SagaAdminServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetSaga",
+			Handler:    _SagaAdminService_GetSaga_Handler,
+		},
+		{
+			MethodName: "ListSagas",
+			Handler:    _SagaAdminService_ListSagas_Handler,
+		},
+		{
+			MethodName: "RetrySaga",
+			Handler:    _SagaAdminService_RetrySaga_Handler,
+		},
+		{
+			MethodName: "CompensateSaga",
+			Handler:    _SagaAdminService_CompensateSaga_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "pkg/saga/sagapb/saga.proto",
+}
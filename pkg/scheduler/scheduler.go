@@ -0,0 +1,305 @@
+package scheduler
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/robfig/cron/v3"
+)
+
+// ErrNotFound is returned when cancelling a message that does not exist or already fired
+var ErrNotFound = errors.New("scheduled message not found")
+
+const tableSQL = `CREATE TABLE IF NOT EXISTS scheduled_messages (
+	id TEXT PRIMARY KEY,
+	subject TEXT NOT NULL,
+	payload BYTEA NOT NULL,
+	due_at TIMESTAMPTZ NOT NULL,
+	cron TEXT NOT NULL DEFAULT '',
+	jitter_ms BIGINT NOT NULL DEFAULT 0,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS scheduled_messages_due_at_idx ON scheduled_messages (due_at)`
+
+// Config holds scheduler configuration
+type Config struct {
+	PollInterval time.Duration // How often due messages are checked (default: 1s)
+	BatchSize    int           // Maximum messages fired per poll (default: 100)
+}
+
+// Scheduler persists delayed and recurring messages in Postgres and publishes
+// them to NATS when due. Any number of replicas may run; each due message is
+// claimed with a row lock so only one replica fires it.
+//
+// Delivery is at least once: if the database commit fails after publishing, the message
+// fires again. Messages carry a Nats-Msg-Id header, so when a JetStream stream captures
+// the subject it drops the duplicate; core NATS subscribers can receive it twice.
+type Scheduler struct {
+	db  *sql.DB
+	nc  *nats.Conn
+	cfg Config
+}
+
+// Option customises a scheduled message
+type Option func(*message)
+
+type message struct {
+	id     string
+	jitter time.Duration
+}
+
+// WithID sets the message ID, making scheduling idempotent: scheduling an ID
+// that already exists replaces the pending message
+func WithID(id string) Option {
+	return func(m *message) {
+		m.id = id
+	}
+}
+
+// WithJitter delays each delivery by a random duration up to max,
+// spreading recurring jobs scheduled by many services for the same time
+func WithJitter(max time.Duration) Option {
+	return func(m *message) {
+		m.jitter = max
+	}
+}
+
+// New creates a scheduler, creating its table if needed
+func New(ctx context.Context, db *sql.DB, nc *nats.Conn, cfg Config) (*Scheduler, error) {
+	if cfg.PollInterval == 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.BatchSize == 0 {
+		cfg.BatchSize = 100
+	}
+
+	if _, err := db.ExecContext(ctx, tableSQL); err != nil {
+		return nil, fmt.Errorf("failed to create scheduled_messages table: %w", err)
+	}
+
+	return &Scheduler{
+		db:  db,
+		nc:  nc,
+		cfg: cfg,
+	}, nil
+}
+
+// ScheduleAt publishes data to subject at the given time and returns the message ID
+func (s *Scheduler) ScheduleAt(ctx context.Context, subject string, data []byte, at time.Time, opts ...Option) (string, error) {
+	return s.schedule(ctx, subject, data, at, "", opts)
+}
+
+// ScheduleAfter publishes data to subject once the delay has elapsed and returns the message ID
+func (s *Scheduler) ScheduleAfter(ctx context.Context, subject string, data []byte, delay time.Duration, opts ...Option) (string, error) {
+	return s.schedule(ctx, subject, data, time.Now().Add(delay), "", opts)
+}
+
+// ScheduleCron publishes data to subject on a recurring schedule and returns the message ID.
+// expr is a standard five-field cron expression or a descriptor such as @hourly or @every 5m.
+func (s *Scheduler) ScheduleCron(ctx context.Context, subject string, data []byte, expr string, opts ...Option) (string, error) {
+	schedule, err := cron.ParseStandard(expr)
+	if err != nil {
+		return "", fmt.Errorf("invalid cron expression %q: %w", expr, err)
+	}
+	return s.schedule(ctx, subject, data, schedule.Next(time.Now()), expr, opts)
+}
+
+func (s *Scheduler) schedule(ctx context.Context, subject string, data []byte, at time.Time, expr string, opts []Option) (string, error) {
+	if subject == "" {
+		return "", fmt.Errorf("subject is required")
+	}
+
+	m := &message{}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.id == "" {
+		id, err := newID()
+		if err != nil {
+			return "", err
+		}
+		m.id = id
+	}
+	if data == nil {
+		data = []byte{}
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO scheduled_messages (id, subject, payload, due_at, cron, jitter_ms)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (id) DO UPDATE SET
+			subject = EXCLUDED.subject, payload = EXCLUDED.payload, due_at = EXCLUDED.due_at,
+			cron = EXCLUDED.cron, jitter_ms = EXCLUDED.jitter_ms`,
+		m.id, subject, data, withJitter(at, m.jitter), expr, m.jitter.Milliseconds(),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to schedule message: %w", err)
+	}
+
+	return m.id, nil
+}
+
+// Cancel removes a pending or recurring message
+func (s *Scheduler) Cancel(ctx context.Context, id string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM scheduled_messages WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to cancel message %s: %w", id, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to cancel message %s: %w", id, err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Run fires due messages until ctx is done
+func (s *Scheduler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	log.Printf("Scheduler started (poll interval %s)", s.cfg.PollInterval)
+
+	for {
+		for {
+			n, err := s.fireDue(ctx)
+			if err != nil {
+				log.Printf("Scheduler: failed to fire due messages: %v", err)
+				break
+			}
+			// Keep draining while full batches come back
+			if n < s.cfg.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// fireDue claims a batch of due messages, publishes them and reschedules or removes them,
+// returning the number fired. Rows are locked with SKIP LOCKED so concurrent replicas
+// never fire the same message. A failed publish stops the batch: the rest stay due and
+// the error ends the drain in Run until the next poll.
+func (s *Scheduler) fireDue(ctx context.Context) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, subject, payload, due_at, cron, jitter_ms
+		FROM scheduled_messages
+		WHERE due_at <= now()
+		ORDER BY due_at
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED`, s.cfg.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query due messages: %w", err)
+	}
+
+	type due struct {
+		id, subject, cron string
+		payload           []byte
+		dueAt             time.Time
+		jitter            time.Duration
+	}
+	var batch []due
+	for rows.Next() {
+		var d due
+		var jitterMs int64
+		if err := rows.Scan(&d.id, &d.subject, &d.payload, &d.dueAt, &d.cron, &jitterMs); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to read due message: %w", err)
+		}
+		d.jitter = time.Duration(jitterMs) * time.Millisecond
+		batch = append(batch, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read due messages: %w", err)
+	}
+
+	fired := 0
+	var publishErr error
+	for _, d := range batch {
+		msg := nats.NewMsg(d.subject)
+		msg.Data = d.payload
+		// A JetStream stream capturing the subject drops the duplicate if the commit
+		// below fails after publishing; core NATS does not deduplicate
+		msg.Header.Set(nats.MsgIdHdr, fmt.Sprintf("%s-%d", d.id, d.dueAt.UnixNano()))
+
+		if err := s.nc.PublishMsg(msg); err != nil {
+			// Leave this message and the rest of the batch due for the next poll
+			publishErr = fmt.Errorf("failed to publish message %s to %s: %w", d.id, d.subject, err)
+			break
+		}
+
+		if d.cron == "" {
+			_, err = tx.ExecContext(ctx, `DELETE FROM scheduled_messages WHERE id = $1`, d.id)
+		} else {
+			var next time.Time
+			next, err = nextRun(d.cron, d.jitter)
+			if err == nil {
+				_, err = tx.ExecContext(ctx, `UPDATE scheduled_messages SET due_at = $1 WHERE id = $2`, next, d.id)
+			}
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to update message %s: %w", d.id, err)
+		}
+		fired++
+	}
+
+	if err := s.nc.FlushWithContext(ctx); err != nil && fired > 0 {
+		return 0, fmt.Errorf("failed to flush published messages: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit fired messages: %w", err)
+	}
+
+	return fired, publishErr
+}
+
+func nextRun(expr string, jitter time.Duration) (time.Time, error) {
+	schedule, err := cron.ParseStandard(expr)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+	}
+	return withJitter(schedule.Next(time.Now()), jitter), nil
+}
+
+func withJitter(t time.Time, max time.Duration) time.Time {
+	if max <= 0 {
+		return t
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(max)))
+	if err != nil {
+		return t
+	}
+	return t.Add(time.Duration(n.Int64()))
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate message ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,101 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// DefaultCheckpointTTL is how long the checkpoint of an idle stream is kept by default
+const DefaultCheckpointTTL = time.Hour
+
+// Checkpoints store the last sequence committed for each client or bidi stream, so a
+// server skips the messages a reconnecting client sends again. Servers sharing streams
+// across instances need a shared store such as RedisCheckpoints.
+type Checkpoints interface {
+	// Get returns the last sequence committed for a stream, 0 if none
+	Get(ctx context.Context, id string) (uint64, error)
+	// Set commits the sequence of a stream
+	Set(ctx context.Context, id string, seq uint64) error
+}
+
+// MemoryCheckpoints keeps checkpoints in memory, for single instance services
+type MemoryCheckpoints struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]checkpoint
+}
+
+type checkpoint struct {
+	seq     uint64
+	expires time.Time
+}
+
+// NewMemoryCheckpoints creates a store dropping checkpoints unused for ttl (default: DefaultCheckpointTTL)
+func NewMemoryCheckpoints(ttl time.Duration) *MemoryCheckpoints {
+	if ttl <= 0 {
+		ttl = DefaultCheckpointTTL
+	}
+	return &MemoryCheckpoints{ttl: ttl, entries: map[string]checkpoint{}}
+}
+
+// Get returns the last sequence committed for a stream
+func (c *MemoryCheckpoints) Get(ctx context.Context, id string) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[id]
+	if !ok || time.Now().After(entry.expires) {
+		return 0, nil
+	}
+	return entry.seq, nil
+}
+
+// Set commits the sequence of a stream
+func (c *MemoryCheckpoints) Set(ctx context.Context, id string, seq uint64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for key, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+	c.entries[id] = checkpoint{seq: seq, expires: now.Add(c.ttl)}
+	return nil
+}
+
+// RedisCheckpoints keeps checkpoints in Redis, shared by the instances of a service
+type RedisCheckpoints struct {
+	client *redis.Client
+	prefix string
+	ttl    time.Duration
+}
+
+// NewRedisCheckpoints creates a store using keys under prefix (default "stream:checkpoint:"),
+// expiring after ttl without updates (default: DefaultCheckpointTTL)
+func NewRedisCheckpoints(client *redis.Client, prefix string, ttl time.Duration) *RedisCheckpoints {
+	if prefix == "" {
+		prefix = "stream:checkpoint:"
+	}
+	if ttl <= 0 {
+		ttl = DefaultCheckpointTTL
+	}
+	return &RedisCheckpoints{client: client, prefix: prefix, ttl: ttl}
+}
+
+// Get returns the last sequence committed for a stream
+func (c *RedisCheckpoints) Get(ctx context.Context, id string) (uint64, error) {
+	seq, err := c.client.Get(ctx, c.prefix+id).Uint64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return seq, err
+}
+
+// Set commits the sequence of a stream
+func (c *RedisCheckpoints) Set(ctx context.Context, id string, seq uint64) error {
+	return c.client.Set(ctx, c.prefix+id, seq, c.ttl).Err()
+}
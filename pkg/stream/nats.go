@@ -0,0 +1,179 @@
+package stream
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/metrics"
+	natslib "github.com/nats-io/nats.go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Defaults of a Subscription
+const (
+	DefaultBuffer    = 256
+	DefaultHeartbeat = 15 * time.Second
+)
+
+// SlowConsumerPolicy decides what happens to a client that falls a full buffer behind
+type SlowConsumerPolicy int
+
+const (
+	// DropOldest drops the oldest buffered message to make room, counted in stream_dropped_total
+	DropOldest SlowConsumerPolicy = iota
+	// Disconnect ends the stream with ResourceExhausted; JetStream clients resume from
+	// the last sequence they received
+	Disconnect
+)
+
+var droppedMetric = metrics.Map("stream_dropped_total")
+
+// Subscription describes the NATS messages bridged to a client by Subscribe
+type Subscription[T any] struct {
+	// Subject may contain wildcards ("orders.*.created", "orders.>")
+	Subject string
+	// JetStream, when set, reads Subject from the JetStream stream that captures it
+	// through an ordered consumer, so messages carry their stream sequence and can be
+	// replayed. Otherwise messages are read from core NATS, live only.
+	JetStream natslib.JetStreamContext
+	// StartAfter replays a JetStream source from the message after this stream sequence,
+	// as returned by Resume; 0 delivers new messages only
+	StartAfter uint64
+
+	// Buffer bounds the messages waiting for the client (default: DefaultBuffer)
+	Buffer int
+	// Policy applies when the buffer is full
+	Policy SlowConsumerPolicy
+	// SendTimeout bounds each send, as in NewSender
+	SendTimeout time.Duration
+	// Heartbeat is the idle time after which Heartbeat messages are sent, keeping
+	// proxies from closing quiet streams and showing clients the stream is alive
+	// (default: DefaultHeartbeat; negative: none)
+	Heartbeat time.Duration
+
+	// Allow filters messages by subject, e.g. with the caller's permissions; nil allows all.
+	// It runs before messages are buffered, so filtered messages never take buffer space.
+	Allow func(subject string) bool
+	// Message converts a NATS message to a stream message; seq is its JetStream stream
+	// sequence, 0 for core NATS. Messages that fail to convert are skipped. Required.
+	Message func(msg *natslib.Msg, seq uint64) (T, error)
+	// HeartbeatMessage builds a heartbeat, required unless Heartbeat is negative
+	HeartbeatMessage func() T
+}
+
+// Subscribe bridges the NATS messages of sub to a server stream until the client goes
+// away, e.g.
+//
+//	return stream.Subscribe(ss.Context(), nc, ss.Send, stream.Subscription[*pb.Event]{...})
+//
+// Messages wait for the client in a buffer of their own, so a slow client never holds up
+// NATS or other clients. Errors are statuses.
+func Subscribe[T any](ctx context.Context, nc *natslib.Conn, send func(T) error, sub Subscription[T]) error {
+	if nc == nil {
+		return status.Error(codes.Unavailable, "event source is not configured")
+	}
+	if sub.Message == nil {
+		return status.Error(codes.Internal, "stream subscription has no Message function")
+	}
+	if sub.Heartbeat >= 0 && sub.HeartbeatMessage == nil {
+		return status.Error(codes.Internal, "stream subscription has no HeartbeatMessage function")
+	}
+	if sub.Buffer <= 0 {
+		sub.Buffer = DefaultBuffer
+	}
+	if sub.Heartbeat == 0 {
+		sub.Heartbeat = DefaultHeartbeat
+	}
+	method, _ := grpc.Method(ctx)
+
+	// The NATS callback runs on the subscription's own goroutine; it never blocks
+	buffer := make(chan *natslib.Msg, sub.Buffer)
+	overflow := make(chan struct{}, 1)
+	handler := func(msg *natslib.Msg) {
+		if sub.Allow != nil && !sub.Allow(msg.Subject) {
+			return
+		}
+		select {
+		case buffer <- msg:
+			return
+		default:
+		}
+		if sub.Policy == Disconnect {
+			select {
+			case overflow <- struct{}{}:
+			default:
+			}
+			return
+		}
+		select {
+		case <-buffer:
+		default:
+		}
+		droppedMetric.Add(method, 1)
+		select {
+		case buffer <- msg:
+		default:
+		}
+	}
+
+	var natsSub *natslib.Subscription
+	var err error
+	if sub.JetStream != nil {
+		start := natslib.DeliverNew()
+		if sub.StartAfter > 0 {
+			start = natslib.StartSequence(sub.StartAfter + 1)
+		}
+		natsSub, err = sub.JetStream.Subscribe(sub.Subject, handler, natslib.OrderedConsumer(), start)
+	} else {
+		natsSub, err = nc.Subscribe(sub.Subject, handler)
+	}
+	if err != nil {
+		return status.Errorf(codes.Unavailable, "failed to subscribe to %s: %v", sub.Subject, err)
+	}
+	defer natsSub.Unsubscribe()
+
+	interval := sub.Heartbeat
+	if interval < 0 {
+		interval = DefaultHeartbeat // Only checks the subscription
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	sender := NewSender(ctx, send, sub.SendTimeout)
+	for {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-overflow:
+			slowConsumerMetric.Add(method, 1)
+			return status.Errorf(codes.ResourceExhausted, "client fell %d messages behind", sub.Buffer)
+		case msg := <-buffer:
+			var seq uint64
+			if meta, err := msg.Metadata(); err == nil {
+				seq = meta.Sequence.Stream
+			}
+			out, err := sub.Message(msg, seq)
+			if err != nil {
+				slog.WarnContext(ctx, "Skipping stream message", "subject", msg.Subject, "error", err)
+				continue
+			}
+			if err := sender.Send(out); err != nil {
+				return err
+			}
+			ticker.Reset(interval)
+		case <-ticker.C:
+			if !natsSub.IsValid() {
+				return status.Errorf(codes.Unavailable, "subscription to %s was closed", sub.Subject)
+			}
+			if sub.Heartbeat < 0 {
+				continue
+			}
+			if err := sender.Send(sub.HeartbeatMessage()); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package stream
+
+import (
+	"context"
+	"time"
+
+	grpcpkg "github.com/LucasPluta/GoMicroserviceFramework/pkg/grpc"
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultSendTimeout is how long a send may wait for a stalled client by default
+const DefaultSendTimeout = 30 * time.Second
+
+var slowConsumerMetric = metrics.Map("stream_slow_consumers_total")
+
+// Sender sends the messages of a stream within the client's flow control. A send blocks
+// while the client's flow-control window is full, which slows the handler down to the
+// client's pace; a client that stops reading for longer than the timeout gets a
+// ResourceExhausted error instead of holding the handler, and a client that goes away
+// or runs out of time ends the send at once.
+//
+// After an error the stream is unusable: the handler must return the error. A send that
+// gives up aborts the stream (see grpc.AbortStream) and waits for the blocked write, so
+// nothing is written after the handler returns.
+type Sender[T any] struct {
+	ctx     context.Context
+	send    func(T) error
+	timeout time.Duration
+	err     error
+}
+
+// NewSender creates a sender for the stream whose context is ctx, e.g.
+//
+//	sender := stream.NewSender(ss.Context(), ss.Send, 0)
+//
+// timeout bounds each send (default: DefaultSendTimeout).
+func NewSender[T any](ctx context.Context, send func(T) error, timeout time.Duration) *Sender[T] {
+	if timeout <= 0 {
+		timeout = DefaultSendTimeout
+	}
+	return &Sender[T]{ctx: ctx, send: send, timeout: timeout}
+}
+
+// Send sends msg, waiting for the client to have room for it
+func (s *Sender[T]) Send(msg T) error {
+	if s.err != nil {
+		return s.err
+	}
+	if err := s.ctx.Err(); err != nil {
+		s.err = status.FromContextError(err).Err()
+		return s.err
+	}
+
+	// The send runs apart so that the wait can be bounded; ending the stream unblocks it
+	done := make(chan error, 1)
+	go func() {
+		done <- s.send(msg)
+	}()
+
+	timer := time.NewTimer(s.timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		if err != nil {
+			s.err = err
+		}
+		return s.err
+	case <-s.ctx.Done():
+		s.err = status.FromContextError(s.ctx.Err()).Err()
+	case <-timer.C:
+		method, _ := grpc.Method(s.ctx)
+		slowConsumerMetric.Add(method, 1)
+		s.err = status.Errorf(codes.ResourceExhausted, "client did not read for %v", s.timeout)
+	}
+	if grpcpkg.AbortStream(s.ctx) {
+		<-done
+	}
+	return s.err
+}
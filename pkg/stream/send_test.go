@@ -0,0 +1,83 @@
+package stream
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	grpcpkg "github.com/LucasPluta/GoMicroserviceFramework/pkg/grpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// floodServer streams health responses through a Sender until a send fails
+type floodServer struct {
+	healthpb.UnimplementedHealthServer
+	inFlight atomic.Int32 // Sends not yet returned
+	result   chan error   // The handler's error and whether a send was still in flight
+}
+
+func (f *floodServer) Watch(req *healthpb.HealthCheckRequest, ss healthpb.Health_WatchServer) error {
+	send := func(msg *healthpb.HealthCheckResponse) error {
+		f.inFlight.Add(1)
+		defer f.inFlight.Add(-1)
+		return ss.Send(msg)
+	}
+	sender := NewSender(ss.Context(), send, 100*time.Millisecond)
+	for {
+		if err := sender.Send(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}); err != nil {
+			if n := f.inFlight.Load(); n != 0 {
+				f.result <- status.Errorf(codes.Internal, "%d sends in flight after %v", n, err)
+			} else {
+				f.result <- err
+			}
+			return err
+		}
+	}
+}
+
+func TestSendTimeoutWaitsForBlockedSend(t *testing.T) {
+	flood := &floodServer{result: make(chan error, 1)}
+	server := grpcpkg.NewServer()
+	healthpb.RegisterHealthServer(server, flood)
+	srv := httptest.NewUnstartedServer(grpcpkg.NewMux(server, nil))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+
+	// A small window without BDP probing, so the server blocks on flow control quickly
+	conn, err := grpc.NewClient(strings.TrimPrefix(srv.URL, "https://"),
+		grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{InsecureSkipVerify: true})),
+		grpc.WithInitialWindowSize(64*1024), grpc.WithInitialConnWindowSize(64*1024))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watch, err := healthpb.NewHealthClient(conn).Watch(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	if _, err := watch.Recv(); err != nil {
+		t.Fatalf("Recv: %v", err)
+	}
+
+	// The client stops reading
+	select {
+	case err := <-flood.result:
+		if status.Code(err) != codes.ResourceExhausted {
+			t.Errorf("handler returned %v, want ResourceExhausted with no send in flight", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the send to a stalled client never timed out")
+	}
+}
@@ -1,6 +1,6 @@
 # build.mk - Build and compilation targets
 
-.PHONY: build build-service build-all-services build-multiarch build-web-client docker-build docker-build-multiarch docker-build-web proto proto-all
+.PHONY: build build-service build-all-services build-multiarch build-web-client docker-build docker-build-multiarch docker-build-web proto proto-all proto-pkg
 
 BUILD_SCRIPTS_DIR=$(SCRIPTS_DIR)/build
 
@@ -16,6 +16,10 @@ build-web-client:
 proto-all:
 	@$(BUILD_SCRIPTS_DIR)/proto-all.sh
 
+# Generate protobuf code for framework packages (pkg/)
+proto-pkg:
+	@$(BUILD_SCRIPTS_DIR)/proto-pkg.sh
+
 # Generate protobuf code for a specific service
 proto:
 	@$(BUILD_SCRIPTS_DIR)/proto.sh $(SERVICE)
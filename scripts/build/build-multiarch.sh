@@ -25,6 +25,8 @@ lp-quiet-echo "Building ${SERVICE} for multiple architectures..."
 SERVICE_DIR="${FRAMEWORK_ROOT}/services/${SERVICE}"
 BIN_DIR="${FRAMEWORK_ROOT}/bin"
 
+LDFLAGS=$(get_ldflags)
+
 # Create bin directory
 mkdir -p "$BIN_DIR"
 
@@ -32,7 +34,7 @@ mkdir -p "$BIN_DIR"
 lp-quiet-echo "Building for linux/amd64..."
 cd "$SERVICE_DIR"
 if ! CGO_ENABLED=0 GOOS=linux GOARCH=amd64 "$GO" build \
-    -ldflags='-w -s' \
+    -ldflags="$LDFLAGS" \
     -o "${BIN_DIR}/${SERVICE}-linux-amd64" \
     ./cmd/main.go 2>&1; then
     exit 1
@@ -43,7 +45,7 @@ lp-echo "Built: ${BIN_DIR}/${SERVICE}-linux-amd64"
 lp-quiet-echo "Building for linux/arm64..."
 cd "$SERVICE_DIR"
 if ! CGO_ENABLED=0 GOOS=linux GOARCH=arm64 "$GO" build \
-    -ldflags='-w -s' \
+    -ldflags="$LDFLAGS" \
     -o "${BIN_DIR}/${SERVICE}-linux-arm64" \
     ./cmd/main.go 2>&1; then
     exit 1
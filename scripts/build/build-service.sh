@@ -25,13 +25,15 @@ lp-quiet-echo "Building ${SERVICE} for current platform..."
 SERVICE_DIR="${FRAMEWORK_ROOT}/services/${SERVICE}"
 BIN_DIR="${FRAMEWORK_ROOT}/bin"
 
+LDFLAGS=$(get_ldflags)
+
 # Create bin directory
 mkdir -p "$BIN_DIR"
 
 lp-quiet-echo "Compiling binary..."
 cd "$SERVICE_DIR"
 if ! CGO_ENABLED=0 "$GO" build \
-    -ldflags='-w -s' \
+    -ldflags="$LDFLAGS" \
     -o "${BIN_DIR}/${SERVICE}" \
     ./cmd/main.go ; then
     exit 1
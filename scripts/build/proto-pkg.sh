@@ -0,0 +1,28 @@
+#!/bin/bash
+. "./scripts/util.sh"
+
+# Generates protobuf code for framework packages (pkg/**/*.proto).
+# Unlike service protos, the generated code for framework packages is committed
+# so the packages can be imported without running protoc first.
+
+lp-quiet-echo "Generating protobuf code for framework packages..."
+
+# Get protoc binary (will error if not installed)
+if ! PROTOC=$(get_protoc_binary); then
+    lp-error "Failed to get protoc binary. Run 'make setup-protoc' first"
+    exit 1
+fi
+
+cd "$FRAMEWORK_ROOT"
+PROTO_FILES=$(find pkg -name '*.proto' | sort)
+
+if [ -z "$PROTO_FILES" ]; then
+    lp-warn "No proto files found in pkg/"
+    exit 0
+fi
+
+$PROTOC -I . --go_out=. --go_opt=paths=source_relative \
+    --go-grpc_out=. --go-grpc_opt=paths=source_relative \
+    $PROTO_FILES 2>&1
+
+lp-echo "Protobuf code generated successfully for framework packages"
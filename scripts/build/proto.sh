@@ -32,8 +32,11 @@ if ! PROTOC=$(get_protoc_binary); then
     exit 1
 fi
 
+# The framework root is on the include path so service protos can import
+# framework options such as pkg/authz/authzpb/authz.proto, and third_party/googleapis
+# for google/api/annotations.proto (REST transcoding)
 cd "$SERVICE_DIR"
-$PROTOC --go_out=. --go_opt=paths=source_relative \
+$PROTOC -I . -I "$FRAMEWORK_ROOT" -I "$FRAMEWORK_ROOT/third_party/googleapis" --go_out=. --go_opt=paths=source_relative \
     --go-grpc_out=. --go-grpc_opt=paths=source_relative \
     "proto/${SERVICE}.proto" 2>&1
 
@@ -154,6 +154,17 @@ validate_service() {
     return 0
 }
 
+# Get the linker flags stamping the build information served by the admin server
+# (pkg/admin Version, Commit and BuildTime). VERSION overrides the git description.
+get_ldflags() {
+    local pkg="github.com/LucasPluta/GoMicroserviceFramework/pkg/admin"
+    local version="${VERSION:-$(git -C "$FRAMEWORK_ROOT" describe --tags --always --dirty 2>/dev/null || echo dev)}"
+    local commit=$(git -C "$FRAMEWORK_ROOT" rev-parse HEAD 2>/dev/null || true)
+    local build_time=$(date -u '+%Y-%m-%dT%H:%M:%SZ')
+
+    echo "-w -s -X ${pkg}.Version=${version} -X ${pkg}.Commit=${commit} -X ${pkg}.BuildTime=${build_time}"
+}
+
 # Get Go binary path
 get_go_binary() {
     local go_version
@@ -3,17 +3,37 @@ package main
 import (
 	"context"
 	"log"
+	"maps"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
 	"database/sql"
 
+	"connectrpc.com/connect"
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/admin"
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/authn"
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/authz"
 	"github.com/LucasPluta/GoMicroserviceFramework/pkg/database"
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/deadline"
+	errorspkg "github.com/LucasPluta/GoMicroserviceFramework/pkg/errors"
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/flags"
 	grpcpkg "github.com/LucasPluta/GoMicroserviceFramework/pkg/grpc"
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/loadshed"
+	logpkg "github.com/LucasPluta/GoMicroserviceFramework/pkg/log"
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/mtls"
 	"github.com/LucasPluta/GoMicroserviceFramework/pkg/nats"
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/pki"
 	"github.com/LucasPluta/GoMicroserviceFramework/pkg/redis"
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/registry"
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/rest"
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/stream"
 	"github.com/LucasPluta/GoMicroserviceFramework/services/example-service/internal/handler"
 	"github.com/LucasPluta/GoMicroserviceFramework/services/example-service/internal/service"
 	pb "github.com/LucasPluta/GoMicroserviceFramework/services/example-service/proto"
@@ -23,6 +43,24 @@ import (
 )
 
 func main() {
+	// Structured logs; the standard log package writes through them too
+	logConfig := logpkg.Config{
+		Format:  getEnv("LOG_FORMAT", "json"),
+		Level:   getEnv("LOG_LEVEL", "info"),
+		Service: getEnv("SERVICE_NAME", "example-service"),
+	}
+	// Sampling keeps the first LOG_SAMPLING_FIRST records of a message each second, then every LOG_SAMPLING_THEREAFTER-th
+	var err error
+	if logConfig.Sampling.First, err = strconv.Atoi(getEnv("LOG_SAMPLING_FIRST", "0")); err != nil {
+		log.Fatalf("Invalid LOG_SAMPLING_FIRST: %v", err)
+	}
+	if logConfig.Sampling.Thereafter, err = strconv.Atoi(getEnv("LOG_SAMPLING_THEREAFTER", "0")); err != nil {
+		log.Fatalf("Invalid LOG_SAMPLING_THEREAFTER: %v", err)
+	}
+	if _, err := logpkg.Setup(logConfig); err != nil {
+		log.Fatalf("Invalid logging configuration: %v", err)
+	}
+
 	log.Println("Starting example-service...")
 
 	// Get configuration from environment
@@ -33,6 +71,37 @@ func main() {
 	keyFile := getEnv("TLS_KEY_FILE", "./certs/server-key.pem")
 	caFile := getEnv("TLS_CA_FILE", "./certs/ca-cert.pem")
 	requireClientAuth := getEnv("TLS_REQUIRE_CLIENT_AUTH", "false") == "true"
+	tlsReloadInterval, err := time.ParseDuration(getEnv("TLS_RELOAD_INTERVAL", "0s"))
+	if err != nil {
+		log.Fatalf("Invalid TLS_RELOAD_INTERVAL: %v", err)
+	}
+	defaultTimeout, err := time.ParseDuration(getEnv("RPC_DEFAULT_TIMEOUT", "30s"))
+	if err != nil {
+		log.Fatalf("Invalid RPC_DEFAULT_TIMEOUT: %v", err)
+	}
+	maxTimeout, err := time.ParseDuration(getEnv("RPC_MAX_TIMEOUT", "5m"))
+	if err != nil {
+		log.Fatalf("Invalid RPC_MAX_TIMEOUT: %v", err)
+	}
+
+	// In development, issue the server certificate from a local CA at startup
+	if caDir := getEnv("DEV_TLS_CA_DIR", ""); caDir != "" && useTLS {
+		ca, _, err := pki.EnsureCA(filepath.Join(caDir, "ca-cert.pem"), filepath.Join(caDir, "ca-key.pem"), "GoMicroserviceFramework Development CA")
+		if err != nil {
+			log.Fatalf("Failed to load development CA: %v", err)
+		}
+		issued, err := ca.EnsureServer(certFile, keyFile, pki.ServerRequest{
+			CommonName: serviceName,
+			Hosts:      pki.ServiceHosts(serviceName),
+		}, 0)
+		if err != nil {
+			log.Fatalf("Failed to issue development certificate: %v", err)
+		}
+		if issued {
+			log.Printf("Issued development certificate %s", certFile)
+		}
+		caFile = filepath.Join(caDir, "ca-cert.pem")
+	}
 
 	log.Printf("Service: %s", serviceName)
 	log.Printf("gRPC Port: %s", grpcPort)
@@ -75,7 +144,19 @@ func main() {
 
 	// Initialize NATS connection if enabled
 	var nc *natslib.Conn
-	if getEnv("USE_NATS", "false") == "true" {
+	if getEnv("DEV_EMBEDDED_NATS", "false") == "true" {
+		// Run an in-process NATS server so the service works without Docker
+		var embedded *nats.EmbeddedServer
+		var err error
+		nc, embedded, err = nats.NewEmbeddedNATSConnection(nats.EmbeddedConfig{
+			StoreDir: getEnv("DEV_EMBEDDED_NATS_STORE_DIR", ""),
+		})
+		if err != nil {
+			log.Fatalf("Failed to start embedded NATS server: %v", err)
+		}
+		defer embedded.Shutdown()
+		defer nc.Close()
+	} else if getEnv("USE_NATS", "false") == "true" {
 		natsConfig := nats.Config{
 			URL: getEnv("NATS_URL", "nats://localhost:4222"),
 		}
@@ -87,67 +168,389 @@ func main() {
 		defer nc.Close()
 	}
 
+	// Initialize feature flags (falls back to local defaults without NATS)
+	var js natslib.JetStreamContext
+	if nc != nil {
+		var err error
+		js, err = nats.NewJetStreamContext(nc)
+		if err != nil {
+			log.Printf("JetStream unavailable, feature flags use local defaults: %v", err)
+		}
+	}
+	flagClient := flags.NewClient(js, flags.Config{
+		Bucket: getEnv("FLAGS_BUCKET", flags.DefaultBucket),
+		Defaults: map[string]flags.Flag{
+			handler.FlagDependencyStatus: {Key: handler.FlagDependencyStatus, Kind: flags.KindBool, Enabled: true},
+		},
+	})
+	defer flagClient.Close()
+
 	// Initialize service
 	svc := service.NewService(ctx, db, redisClient, nc)
 
+	// Bound every call by a deadline; the remaining budget flows to outbound calls made with the request context
+	deadlineConfig := deadline.Config{
+		Limits: deadline.Limits{Default: defaultTimeout, Max: maxTimeout},
+		// Subscriptions last as long as the client wants them
+		Methods: map[string]deadline.Limits{pb.ExampleServiceService_WatchData_FullMethodName: {}},
+	}
+	serverOpts := deadline.ServerOptions(deadlineConfig)
+
+	// Log every call; LOG_PAYLOADS adds the messages, with (log.sensitive) fields redacted, at debug level
+	callLogOpts := logpkg.CallOptions{
+		Payloads: getEnv("LOG_PAYLOADS", "false") == "true",
+		Skip:     []string{"/grpc.health.v1.Health/*", "/grpc.reflection.*/*"},
+	}
+	serverOpts = append(serverOpts, logpkg.ServerOptions(callLogOpts)...)
+
+	// Shed excess load early, before authentication and handlers spend any work on it
+	var limiter *loadshed.Limiter
+	if getEnv("LOAD_SHEDDING", "true") == "true" {
+		maxConcurrency, err := strconv.Atoi(getEnv("LOAD_SHEDDING_MAX_LIMIT", "1000"))
+		if err != nil {
+			log.Fatalf("Invalid LOAD_SHEDDING_MAX_LIMIT: %v", err)
+		}
+		// Streams such as WatchData subscriptions don't hold slots of the limit while open;
+		// LOAD_SHEDDING_MAX_STREAMS bounds them separately
+		maxStreams, err := strconv.Atoi(getEnv("LOAD_SHEDDING_MAX_STREAMS", "0"))
+		if err != nil {
+			log.Fatalf("Invalid LOAD_SHEDDING_MAX_STREAMS: %v", err)
+		}
+		// Only these mTLS peers (e.g. the gateway) may raise their calls above normal priority
+		var trustedPeers []string
+		if peers := getEnv("LOAD_SHEDDING_TRUSTED_PEERS", ""); peers != "" {
+			trustedPeers = strings.Split(peers, ",")
+		}
+		limiter = loadshed.NewLimiter(loadshed.Config{
+			Name:         serviceName,
+			MaxLimit:     maxConcurrency,
+			MaxStreams:   maxStreams,
+			TrustedPeers: trustedPeers,
+		})
+		serverOpts = append(serverOpts, limiter.ServerOptions()...)
+	}
+
+	// Extract mTLS peer identities and enforce the authorization policy if configured
+	var mtlsPolicy *mtls.Policy
+	if policyFile := getEnv("MTLS_POLICY_FILE", ""); policyFile != "" {
+		// Without TLS there are no client certificates, so the policy could not be enforced
+		if !useTLS {
+			log.Fatalf("MTLS_POLICY_FILE requires USE_TLS=true")
+		}
+		mtlsPolicy, err = mtls.LoadPolicy(policyFile)
+		if err != nil {
+			log.Fatalf("Failed to load mTLS policy: %v", err)
+		}
+	}
+	if useTLS {
+		serverOpts = append(serverOpts, mtls.ServerOptions(mtlsPolicy)...)
+	}
+
+	// Authenticate bearer JWTs if a JWKS source is configured
+	var authenticator *authn.Authenticator
+	if jwksURL, jwksFile := getEnv("AUTH_JWKS_URL", ""), getEnv("AUTH_JWKS_FILE", ""); jwksURL != "" || jwksFile != "" {
+		var keys *authn.KeySet
+		if jwksFile != "" {
+			keys, err = authn.NewKeySetFromFile(jwksFile)
+		} else {
+			keys, err = authn.NewKeySetFromURL(ctx, jwksURL, time.Hour)
+		}
+		if err != nil {
+			log.Fatalf("Failed to load JWKS: %v", err)
+		}
+		// Methods annotated (authz.rule).public are always callable without a token
+		publicMethods := authz.PublicMethods()
+		if methods := getEnv("AUTH_PUBLIC_METHODS", ""); methods != "" {
+			publicMethods = append(publicMethods, strings.Split(methods, ",")...)
+		}
+		authenticator = authn.NewAuthenticator(keys, authn.Config{
+			Issuer:        getEnv("AUTH_ISSUER", ""),
+			Audience:      getEnv("AUTH_AUDIENCE", ""),
+			ClockSkew:     30 * time.Second,
+			PublicMethods: publicMethods,
+		})
+		serverOpts = append(serverOpts, authenticator.ServerOptions()...)
+	}
+
+	// Enforce the (authz.rule) method options against token roles; runs after authentication
+	var authorizer *authz.Authorizer
+	if authenticator != nil {
+		var authzConfig authz.Config
+		if configFile := getEnv("AUTHZ_CONFIG_FILE", ""); configFile != "" {
+			authzConfig, err = authz.LoadConfig(configFile)
+			if err != nil {
+				log.Fatalf("Failed to load authorization config: %v", err)
+			}
+		}
+		// Keep server reflection usable for tools like grpcurl
+		authzConfig.Unannotated = append(authzConfig.Unannotated, "/grpc.reflection.*/*")
+		authorizer = authz.NewAuthorizer(authzConfig)
+		serverOpts = append(serverOpts, authorizer.ServerOptions()...)
+	}
+
+	// Evaluate feature flags against the caller's token subject and tenant
+	serverOpts = append(serverOpts, flags.ServerOptions()...)
+
+	// Map the errors returned by handlers to codes with details; innermost, so the
+	// interceptors above log and count the mapped codes
+	serverOpts = append(serverOpts, errorspkg.ServerOptions()...)
+
+	// Stream checkpoints are shared through Redis when available, so streams resume on any instance
+	var checkpoints stream.Checkpoints = stream.NewMemoryCheckpoints(0)
+	if redisClient != nil {
+		checkpoints = stream.NewRedisCheckpoints(redisClient, "", 0)
+	}
+
+	// Subscribers receive the events of subjects "example.stream.<name>" if they hold the
+	// permission "example.<name>.read", and replay them when JetStream persists them
+	events := handler.Events{Conn: nc}
+	if js != nil {
+		if err := service.EnsureEventStream(js); err != nil {
+			log.Printf("Events are not replayable: %v", err)
+		} else {
+			events.JetStream = js
+		}
+	}
+	if authorizer != nil {
+		events.Allow = func(ctx context.Context, subject string) bool {
+			name := strings.TrimPrefix(subject, strings.TrimSuffix(service.EventSubjects, ">"))
+			return authorizer.Permitted(ctx, "example."+name+".read")
+		}
+	}
+
 	// Create handlers
-	h := handler.NewHandler(svc)
+	h := handler.NewHandler(svc, checkpoints, events, flagClient)
+
+	// One reloader watches the certificate files for every TLS listener; it loads the
+	// client CAs when the RPC or the admin listener requires client certificates
+	adminMTLS := getEnv("ADMIN_MTLS", "false") == "true"
+	tlsConfig := grpcpkg.TLSConfig{
+		CertFile:   certFile,
+		KeyFile:    keyFile,
+		CAFile:     caFile,
+		ClientAuth: requireClientAuth,
+	}
+	if (useTLS || adminMTLS) && tlsReloadInterval > 0 {
+		reloadConfig := tlsConfig
+		reloadConfig.ClientAuth = requireClientAuth || adminMTLS
+		reloader, err := grpcpkg.NewCertReloader(reloadConfig)
+		if err != nil {
+			log.Fatalf("Failed to load TLS certificates: %v", err)
+		}
+		watchCtx, stopWatch := context.WithCancel(ctx)
+		defer stopWatch()
+		go reloader.Watch(watchCtx, tlsReloadInterval)
+		tlsConfig.Reloader = reloader
+	}
 
 	// Create gRPC server (with or without TLS)
 	var grpcServer *grpc.Server
 	if useTLS {
-		tlsConfig := grpcpkg.TLSConfig{
-			CertFile:   certFile,
-			KeyFile:    keyFile,
-			CAFile:     caFile,
-			ClientAuth: requireClientAuth,
-		}
 		var err error
-		grpcServer, err = grpcpkg.NewSecureConnectServer(tlsConfig)
+		grpcServer, err = grpcpkg.NewSecureConnectServer(tlsConfig, serverOpts...)
 		if err != nil {
 			log.Fatalf("Failed to create secure gRPC server: %v", err)
 		}
 	} else {
-		grpcServer = grpcpkg.NewConnectServer()
+		grpcServer = grpcpkg.NewConnectServer(serverOpts...)
+	}
+
+	// Serve the same implementations over Connect and gRPC-Web
+	connectAdapter := grpcpkg.NewConnectAdapter(connect.WithInterceptors(deadline.ConnectInterceptor(deadlineConfig)))
+	connectAdapter.UseServerInterceptors(logpkg.UnaryServerInterceptor(callLogOpts), logpkg.StreamServerInterceptor(callLogOpts))
+	connectAdapter.UseServerInterceptors(flags.UnaryServerInterceptor(), flags.StreamServerInterceptor())
+	connectAdapter.UseServerInterceptors(errorspkg.UnaryServerInterceptor(), errorspkg.StreamServerInterceptor())
+	for _, registrar := range []grpc.ServiceRegistrar{grpcServer, connectAdapter} {
+		pb.RegisterExampleServiceServiceServer(registrar, h)
+		// Anyone reaching the port could change flags, so the flag admin RPCs are public
+		// only behind authorization; otherwise they are served on the admin port
+		if authorizer != nil {
+			flags.RegisterAdminServer(registrar, flagClient)
+		}
+	}
+	if authorizer == nil && getEnv("ADMIN_PORT", "") == "" {
+		log.Println("Flag admin RPCs are disabled: configure authorization or ADMIN_PORT to serve them")
 	}
-	pb.RegisterExampleServiceServiceServer(grpcServer, h)
 
-	// Create Connect-RPC handlers
-	connectMux := http.NewServeMux()
-	handler.RegisterConnectHandlers(connectMux, h)
+	var connectHandler http.Handler = connectAdapter
+	if useTLS {
+		connectHandler = mtls.Middleware(mtlsPolicy, connectHandler)
+	}
+	if authorizer != nil {
+		connectHandler = authorizer.Middleware(connectHandler)
+	}
+	if authenticator != nil {
+		connectHandler = authenticator.Middleware(connectHandler)
+	}
+	if limiter != nil {
+		connectHandler = limiter.Middleware(connectHandler)
+	}
+
+	// Route gRPC, gRPC-Web and Connect by protocol; plain HTTP endpoints are mounted alongside
+	mux := grpcpkg.NewMux(grpcServer, connectHandler)
+	// Health checks fail while the instance is drained through the admin server
+	drainer := admin.NewDrainer()
+	mux.Handle("GET /healthz", drainer.HealthHandler())
+
+	// Serve the google.api.http routes of the registered services as REST/JSON. Calls are
+	// transcoded to Connect and dispatched through the mux, so they share its middleware.
+	var services []string
+	for name := range grpcServer.GetServiceInfo() {
+		services = append(services, name)
+	}
+	sort.Strings(services)
+	transcoder, err := rest.NewTranscoder(mux, services...)
+	if err != nil {
+		log.Fatalf("Failed to create REST transcoder: %v", err)
+	}
+	transcoder.Mount(mux)
+
+	// Let browsers on other origins (e.g. the web client's dev server) call the service
+	corsOrigins := getEnv("CORS_ALLOWED_ORIGINS", "")
+	csrfProtection := getEnv("CSRF_PROTECTION", "false") == "true"
+	if corsOrigins != "" || csrfProtection {
+		corsMaxAge, err := time.ParseDuration(getEnv("CORS_MAX_AGE", "2h"))
+		if err != nil {
+			log.Fatalf("Invalid CORS_MAX_AGE: %v", err)
+		}
+		corsConfig := grpcpkg.CORSConfig{
+			AllowCredentials: getEnv("CORS_ALLOW_CREDENTIALS", "false") == "true",
+			MaxAge:           corsMaxAge,
+			CSRFProtection:   csrfProtection,
+		}
+		if corsOrigins != "" {
+			corsConfig.AllowedOrigins = strings.Split(corsOrigins, ",")
+		}
+		cors, err := grpcpkg.NewCORS(corsConfig)
+		if err != nil {
+			log.Fatalf("Invalid CORS configuration: %v", err)
+		}
+		mux.Use(cors.Middleware)
+	}
 
 	// Start server in a goroutine (supports both gRPC and Connect-RPC)
 	go func() {
 		var err error
 		if useTLS {
-			tlsConfig := grpcpkg.TLSConfig{
-				CertFile:   certFile,
-				KeyFile:    keyFile,
-				CAFile:     caFile,
-				ClientAuth: requireClientAuth,
-			}
-			err = grpcpkg.StartSecureConnectServer(grpcServer, connectMux, tlsConfig, grpcPort)
+			err = grpcpkg.StartSecureMux(mux, tlsConfig, grpcPort)
 		} else {
-			err = grpcpkg.StartConnectServer(grpcServer, connectMux, grpcPort)
+			err = grpcpkg.StartMux(mux, grpcPort)
 		}
 		if err != nil {
 			log.Fatalf("Failed to start gRPC server: %v", err)
 		}
 	}()
 
+	// Register in the service registry so clients dialing registry:///<service> find this instance
+	var registration *registry.Registration
+	if registryBackend := getEnv("REGISTRY", ""); registryBackend != "" {
+		var backend registry.Backend
+		switch registryBackend {
+		case "nats":
+			if js == nil {
+				log.Fatalf("REGISTRY=nats requires NATS with JetStream")
+			}
+			backend, err = registry.NewNATSBackend(js, getEnv("REGISTRY_BUCKET", registry.DefaultBucket), 0)
+		case "redis":
+			if redisClient == nil {
+				log.Fatalf("REGISTRY=redis requires USE_REDIS=true")
+			}
+			backend = registry.NewRedisBackend(redisClient, "", 0)
+		case "file":
+			backend, err = registry.NewFileBackend(getEnv("REGISTRY_FILE", "registry.json"), 0)
+		default:
+			log.Fatalf("Unknown REGISTRY backend %q (nats, redis or file)", registryBackend)
+		}
+		if err != nil {
+			log.Fatalf("Failed to open the service registry: %v", err)
+		}
+		registry.RegisterResolver(backend)
+
+		// A static file lists instances but cannot be registered in
+		if registryBackend != "file" {
+			hostname, _ := os.Hostname()
+			registryTTL, err := time.ParseDuration(getEnv("REGISTRY_TTL", registry.DefaultTTL.String()))
+			if err != nil {
+				log.Fatalf("Invalid REGISTRY_TTL: %v", err)
+			}
+			registration, err = registry.Register(ctx, backend, registry.Instance{
+				Service:  serviceName,
+				Address:  getEnv("REGISTRY_ADDRESS", hostname+":"+grpcPort),
+				Version:  getEnv("SERVICE_VERSION", ""),
+				Metadata: map[string]string{"tls": strconv.FormatBool(useTLS)},
+			}, registryTTL)
+			if err != nil {
+				log.Fatalf("Failed to register: %v", err)
+			}
+		}
+	}
+
+	// Draining also takes the instance out of the registry's serving instances
+	drainer.OnChange(func(ctx context.Context, draining bool) error {
+		if registration == nil {
+			return nil
+		}
+		if draining {
+			return registration.SetHealth(ctx, registry.HealthNotServing)
+		}
+		return registration.SetHealth(ctx, registry.HealthServing)
+	})
+
+	// Serve the admin endpoints (pprof, channelz, build info, config, log level, drain)
+	// on their own port, protected by a token and/or client certificates
+	if adminPort := getEnv("ADMIN_PORT", ""); adminPort != "" {
+		adminConfig := admin.Config{
+			Port:    adminPort,
+			Token:   os.Getenv("ADMIN_TOKEN"),
+			Drainer: drainer,
+		}
+		if adminMTLS {
+			adminTLS := tlsConfig
+			adminTLS.ClientAuth = true
+			adminConfig.TLS = &adminTLS
+		}
+		adminConfig.Settings = maps.Clone(settings)
+		adminServer, err := admin.New(adminConfig)
+		if err != nil {
+			log.Fatalf("Failed to create admin server: %v", err)
+		}
+		if authorizer == nil {
+			flags.RegisterAdminServer(adminServer, flagClient)
+		}
+		go func() {
+			if err := adminServer.Start(); err != nil {
+				log.Fatalf("Failed to start admin server: %v", err)
+			}
+		}()
+	}
+
 	// Wait for interrupt signal to gracefully shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
 	log.Println("Shutting down server...")
+	if registration != nil {
+		// Stop receiving new calls before draining the ones in flight
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := registration.Close(shutdownCtx); err != nil {
+			log.Printf("Failed to deregister: %v", err)
+		}
+		cancel()
+	}
 	grpcServer.GracefulStop()
 	log.Println("Server stopped")
 }
 
+// settings records the configuration read from the environment, served by the admin
+// server with its secrets redacted
+var settings = map[string]string{}
+
 func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+	value := os.Getenv(key)
+	if value == "" {
+		value = defaultValue
 	}
-	return defaultValue
+	settings[key] = value
+	return value
 }
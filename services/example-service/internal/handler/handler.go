@@ -2,33 +2,80 @@ package handler
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/deadline"
+	errorspkg "github.com/LucasPluta/GoMicroserviceFramework/pkg/errors"
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/flags"
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/stream"
 	"github.com/LucasPluta/GoMicroserviceFramework/services/example-service/internal/service"
 	pb "github.com/LucasPluta/GoMicroserviceFramework/services/example-service/proto"
+	natslib "github.com/nats-io/nats.go"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
+// FlagDependencyStatus rolls out the dependency checks of GetStatus; callers it is off
+// for are only told that the service is running
+const FlagDependencyStatus = "example.status.dependencies"
+
 type Handler struct {
 	pb.UnimplementedExampleServiceServiceServer
-	svc *service.Service
+	svc         *service.Service
+	checkpoints stream.Checkpoints
+	events      Events
+	flags       *flags.Client
+}
+
+// Events is the source of WatchData
+type Events struct {
+	Conn *natslib.Conn
+	// JetStream is set when the service.EventStream stream exists, making events replayable
+	JetStream natslib.JetStreamContext
+	// Allow filters the events of a caller by subject; nil allows all
+	Allow func(ctx context.Context, subject string) bool
 }
 
-func NewHandler(svc *service.Service) *Handler {
+// NewHandler creates the handler. checkpoints keep the progress of client and bidi
+// streams so that reconnecting clients resume them; flagClient evaluates the feature flags
+// of the handler.
+func NewHandler(svc *service.Service, checkpoints stream.Checkpoints, events Events, flagClient *flags.Client) *Handler {
 	return &Handler{
-		svc: svc,
+		svc:         svc,
+		checkpoints: checkpoints,
+		events:      events,
+		flags:       flagClient,
 	}
 }
 
 // GetStatus implements the unary RPC method
 func (h *Handler) GetStatus(ctx context.Context, req *pb.GetStatusRequest) (*pb.GetStatusResponse, error) {
+	var violations errorspkg.Violations
 	if req.ServiceId == "" {
-		return nil, status.Error(codes.InvalidArgument, "service_id is required")
+		violations.Add("service_id", "must not be empty")
+	}
+	if err := violations.Err(); err != nil {
+		return nil, err
+	}
+
+	// The flag is evaluated for the caller, whose attributes the flags interceptor set
+	if !h.flags.Bool(ctx, FlagDependencyStatus, true) {
+		return &pb.GetStatusResponse{
+			Status:  "healthy",
+			Message: fmt.Sprintf("Service %s is running", req.ServiceId),
+		}, nil
 	}
 
 	// Call service layer
-	statusMsg := h.svc.GetServiceStatus(req.ServiceId)
+	statusMsg := h.svc.GetServiceStatus(ctx, req.ServiceId)
 
 	return &pb.GetStatusResponse{
 		Status:  "healthy",
@@ -36,28 +83,240 @@ func (h *Handler) GetStatus(ctx context.Context, req *pb.GetStatusRequest) (*pb.
 	}, nil
 }
 
-// StreamData implements the server-side streaming RPC method
-func (h *Handler) StreamData(req *pb.StreamDataRequest, stream pb.ExampleServiceService_StreamDataServer) error {
+// StreamData implements the server-side streaming RPC method. An interrupted stream
+// resumes after the item named by the request's resume_token or the x-last-sequence header.
+func (h *Handler) StreamData(req *pb.StreamDataRequest, ss pb.ExampleServiceService_StreamDataServer) error {
 	if req.Limit <= 0 {
 		req.Limit = 10 // Default limit
 	}
 
-	// Stream data to client
-	for i := int32(0); i < req.Limit; i++ {
-		data := h.svc.GenerateData(req.Filter, i)
+	ctx := ss.Context()
+	last, err := stream.Resume(ctx, req.Filter, req.ResumeToken)
+	if err != nil {
+		return err
+	}
+
+	// Sends wait for the client to have room, and give up on clients that stop reading
+	sender := stream.NewSender(ctx, ss.Send, 0)
+	for seq := last + 1; seq <= uint64(req.Limit); seq++ {
+		data := h.svc.GenerateData(ctx, req.Filter, int32(seq-1))
 
 		resp := &pb.StreamDataResponse{
-			Data:      data,
-			Timestamp: time.Now().Unix(),
+			Data:        data,
+			Timestamp:   time.Now().Unix(),
+			Sequence:    seq,
+			ResumeToken: stream.Token(req.Filter, seq),
+		}
+		if err := sender.Send(resp); err != nil {
+			return err
+		}
+
+		// Simulate some processing time, stopping if the client goes away or the deadline passes
+		if err := deadline.Sleep(ctx, 100*time.Millisecond); err != nil {
+			return status.FromContextError(err).Err()
+		}
+	}
+
+	return nil
+}
+
+// WatchData implements the live subscription RPC method, streaming the events published
+// under example.stream. A client falling behind loses its oldest events; with JetStream,
+// a reconnecting client replays the events it missed from its resume token.
+func (h *Handler) WatchData(req *pb.WatchDataRequest, ss pb.ExampleServiceService_WatchDataServer) error {
+	subject := req.Subject
+	if subject == "" {
+		subject = ">"
+	}
+	if !validSubject(subject) {
+		return errorspkg.InvalidArgument("subject", fmt.Sprintf("%q is not a NATS subject", subject))
+	}
+	subject = strings.TrimSuffix(service.EventSubjects, ">") + subject
+
+	ctx := ss.Context()
+	last, err := stream.Resume(ctx, subject, req.ResumeToken)
+	if err != nil {
+		return err
+	}
+
+	sub := stream.Subscription[*pb.DataEvent]{
+		Subject:    subject,
+		JetStream:  h.events.JetStream,
+		StartAfter: last,
+		Message: func(msg *natslib.Msg, seq uint64) (*pb.DataEvent, error) {
+			event := &pb.DataEvent{
+				Subject:   msg.Subject,
+				Data:      string(msg.Data),
+				Timestamp: time.Now().Unix(),
+				Sequence:  seq,
+			}
+			if seq > 0 {
+				event.ResumeToken = stream.Token(subject, seq)
+			}
+			return event, nil
+		},
+		HeartbeatMessage: func() *pb.DataEvent {
+			return &pb.DataEvent{Heartbeat: true, Timestamp: time.Now().Unix()}
+		},
+	}
+	if h.events.Allow != nil {
+		sub.Allow = func(subject string) bool {
+			return h.events.Allow(ctx, subject)
+		}
+	}
+	return stream.Subscribe(ctx, h.events.Conn, ss.Send, sub)
+}
+
+// UploadData implements the client streaming RPC method. Uploads named by the
+// x-stream-id header are resumable: the x-last-sequence response header gives the last
+// item already stored, and items sent again are skipped.
+func (h *Handler) UploadData(ss pb.ExampleServiceService_UploadDataServer) error {
+	ctx := ss.Context()
+	id, last, err := h.openStream(ss, "upload:")
+	if err != nil {
+		return err
+	}
+
+	var received int32
+	for {
+		req, err := ss.Recv()
+		if err == io.EOF {
+			return ss.SendAndClose(&pb.UploadDataResponse{
+				Received:     received,
+				LastSequence: last,
+			})
+		}
+		if err != nil {
+			return err // The client went away or the deadline passed
+		}
+
+		seq, skip, err := nextSequence(req.Sequence, last)
+		if err != nil {
+			return err
+		}
+		if skip {
+			continue
+		}
+		if err := h.svc.StoreData(ctx, id, seq, req.Data); err != nil {
+			return fmt.Errorf("store item %d: %w", seq, err) // Mapped to a code by pkg/errors
+		}
+		if err := h.commit(ctx, "upload:"+id, seq); err != nil {
+			return err
 		}
+		last = seq
+		received++
+	}
+}
+
+// ProcessData implements the bidirectional streaming RPC method. Results are sent as
+// items arrive, so a client that stops reading also stops being read from. Streams named
+// by the x-stream-id header are resumable like UploadData.
+func (h *Handler) ProcessData(ss pb.ExampleServiceService_ProcessDataServer) error {
+	ctx := ss.Context()
+	id, last, err := h.openStream(ss, "process:")
+	if err != nil {
+		return err
+	}
 
-		if err := stream.Send(resp); err != nil {
-			return status.Errorf(codes.Internal, "failed to send data: %v", err)
+	sender := stream.NewSender(ctx, ss.Send, 0)
+	for {
+		req, err := ss.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
 		}
 
-		// Simulate some processing time
-		time.Sleep(100 * time.Millisecond)
+		seq, skip, err := nextSequence(req.Sequence, last)
+		if err != nil {
+			return err
+		}
+		if skip {
+			continue
+		}
+		resp := &pb.ProcessDataResponse{
+			Sequence: seq,
+			Result:   h.svc.ProcessData(ctx, req.Data),
+		}
+		if err := sender.Send(resp); err != nil {
+			return err
+		}
+		if err := h.commit(ctx, "process:"+id, seq); err != nil {
+			return err
+		}
+		last = seq
 	}
+}
 
+// openStream returns the ID of a client or bidi stream and its last committed sequence,
+// and tells the client where to resume. Streams without an x-stream-id header get a
+// fresh ID and are not resumable.
+func (h *Handler) openStream(ss grpc.ServerStream, prefix string) (string, uint64, error) {
+	ctx := ss.Context()
+	id := stream.ID(ctx)
+	if id == "" {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			return "", 0, status.Errorf(codes.Internal, "failed to generate a stream ID: %v", err)
+		}
+		return hex.EncodeToString(b), 0, nil
+	}
+
+	last, err := h.checkpoints.Get(ctx, prefix+id)
+	if err != nil {
+		return "", 0, errorspkg.Wrap(err, codes.Unavailable, "failed to load the checkpoint of stream "+id).
+			WithRetryDelay(time.Second)
+	}
+	if err := stream.SendLastSequence(ss, last); err != nil {
+		return "", 0, err
+	}
+	return id, last, nil
+}
+
+func (h *Handler) commit(ctx context.Context, key string, seq uint64) error {
+	if err := h.checkpoints.Set(ctx, key, seq); err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return status.FromContextError(err).Err()
+		}
+		return errorspkg.Wrap(err, codes.Unavailable, fmt.Sprintf("failed to commit item %d", seq)).
+			WithRetryDelay(time.Second)
+	}
 	return nil
 }
+
+// validSubject checks a NATS subject: non-empty tokens without spaces, where "*" and ">"
+// are whole tokens and ">" comes last
+func validSubject(subject string) bool {
+	tokens := strings.Split(subject, ".")
+	for i, token := range tokens {
+		switch {
+		case token == "" || strings.ContainsAny(token, " \t\r\n"):
+			return false
+		case token == ">" && i != len(tokens)-1:
+			return false
+		case token != "*" && token != ">" && strings.ContainsAny(token, "*>"):
+			return false
+		}
+	}
+	return true
+}
+
+// nextSequence checks the sequence of a received item against the last one committed.
+// Items without a sequence follow the last one; items already committed are skipped;
+// gaps are rejected, as the items in between would be lost.
+func nextSequence(seq, last uint64) (uint64, bool, error) {
+	switch {
+	case seq == 0:
+		return last + 1, false, nil
+	case seq <= last:
+		return seq, true, nil
+	case seq > last+1:
+		return 0, false, errorspkg.Newf(codes.FailedPrecondition, "expected item %d, got %d", last+1, seq).
+			WithReason("SEQUENCE_GAP", "example-service", map[string]string{
+				"expected": strconv.FormatUint(last+1, 10),
+				"received": strconv.FormatUint(seq, 10),
+			})
+	}
+	return seq, false, nil
+}
@@ -3,13 +3,25 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/deadline"
 
 	redisclient "github.com/go-redis/redis/v8"
 	natslib "github.com/nats-io/nats.go"
 )
 
+// Events are published under EventSubjects and persisted in the EventStream JetStream
+// stream, when JetStream is available, so subscribers can replay them
+const (
+	EventSubjects = "example.stream.>"
+	EventStream   = "EXAMPLE_EVENTS"
+)
+
 type Service struct {
 	ctx   context.Context
 	db    *sql.DB
@@ -26,15 +38,16 @@ func NewService(ctx context.Context, db *sql.DB, redis *redisclient.Client, nc *
 	}
 }
 
-// GetServiceStatus returns the status of the service
-func (s *Service) GetServiceStatus(serviceID string) string {
+// GetServiceStatus returns the status of the service.
+// Dependency checks use ctx so they stop when the caller's deadline passes.
+func (s *Service) GetServiceStatus(ctx context.Context, serviceID string) string {
 	msg := fmt.Sprintf("Service %s is running", serviceID)
 
 	// Example: Check if PostgreSQL is available
 	if s.db != nil {
-		if err := s.db.Ping(); err != nil {
+		if err := s.db.PingContext(ctx); err != nil {
 			msg += " (PostgreSQL: error)"
-			log.Printf("PostgreSQL ping failed: %v", err)
+			slog.ErrorContext(ctx, "PostgreSQL ping failed", "error", err)
 		} else {
 			msg += " (PostgreSQL: connected)"
 		}
@@ -42,9 +55,9 @@ func (s *Service) GetServiceStatus(serviceID string) string {
 
 	// Example: Check if Redis is available
 	if s.redis != nil {
-		if err := s.redis.Ping(s.ctx).Err(); err != nil {
+		if err := s.redis.Ping(ctx).Err(); err != nil {
 			msg += " (Redis: error)"
-			log.Printf("Redis ping failed: %v", err)
+			slog.ErrorContext(ctx, "Redis ping failed", "error", err)
 		} else {
 			msg += " (Redis: connected)"
 		}
@@ -63,7 +76,7 @@ func (s *Service) GetServiceStatus(serviceID string) string {
 }
 
 // GenerateData generates sample data for streaming
-func (s *Service) GenerateData(filter string, index int32) string {
+func (s *Service) GenerateData(ctx context.Context, filter string, index int32) string {
 	data := fmt.Sprintf("Item %d", index)
 
 	if filter != "" {
@@ -73,18 +86,50 @@ func (s *Service) GenerateData(filter string, index int32) string {
 	// Example: Store data in Redis if available
 	if s.redis != nil {
 		key := fmt.Sprintf("stream:data:%d", index)
-		if err := s.redis.Set(s.ctx, key, data, 0).Err(); err != nil {
-			log.Printf("Failed to store data in Redis: %v", err)
+		if err := s.redis.Set(ctx, key, data, 0).Err(); err != nil {
+			slog.ErrorContext(ctx, "Failed to store data in Redis", "error", err)
 		}
 	}
 
 	// Example: Publish to NATS if available
 	if s.nats != nil {
 		subject := "example.stream.data"
-		if err := s.nats.Publish(subject, []byte(data)); err != nil {
-			log.Printf("Failed to publish to NATS: %v", err)
+		// The message carries the remaining deadline so subscribers can bound their work
+		if err := s.nats.PublishMsg(deadline.NewNATSMsg(ctx, subject, []byte(data))); err != nil {
+			slog.ErrorContext(ctx, "Failed to publish to NATS", "subject", subject, "error", err)
 		}
 	}
 
 	return data
 }
+
+// StoreData stores an uploaded item
+func (s *Service) StoreData(ctx context.Context, uploadID string, sequence uint64, data string) error {
+	if s.redis == nil {
+		slog.InfoContext(ctx, "Received upload item", "upload_id", uploadID, "sequence", sequence)
+		return nil
+	}
+	key := fmt.Sprintf("upload:%s:%d", uploadID, sequence)
+	return s.redis.Set(ctx, key, data, 0).Err()
+}
+
+// ProcessData transforms an item received on a bidirectional stream
+func (s *Service) ProcessData(ctx context.Context, data string) string {
+	return fmt.Sprintf("Processed %q", strings.ToUpper(data))
+}
+
+// EnsureEventStream creates the JetStream stream persisting the events, keeping an hour of them
+func EnsureEventStream(js natslib.JetStreamContext) error {
+	_, err := js.StreamInfo(EventStream)
+	if errors.Is(err, natslib.ErrStreamNotFound) {
+		_, err = js.AddStream(&natslib.StreamConfig{
+			Name:     EventStream,
+			Subjects: []string{EventSubjects},
+			MaxAge:   time.Hour,
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create event stream %s: %w", EventStream, err)
+	}
+	return nil
+}
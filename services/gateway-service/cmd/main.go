@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"log"
+	"maps"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/admin"
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/authn"
+	grpcpkg "github.com/LucasPluta/GoMicroserviceFramework/pkg/grpc"
+	logpkg "github.com/LucasPluta/GoMicroserviceFramework/pkg/log"
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/nats"
+	"github.com/LucasPluta/GoMicroserviceFramework/services/gateway-service/internal/gateway"
+)
+
+func main() {
+	// Structured logs; the standard log package writes through them too
+	logConfig := logpkg.Config{
+		Format:  getEnv("LOG_FORMAT", "json"),
+		Level:   getEnv("LOG_LEVEL", "info"),
+		Service: getEnv("SERVICE_NAME", "gateway-service"),
+	}
+	// Sampling keeps the first LOG_SAMPLING_FIRST records of a message each second, then every LOG_SAMPLING_THEREAFTER-th
+	var err error
+	if logConfig.Sampling.First, err = strconv.Atoi(getEnv("LOG_SAMPLING_FIRST", "0")); err != nil {
+		log.Fatalf("Invalid LOG_SAMPLING_FIRST: %v", err)
+	}
+	if logConfig.Sampling.Thereafter, err = strconv.Atoi(getEnv("LOG_SAMPLING_THEREAFTER", "0")); err != nil {
+		log.Fatalf("Invalid LOG_SAMPLING_THEREAFTER: %v", err)
+	}
+	if _, err := logpkg.Setup(logConfig); err != nil {
+		log.Fatalf("Invalid logging configuration: %v", err)
+	}
+
+	log.Println("Starting gateway-service...")
+
+	// Get configuration from environment
+	serviceName := getEnv("SERVICE_NAME", "gateway-service")
+	port := getEnv("GATEWAY_PORT", "8080")
+	useTLS := getEnv("USE_TLS", "false") == "true"
+	certFile := getEnv("TLS_CERT_FILE", "./certs/server-cert.pem")
+	keyFile := getEnv("TLS_KEY_FILE", "./certs/server-key.pem")
+	caFile := getEnv("TLS_CA_FILE", "./certs/ca-cert.pem")
+	requireClientAuth := getEnv("TLS_REQUIRE_CLIENT_AUTH", "false") == "true"
+	tlsReloadInterval, err := time.ParseDuration(getEnv("TLS_RELOAD_INTERVAL", "0s"))
+	if err != nil {
+		log.Fatalf("Invalid TLS_RELOAD_INTERVAL: %v", err)
+	}
+	routesFile := getEnv("GATEWAY_ROUTES_FILE", "")
+	routesReloadInterval, err := time.ParseDuration(getEnv("GATEWAY_ROUTES_RELOAD_INTERVAL", "10s"))
+	if err != nil {
+		log.Fatalf("Invalid GATEWAY_ROUTES_RELOAD_INTERVAL: %v", err)
+	}
+	rateLimit, err := strconv.ParseFloat(getEnv("RATE_LIMIT_RPS", "0"), 64)
+	if err != nil {
+		log.Fatalf("Invalid RATE_LIMIT_RPS: %v", err)
+	}
+	rateLimitBurst, err := strconv.Atoi(getEnv("RATE_LIMIT_BURST", "0"))
+	if err != nil {
+		log.Fatalf("Invalid RATE_LIMIT_BURST: %v", err)
+	}
+
+	log.Printf("Service: %s", serviceName)
+	log.Printf("Port: %s", port)
+	log.Printf("TLS Enabled: %v", useTLS)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Authenticate bearer JWTs centrally if a JWKS source is configured
+	var authenticator *authn.Authenticator
+	if jwksURL, jwksFile := getEnv("AUTH_JWKS_URL", ""), getEnv("AUTH_JWKS_FILE", ""); jwksURL != "" || jwksFile != "" {
+		var keys *authn.KeySet
+		if jwksFile != "" {
+			keys, err = authn.NewKeySetFromFile(jwksFile)
+		} else {
+			keys, err = authn.NewKeySetFromURL(ctx, jwksURL, time.Hour)
+		}
+		if err != nil {
+			log.Fatalf("Failed to load JWKS: %v", err)
+		}
+		var publicMethods []string
+		if methods := getEnv("AUTH_PUBLIC_METHODS", ""); methods != "" {
+			publicMethods = strings.Split(methods, ",")
+		}
+		authenticator = authn.NewAuthenticator(keys, authn.Config{
+			Issuer:        getEnv("AUTH_ISSUER", ""),
+			Audience:      getEnv("AUTH_AUDIENCE", ""),
+			ClockSkew:     30 * time.Second,
+			PublicMethods: publicMethods,
+		})
+	}
+
+	gw, err := gateway.New(gateway.Options{
+		StripPrefix:       getEnv("GATEWAY_STRIP_PREFIX", "/api"),
+		Authenticator:     authenticator,
+		RateLimit:         gateway.RateLimit{RequestsPerSecond: rateLimit, Burst: rateLimitBurst},
+		TrustForwardedFor: getEnv("GATEWAY_TRUST_FORWARDED_FOR", "false") == "true",
+		BackendTLS: grpcpkg.TLSConfig{
+			CAFile:   getEnv("BACKEND_TLS_CA_FILE", ""),
+			CertFile: getEnv("BACKEND_TLS_CERT_FILE", ""),
+			KeyFile:  getEnv("BACKEND_TLS_KEY_FILE", ""),
+		},
+	})
+	if err != nil {
+		log.Fatalf("Failed to create gateway: %v", err)
+	}
+
+	// Routes come from a file, the NATS registry, or both (registry routes win)
+	if routesFile != "" {
+		if err := gw.LoadFile(ctx, routesFile, routesReloadInterval); err != nil {
+			log.Fatalf("Failed to load routes: %v", err)
+		}
+	}
+	if getEnv("USE_NATS", "false") == "true" {
+		nc, err := nats.NewNATSConnection(nats.Config{URL: getEnv("NATS_URL", "nats://localhost:4222")})
+		if err != nil {
+			log.Fatalf("Failed to connect to NATS: %v", err)
+		}
+		defer nc.Close()
+		js, err := nats.NewJetStreamContext(nc)
+		if err != nil {
+			log.Fatalf("Failed to create JetStream context: %v", err)
+		}
+		if err := gw.WatchRegistry(ctx, js, getEnv("GATEWAY_ROUTES_BUCKET", gateway.DefaultRegistryBucket)); err != nil {
+			log.Fatalf("Failed to watch the route registry: %v", err)
+		}
+	} else if routesFile == "" {
+		log.Fatalf("No routes: set GATEWAY_ROUTES_FILE or USE_NATS")
+	}
+
+	// Health checks fail while the gateway is drained through the admin server
+	drainer := admin.NewDrainer()
+	mux := http.NewServeMux()
+	mux.Handle("GET /healthz", drainer.HealthHandler())
+	// Assign request IDs and record trace context before proxying
+	mux.Handle("/", grpcpkg.Middleware(gw))
+
+	var handler http.Handler = mux
+	corsOrigins := getEnv("CORS_ALLOWED_ORIGINS", "")
+	csrfProtection := getEnv("CSRF_PROTECTION", "false") == "true"
+	if corsOrigins != "" || csrfProtection {
+		corsMaxAge, err := time.ParseDuration(getEnv("CORS_MAX_AGE", "2h"))
+		if err != nil {
+			log.Fatalf("Invalid CORS_MAX_AGE: %v", err)
+		}
+		corsConfig := grpcpkg.CORSConfig{
+			AllowCredentials: getEnv("CORS_ALLOW_CREDENTIALS", "false") == "true",
+			MaxAge:           corsMaxAge,
+			CSRFProtection:   csrfProtection,
+		}
+		if corsOrigins != "" {
+			corsConfig.AllowedOrigins = strings.Split(corsOrigins, ",")
+		}
+		cors, err := grpcpkg.NewCORS(corsConfig)
+		if err != nil {
+			log.Fatalf("Invalid CORS configuration: %v", err)
+		}
+		handler = cors.Middleware(handler)
+	}
+
+	// One reloader watches the certificate files for every TLS listener; it loads the
+	// client CAs when the gateway or the admin listener requires client certificates
+	adminMTLS := getEnv("ADMIN_MTLS", "false") == "true"
+	tlsConfig := grpcpkg.TLSConfig{
+		CertFile:   certFile,
+		KeyFile:    keyFile,
+		CAFile:     caFile,
+		ClientAuth: requireClientAuth,
+	}
+	if (useTLS || adminMTLS) && tlsReloadInterval > 0 {
+		reloadConfig := tlsConfig
+		reloadConfig.ClientAuth = requireClientAuth || adminMTLS
+		reloader, err := grpcpkg.NewCertReloader(reloadConfig)
+		if err != nil {
+			log.Fatalf("Failed to load TLS certificates: %v", err)
+		}
+		go reloader.Watch(ctx, tlsReloadInterval)
+		tlsConfig.Reloader = reloader
+	}
+
+	// Start server in a goroutine; TLS is terminated here for every backend
+	go func() {
+		var err error
+		if useTLS {
+			err = grpcpkg.StartSecureHTTP(handler, tlsConfig, port)
+		} else {
+			err = grpcpkg.StartHTTP(handler, port)
+		}
+		if err != nil {
+			log.Fatalf("Failed to start gateway: %v", err)
+		}
+	}()
+
+	// Serve the admin endpoints (pprof, channelz, build info, config, log level, drain)
+	// on their own port, protected by a token and/or client certificates
+	if adminPort := getEnv("ADMIN_PORT", ""); adminPort != "" {
+		adminConfig := admin.Config{
+			Port:    adminPort,
+			Token:   os.Getenv("ADMIN_TOKEN"),
+			Drainer: drainer,
+		}
+		if adminMTLS {
+			adminTLS := tlsConfig
+			adminTLS.ClientAuth = true
+			adminConfig.TLS = &adminTLS
+		}
+		adminConfig.Settings = maps.Clone(settings)
+		adminServer, err := admin.New(adminConfig)
+		if err != nil {
+			log.Fatalf("Failed to create admin server: %v", err)
+		}
+		go func() {
+			if err := adminServer.Start(); err != nil {
+				log.Fatalf("Failed to start admin server: %v", err)
+			}
+		}()
+	}
+
+	// Wait for interrupt signal to gracefully shutdown
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	log.Println("Gateway stopped")
+}
+
+// settings records the configuration read from the environment, served by the admin
+// server with its secrets redacted
+var settings = map[string]string{}
+
+func getEnv(key, defaultValue string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		value = defaultValue
+	}
+	settings[key] = value
+	return value
+}
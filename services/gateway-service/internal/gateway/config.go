@@ -0,0 +1,135 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+// Config is the routing table of the gateway.
+//
+// Example (JSON):
+//
+//	{
+//	  "routes": [
+//	    {"name": "example-service",
+//	     "packages": ["exampleservice", "flags"],
+//	     "paths": ["/v1/status/"],
+//	     "public_methods": ["/exampleservice.ExampleServiceService/GetStatus", "/v1/status/*"],
+//	     "backends": [
+//	       {"url": "https://example-service:50051", "weight": 90},
+//	       {"url": "https://example-service-canary:50051", "weight": 10}
+//	     ]}
+//	  ]
+//	}
+type Config struct {
+	Routes []Route `json:"routes"`
+}
+
+// Route sends the RPCs of some proto packages, and REST paths, to a set of backends
+type Route struct {
+	Name string `json:"name"`
+
+	// Packages are proto packages ("users") or fully-qualified services
+	// ("users.UserService") whose Connect, gRPC and gRPC-Web calls use this route.
+	// The most specific match wins.
+	Packages []string `json:"packages"`
+
+	// Paths are REST path prefixes ("/v1/users/") or exact paths; the longest match wins
+	Paths []string `json:"paths,omitempty"`
+
+	// Backends share the traffic in proportion to their weights, e.g. 90/10 for a canary
+	Backends []Backend `json:"backends"`
+
+	// PublicMethods are method or path patterns callable without a token, in addition
+	// to the gateway's own AUTH_PUBLIC_METHODS
+	PublicMethods []string `json:"public_methods,omitempty"`
+
+	// RateLimit overrides the gateway's default per-client rate limit for this route
+	RateLimit *RateLimit `json:"rate_limit,omitempty"`
+}
+
+// Backend is an instance, or a load-balanced group of instances, serving a route
+type Backend struct {
+	URL    string `json:"url"`    // https:// for TLS, http:// for HTTP/2 without TLS (h2c)
+	Weight int    `json:"weight"` // Relative share of the traffic; when every weight is 0 it is split evenly
+}
+
+// RateLimit is a token bucket per client: RequestsPerSecond refill and Burst capacity
+type RateLimit struct {
+	RequestsPerSecond float64 `json:"rps"`
+	Burst             int     `json:"burst"`
+}
+
+// LoadConfig reads a JSON routing table
+func LoadConfig(file string) (Config, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read gateway routes: %w", err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("failed to parse gateway routes %s: %w", file, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// Validate checks every route and that route names are unique
+func (c Config) Validate() error {
+	names := map[string]bool{}
+	for i, route := range c.Routes {
+		if err := route.Validate(); err != nil {
+			return fmt.Errorf("gateway route %d: %w", i, err)
+		}
+		if names[route.Name] {
+			return fmt.Errorf("gateway route %d: duplicate name %q", i, route.Name)
+		}
+		names[route.Name] = true
+	}
+	return nil
+}
+
+// Validate checks the route for missing backends and malformed URLs and patterns
+func (r Route) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("route has no name")
+	}
+	if len(r.Packages) == 0 && len(r.Paths) == 0 {
+		return fmt.Errorf("route %s has no packages or paths", r.Name)
+	}
+	for _, p := range r.Paths {
+		if !strings.HasPrefix(p, "/") {
+			return fmt.Errorf("route %s: path %q must start with /", r.Name, p)
+		}
+	}
+	for _, pattern := range r.PublicMethods {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("route %s: malformed pattern %q", r.Name, pattern)
+		}
+	}
+
+	if len(r.Backends) == 0 {
+		return fmt.Errorf("route %s has no backends", r.Name)
+	}
+	for _, b := range r.Backends {
+		u, err := url.Parse(b.URL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("route %s: backend URL %q must be http(s)://host:port", r.Name, b.URL)
+		}
+		if b.Weight < 0 {
+			return fmt.Errorf("route %s: backend %s has a negative weight", r.Name, b.URL)
+		}
+	}
+
+	if r.RateLimit != nil && (r.RateLimit.RequestsPerSecond < 0 || r.RateLimit.Burst < 0) {
+		return fmt.Errorf("route %s: rate limit must not be negative", r.Name)
+	}
+	return nil
+}
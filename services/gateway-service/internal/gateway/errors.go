@@ -0,0 +1,56 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"connectrpc.com/connect"
+	grpcpkg "github.com/LucasPluta/GoMicroserviceFramework/pkg/grpc"
+)
+
+// httpStatus maps error codes to the HTTP statuses of Connect and REST responses
+var httpStatus = map[connect.Code]int{
+	connect.CodeCanceled:          499,
+	connect.CodeNotFound:          http.StatusNotFound,
+	connect.CodeUnimplemented:     http.StatusNotImplemented,
+	connect.CodeUnauthenticated:   http.StatusUnauthorized,
+	connect.CodeResourceExhausted: http.StatusTooManyRequests,
+	connect.CodeUnavailable:       http.StatusServiceUnavailable,
+}
+
+// writeError answers with an error the client's protocol understands: a trailers-only
+// response with grpc-status for gRPC and gRPC-Web, a Connect JSON error otherwise
+func writeError(w http.ResponseWriter, r *http.Request, code connect.Code, message string) {
+	switch grpcpkg.DetectProtocol(r) {
+	case grpcpkg.ProtocolGRPC, grpcpkg.ProtocolGRPCWeb:
+		w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+		w.Header().Set("Grpc-Status", strconv.Itoa(int(code)))
+		w.Header().Set("Grpc-Message", percentEncode(message))
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	status, ok := httpStatus[code]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"code": code.String(), "message": message})
+}
+
+// percentEncode encodes a grpc-message value: bytes outside printable ASCII, and '%'
+func percentEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c < ' ' || c > '~' || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,243 @@
+// Package gateway routes Connect, gRPC, gRPC-Web and REST requests to backend services by
+// proto package, with central authentication, rate limiting and weighted routes.
+package gateway
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/authn"
+	grpcpkg "github.com/LucasPluta/GoMicroserviceFramework/pkg/grpc"
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/loadshed"
+	"github.com/LucasPluta/GoMicroserviceFramework/pkg/metrics"
+	"golang.org/x/net/http2"
+)
+
+var (
+	requestsMetric    = metrics.Map("gateway_requests_total")       // By route and backend
+	errorsMetric      = metrics.Map("gateway_backend_errors_total") // By route and backend
+	rateLimitedMetric = metrics.Map("gateway_rate_limited_total")   // By route
+	unroutedMetric    = metrics.Int("gateway_unrouted_requests_total")
+)
+
+// Options configures the gateway
+type Options struct {
+	// StripPrefix is removed from request paths before routing, e.g. "/api" for browser
+	// clients served from the same origin as the web app
+	StripPrefix string
+
+	// Authenticator verifies bearer tokens; nil leaves authentication to the backends.
+	// Its public methods, and those of the route, are callable without a token.
+	Authenticator *authn.Authenticator
+
+	// RateLimit applies per client to routes that don't set their own (0 disables)
+	RateLimit RateLimit
+
+	// TrustForwardedFor identifies clients by the first X-Forwarded-For address, for
+	// rate limiting and forwarding, when the gateway runs behind a trusted proxy
+	TrustForwardedFor bool
+
+	// BackendTLS configures connections to https:// backends: CAFile verifies them and
+	// CertFile/KeyFile present the gateway's client certificate for mTLS
+	BackendTLS grpcpkg.TLSConfig
+}
+
+// Gateway is an http.Handler proxying requests to the backend of their route
+type Gateway struct {
+	opts         Options
+	tlsTransport http.RoundTripper // https:// backends
+	h2cTransport http.RoundTripper // http:// backends
+
+	mu      sync.Mutex
+	sources map[string][]Route // Routes by source, e.g. the routes file or the registry
+	order   []string
+	table   atomic.Pointer[table]
+}
+
+// New creates a gateway without routes; add them with SetRoutes, LoadFile or WatchRegistry
+func New(opts Options) (*Gateway, error) {
+	tlsConfig, err := grpcpkg.NewClientTLS(opts.BackendTLS, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backend TLS config: %w", err)
+	}
+
+	g := &Gateway{
+		opts: opts,
+		tlsTransport: &http2.Transport{
+			TLSClientConfig: tlsConfig,
+			ReadIdleTimeout: 30 * time.Second,
+			PingTimeout:     10 * time.Second,
+		},
+		h2cTransport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, addr)
+			},
+			ReadIdleTimeout: 30 * time.Second,
+			PingTimeout:     10 * time.Second,
+		},
+		sources: map[string][]Route{},
+	}
+	g.table.Store(&table{})
+	return g, nil
+}
+
+// SetRoutes replaces the routes of a source. Sources are merged in the order they were
+// first set; a later source takes over the packages and paths of an earlier one.
+func (g *Gateway) SetRoutes(source string, routes []Route) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, ok := g.sources[source]; !ok {
+		g.order = append(g.order, source)
+	}
+	g.sources[source] = routes
+
+	var all []Route
+	for _, name := range g.order {
+		all = append(all, g.sources[name]...)
+	}
+	g.table.Store(newTable(all, g.table.Load(), g.newProxy, g.opts.RateLimit))
+	log.Printf("Gateway routes updated from %s: %d routes", source, len(routes))
+}
+
+// newProxy creates the reverse proxy of a backend. Responses are flushed as they arrive
+// so streaming RPCs aren't buffered, and trailers pass through.
+func (g *Gateway) newProxy(target *url.URL) *httputil.ReverseProxy {
+	transport := g.tlsTransport
+	if target.Scheme == "http" {
+		transport = g.h2cTransport
+	}
+	return &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			if g.opts.TrustForwardedFor {
+				pr.Out.Header["X-Forwarded-For"] = pr.In.Header["X-Forwarded-For"]
+			}
+			pr.SetXForwarded()
+			pr.Out.Header.Set(grpcpkg.RequestIDHeader, grpcpkg.RequestIDFromContext(pr.In.Context()))
+			// Backends trust the gateway's priority, so clients can't choose it through the gateway
+			pr.Out.Header.Del(loadshed.DefaultPriorityHeader)
+		},
+		Transport:     transport,
+		FlushInterval: -1,
+		ModifyResponse: func(resp *http.Response) error {
+			// The gateway has already set it for the client
+			resp.Header.Del(grpcpkg.RequestIDHeader)
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			rt, _ := r.Context().Value(routeKey{}).(string)
+			errorsMetric.Add(rt+" "+target.String(), 1)
+			if r.Context().Err() != nil {
+				// The client went away or its deadline passed
+				writeError(w, r, connect.CodeCanceled, "request canceled")
+				return
+			}
+			slog.ErrorContext(r.Context(), "Gateway backend failed", "backend", target.String(), "path", r.URL.Path, "error", err)
+			writeError(w, r, connect.CodeUnavailable, "backend unavailable")
+		},
+	}
+}
+
+type routeKey struct{}
+
+// ServeHTTP routes a request. Wrap the gateway with grpc.Middleware so request IDs and
+// trace context are assigned before it runs.
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if g.opts.StripPrefix != "" {
+		p, ok := strings.CutPrefix(r.URL.Path, g.opts.StripPrefix)
+		if ok && strings.HasPrefix(p, "/") {
+			r = r.Clone(r.Context())
+			r.URL.Path, r.URL.RawPath = p, ""
+		}
+	}
+
+	rt := g.table.Load().match(r.URL.Path)
+	if rt == nil {
+		unroutedMetric.Add(1)
+		code := connect.CodeNotFound
+		if grpcpkg.DetectProtocol(r) != grpcpkg.ProtocolUnknown {
+			code = connect.CodeUnimplemented
+		}
+		writeError(w, r, code, "no route for "+r.URL.Path)
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), routeKey{}, rt.Name)
+	client := g.clientAddr(r)
+	if g.opts.Authenticator != nil {
+		var err error
+		if ctx, err = g.authenticate(ctx, rt, r); err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			writeError(w, r, connect.CodeUnauthenticated, err.Error())
+			return
+		}
+		if claims, ok := authn.FromContext(ctx); ok {
+			client = "sub:" + claims.Subject
+		}
+	}
+
+	if ok, wait := rt.limiter.allow(client, time.Now()); !ok {
+		rateLimitedMetric.Add(rt.Name, 1)
+		w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+		writeError(w, r, connect.CodeResourceExhausted, "rate limit exceeded")
+		return
+	}
+
+	b := rt.pick()
+	requestsMetric.Add(rt.Name+" "+b.url, 1)
+	b.proxy.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// authenticate verifies the bearer token. Methods that aren't public require one.
+func (g *Gateway) authenticate(ctx context.Context, rt *route, r *http.Request) (context.Context, error) {
+	method := r.URL.Path
+	public := rt.isPublic(method) || g.opts.Authenticator.IsPublic(method)
+
+	scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !strings.EqualFold(scheme, "Bearer") || token == "" {
+		if public {
+			return ctx, nil
+		}
+		return nil, authn.ErrMissingToken
+	}
+	claims, err := g.opts.Authenticator.Verify(ctx, token)
+	if err != nil {
+		if public {
+			// An unusable token on a public method is treated as anonymous, as in authn
+			return ctx, nil
+		}
+		return nil, err
+	}
+	return authn.NewContext(ctx, claims), nil
+}
+
+// clientAddr identifies the client for rate limiting
+func (g *Gateway) clientAddr(r *http.Request) string {
+	if g.opts.TrustForwardedFor {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			first, _, _ := strings.Cut(forwarded, ",")
+			return strings.TrimSpace(first)
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,77 @@
+package gateway
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// maxBuckets bounds the number of clients tracked per route; full buckets are evicted first
+const maxBuckets = 10000
+
+// rateLimiter keeps a token bucket per client
+type rateLimiter struct {
+	limit RateLimit // As configured
+	burst float64
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter returns a limiter, or nil when the limit is disabled (0 requests per second)
+func newRateLimiter(limit RateLimit) *rateLimiter {
+	if limit.RequestsPerSecond <= 0 {
+		return nil
+	}
+	burst := float64(limit.Burst)
+	if burst < 1 {
+		burst = math.Ceil(limit.RequestsPerSecond)
+	}
+	return &rateLimiter{limit: limit, burst: burst, buckets: map[string]*bucket{}}
+}
+
+// allow takes a token from the client's bucket. When it is empty, it returns false and
+// how long until a token is available.
+func (l *rateLimiter) allow(client string, now time.Time) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[client]
+	if !ok {
+		if len(l.buckets) >= maxBuckets {
+			l.evict(now)
+		}
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	}
+
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.limit.RequestsPerSecond)
+	b.last = now
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / l.limit.RequestsPerSecond * float64(time.Second))
+		return false, wait
+	}
+	b.tokens--
+	return true, 0
+}
+
+// evict drops the buckets that have refilled, which behave like new ones
+func (l *rateLimiter) evict(now time.Time) {
+	for client, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.limit.RequestsPerSecond >= l.burst {
+			delete(l.buckets, client)
+		}
+	}
+	// Clients are all active; start over rather than grow without bound
+	if len(l.buckets) >= maxBuckets {
+		l.buckets = map[string]*bucket{}
+	}
+}
@@ -0,0 +1,143 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// DefaultRegistryBucket is the NATS KV bucket services announce their routes in
+const DefaultRegistryBucket = "gateway-routes"
+
+// LoadFile sets the routes of a JSON routes file and reloads them when the file changes,
+// checking every interval until ctx is done. A file that fails to load keeps the
+// previous routes in use.
+func (g *Gateway) LoadFile(ctx context.Context, file string, interval time.Duration) error {
+	cfg, err := LoadConfig(file)
+	if err != nil {
+		return err
+	}
+	g.SetRoutes(file, cfg.Routes)
+
+	stat, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		modTime, size := stat.ModTime(), stat.Size()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			stat, err := os.Stat(file)
+			if err != nil || (stat.ModTime().Equal(modTime) && stat.Size() == size) {
+				continue
+			}
+			modTime, size = stat.ModTime(), stat.Size()
+			cfg, err := LoadConfig(file)
+			if err != nil {
+				log.Printf("Gateway: keeping the current routes: %v", err)
+				continue
+			}
+			g.SetRoutes(file, cfg.Routes)
+		}
+	}()
+	return nil
+}
+
+// WatchRegistry follows the routes announced in a NATS KV bucket, one Route as JSON per
+// key (the route name), until ctx is done. Registry routes take over the packages and
+// paths of routes from sources set before. Routes are put with
+// e.g. `nats kv put gateway-routes users '{"packages":["users"],"backends":[...]}'`.
+func (g *Gateway) WatchRegistry(ctx context.Context, js nats.JetStreamContext, bucket string) error {
+	kv, err := openBucket(js, bucket)
+	if err != nil {
+		return err
+	}
+	watcher, err := kv.WatchAll()
+	if err != nil {
+		return fmt.Errorf("failed to watch gateway registry %s: %w", bucket, err)
+	}
+
+	source := "nats:" + bucket
+	routes := map[string]Route{}
+	apply := func(entry nats.KeyValueEntry) {
+		if entry.Operation() != nats.KeyValuePut {
+			delete(routes, entry.Key())
+			return
+		}
+		var route Route
+		if err := json.Unmarshal(entry.Value(), &route); err != nil {
+			log.Printf("Gateway: ignoring malformed route %s: %v", entry.Key(), err)
+			return
+		}
+		route.Name = entry.Key()
+		if err := route.Validate(); err != nil {
+			log.Printf("Gateway: ignoring route %s: %v", entry.Key(), err)
+			return
+		}
+		routes[entry.Key()] = route
+	}
+	publish := func() {
+		names := make([]string, 0, len(routes))
+		for name := range routes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		list := make([]Route, 0, len(names))
+		for _, name := range names {
+			list = append(list, routes[name])
+		}
+		g.SetRoutes(source, list)
+	}
+
+	// Apply the initial values synchronously so the gateway starts with them
+	for entry := range watcher.Updates() {
+		if entry == nil {
+			break
+		}
+		apply(entry)
+	}
+	publish()
+
+	go func() {
+		<-ctx.Done()
+		watcher.Stop()
+	}()
+	go func() {
+		for entry := range watcher.Updates() {
+			if entry != nil {
+				apply(entry)
+				publish()
+			}
+		}
+	}()
+	log.Printf("Gateway: watching registry %s", bucket)
+	return nil
+}
+
+func openBucket(js nats.JetStreamContext, bucket string) (nats.KeyValue, error) {
+	kv, err := js.KeyValue(bucket)
+	if errors.Is(err, nats.ErrBucketNotFound) {
+		kv, err = js.CreateKeyValue(&nats.KeyValueConfig{
+			Bucket:      bucket,
+			Description: "API gateway routes",
+			History:     5,
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open gateway registry %s: %w", bucket, err)
+	}
+	return kv, nil
+}
@@ -0,0 +1,120 @@
+package gateway
+
+import (
+	"math/rand/v2"
+	"net/http/httputil"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// table is an immutable snapshot of the routes, swapped atomically when they change
+type table struct {
+	packages map[string]*route // Package or fully-qualified service name
+	paths    map[string]*route // REST path prefix or exact path
+	names    map[string]*route
+}
+
+// route is a Route with its proxies and rate limiter
+type route struct {
+	Route
+	backends []*backend
+	total    int
+	limiter  *rateLimiter
+}
+
+type backend struct {
+	url    string
+	weight int
+	proxy  *httputil.ReverseProxy
+}
+
+// pick chooses a backend in proportion to the weights
+func (r *route) pick() *backend {
+	if r.total == 0 {
+		return r.backends[rand.IntN(len(r.backends))]
+	}
+	n := rand.IntN(r.total)
+	for _, b := range r.backends {
+		if n < b.weight {
+			return b
+		}
+		n -= b.weight
+	}
+	return r.backends[len(r.backends)-1]
+}
+
+// isPublic reports whether the route lets a method or path be called without a token
+func (r *route) isPublic(method string) bool {
+	for _, pattern := range r.PublicMethods {
+		if ok, _ := path.Match(pattern, method); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// match finds the route of a request path: "/package.Service/Method" paths by the most
+// specific package, other paths by the longest REST prefix
+func (t *table) match(p string) *route {
+	if service, method, ok := strings.Cut(strings.TrimPrefix(p, "/"), "/"); ok &&
+		strings.Contains(service, ".") && method != "" && !strings.Contains(method, "/") {
+		for name := service; name != ""; {
+			if r, ok := t.packages[name]; ok {
+				return r
+			}
+			i := strings.LastIndexByte(name, '.')
+			if i < 0 {
+				break
+			}
+			name = name[:i]
+		}
+	}
+
+	if r, ok := t.paths[p]; ok {
+		return r
+	}
+	for i := len(p) - 1; i >= 0; i-- {
+		if p[i] == '/' {
+			if r, ok := t.paths[p[:i+1]]; ok {
+				return r
+			}
+		}
+	}
+	return nil
+}
+
+// newTable compiles routes. Routes listed later take over the packages and paths of
+// earlier ones. Rate limiter state is kept from prev for routes whose limit is unchanged.
+func newTable(routes []Route, prev *table, newProxy func(target *url.URL) *httputil.ReverseProxy, defaultLimit RateLimit) *table {
+	t := &table{packages: map[string]*route{}, paths: map[string]*route{}, names: map[string]*route{}}
+	for _, rt := range routes {
+		r := &route{Route: rt}
+		for _, b := range rt.Backends {
+			target, err := url.Parse(b.URL)
+			if err != nil {
+				continue // Rejected by Validate
+			}
+			r.backends = append(r.backends, &backend{url: b.URL, weight: b.Weight, proxy: newProxy(target)})
+			r.total += b.Weight
+		}
+		limit := defaultLimit
+		if rt.RateLimit != nil {
+			limit = *rt.RateLimit
+		}
+		if old, ok := prev.names[rt.Name]; ok && old.limiter != nil && old.limiter.limit == limit {
+			r.limiter = old.limiter
+		} else {
+			r.limiter = newRateLimiter(limit)
+		}
+		t.names[rt.Name] = r
+
+		for _, pkg := range rt.Packages {
+			t.packages[pkg] = r
+		}
+		for _, p := range rt.Paths {
+			t.paths[p] = r
+		}
+	}
+	return t
+}
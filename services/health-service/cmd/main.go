@@ -7,13 +7,25 @@ import (
 	"os/signal"
 	"syscall"
 
+	errorspkg "github.com/LucasPluta/GoMicroserviceFramework/pkg/errors"
 	"github.com/LucasPluta/GoMicroserviceFramework/pkg/grpc"
+	logpkg "github.com/LucasPluta/GoMicroserviceFramework/pkg/log"
 	"github.com/LucasPluta/GoMicroserviceFramework/services/health-service/internal/handler"
 	"github.com/LucasPluta/GoMicroserviceFramework/services/health-service/internal/service"
 	pb "github.com/LucasPluta/GoMicroserviceFramework/services/health-service/proto"
 )
 
 func main() {
+	// Structured logs; the standard log package writes through them too
+	logConfig := logpkg.Config{
+		Format:  getEnv("LOG_FORMAT", "json"),
+		Level:   getEnv("LOG_LEVEL", "info"),
+		Service: getEnv("SERVICE_NAME", "health-service"),
+	}
+	if _, err := logpkg.Setup(logConfig); err != nil {
+		log.Fatalf("Invalid logging configuration: %v", err)
+	}
+
 	log.Println("Starting health-service...")
 
 	// Get configuration from environment
@@ -29,7 +41,8 @@ func main() {
 	svc := service.NewService(ctx)
 
 	// Create gRPC server
-	grpcServer := grpc.NewServer()
+	// Handlers return domain, database and context errors as they are; pkg/errors maps them to codes
+	grpcServer := grpc.NewServer(errorspkg.ServerOptions()...)
 	pb.RegisterHealthServiceServiceServer(grpcServer, handler.NewHandler(svc))
 
 	// Start server in a goroutine
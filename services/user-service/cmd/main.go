@@ -10,7 +10,9 @@ import (
 	"database/sql"
 
 	"github.com/LucasPluta/GoMicroserviceFramework/pkg/database"
+	errorspkg "github.com/LucasPluta/GoMicroserviceFramework/pkg/errors"
 	"github.com/LucasPluta/GoMicroserviceFramework/pkg/grpc"
+	logpkg "github.com/LucasPluta/GoMicroserviceFramework/pkg/log"
 	"github.com/LucasPluta/GoMicroserviceFramework/pkg/redis"
 	"github.com/LucasPluta/GoMicroserviceFramework/services/user-service/internal/handler"
 	"github.com/LucasPluta/GoMicroserviceFramework/services/user-service/internal/service"
@@ -20,6 +22,16 @@ import (
 )
 
 func main() {
+	// Structured logs; the standard log package writes through them too
+	logConfig := logpkg.Config{
+		Format:  getEnv("LOG_FORMAT", "json"),
+		Level:   getEnv("LOG_LEVEL", "info"),
+		Service: getEnv("SERVICE_NAME", "user-service"),
+	}
+	if _, err := logpkg.Setup(logConfig); err != nil {
+		log.Fatalf("Invalid logging configuration: %v", err)
+	}
+
 	log.Println("Starting user-service...")
 
 	// Get configuration from environment
@@ -68,7 +80,8 @@ func main() {
 	svc := service.NewService(ctx, db, redisClient)
 
 	// Create gRPC server
-	grpcServer := grpc.NewServer()
+	// Handlers return domain, database and context errors as they are; pkg/errors maps them to codes
+	grpcServer := grpc.NewServer(errorspkg.ServerOptions()...)
 	pb.RegisterUserServiceServiceServer(grpcServer, handler.NewHandler(svc))
 
 	// Start server in a goroutine
@@ -1,23 +1,47 @@
-import React, { useState } from 'react';
+import React, { useRef, useState } from 'react';
 import { createConnectTransport } from '@connectrpc/connect-web';
-import { createPromiseClient } from '@connectrpc/connect';
+import { ConnectError, Code, createPromiseClient } from '@connectrpc/connect';
+import { proto3, ScalarType } from '@bufbuild/protobuf';
 import { ExampleServiceService } from './gen/example-service_connect';
-import { GetStatusRequest, StreamDataRequest } from './gen/example-service_pb';
+import { GetStatusRequest, StreamDataRequest, WatchDataRequest } from './gen/example-service_pb';
 
 // Create transport to connect to the gRPC-Web proxy
 // Use HTTPS in production, HTTP in development (webpack proxy)
 const isDevelopment = process.env.NODE_ENV === 'development';
 const transport = createConnectTransport({
-  baseUrl: isDevelopment ? '/api' : 'https://localhost:443/api', // This will be proxied by nginx to the gRPC service
+  // API_URL calls the service directly (cross-origin); otherwise nginx proxies /api to the gRPC service
+  baseUrl: process.env.API_URL || (isDevelopment ? '/api' : 'https://localhost:443/api'),
 });
 
 // Create a client
 const client = createPromiseClient(ExampleServiceService, transport);
 
+// google.rpc.BadRequest, the field violations attached to errors by pkg/errors. Connect
+// errors carry their details, decoded with findDetails.
+const FieldViolation = proto3.makeMessageType('google.rpc.BadRequest.FieldViolation', () => [
+  { no: 1, name: 'field', kind: 'scalar', T: ScalarType.STRING },
+  { no: 2, name: 'description', kind: 'scalar', T: ScalarType.STRING },
+]);
+const BadRequest = proto3.makeMessageType('google.rpc.BadRequest', () => [
+  { no: 1, name: 'field_violations', kind: 'message', T: FieldViolation, repeated: true },
+]);
+
+// Maps the request fields rejected by an error to the reasons, e.g. { service_id: 'must not be empty' }
+function fieldErrors(error: unknown): Record<string, string> {
+  const fields: Record<string, string> = {};
+  for (const badRequest of ConnectError.from(error).findDetails(BadRequest)) {
+    for (const violation of badRequest.fieldViolations as Array<{ field: string; description: string }>) {
+      fields[violation.field] = violation.description;
+    }
+  }
+  return fields;
+}
+
 function App() {
   const [serviceId, setServiceId] = useState('example-service-1');
   const [statusResult, setStatusResult] = useState<string>('');
   const [statusError, setStatusError] = useState<string>('');
+  const [statusFields, setStatusFields] = useState<Record<string, string>>({});
   const [statusLoading, setStatusLoading] = useState(false);
 
   const [streamFilter, setStreamFilter] = useState('test');
@@ -26,9 +50,17 @@ function App() {
   const [streamError, setStreamError] = useState<string>('');
   const [streamLoading, setStreamLoading] = useState(false);
 
+  const [watchSubject, setWatchSubject] = useState('>');
+  const [watchEvents, setWatchEvents] = useState<Array<{ subject: string; data: string; sequence: string }>>([]);
+  const [watchError, setWatchError] = useState<string>('');
+  const [watchFields, setWatchFields] = useState<Record<string, string>>({});
+  const [watching, setWatching] = useState(false);
+  const watchAbort = useRef<AbortController | null>(null);
+
   const handleGetStatus = async () => {
     setStatusLoading(true);
     setStatusError('');
+    setStatusFields({});
     setStatusResult('');
 
     try {
@@ -43,6 +75,7 @@ function App() {
       }, null, 2));
     } catch (error) {
       setStatusError(`Error: ${error}`);
+      setStatusFields(fieldErrors(error));
     } finally {
       setStatusLoading(false);
     }
@@ -80,6 +113,57 @@ function App() {
     }
   };
 
+  // Events are pushed by the server as they are published. When the stream breaks, it is
+  // reopened with the resume token of the last event, so no event is lost or repeated.
+  const handleWatch = async () => {
+    if (watchAbort.current) {
+      watchAbort.current.abort();
+      watchAbort.current = null;
+      return;
+    }
+    const abort = new AbortController();
+    watchAbort.current = abort;
+    setWatching(true);
+    setWatchError('');
+    setWatchFields({});
+    setWatchEvents([]);
+
+    let resumeToken = '';
+    const received: Array<{ subject: string; data: string; sequence: string }> = [];
+    while (!abort.signal.aborted) {
+      try {
+        const request = new WatchDataRequest({ subject: watchSubject, resumeToken });
+        for await (const event of client.watchData(request, { signal: abort.signal })) {
+          setWatchError('');
+          if (event.heartbeat) {
+            continue;
+          }
+          if (event.resumeToken) {
+            resumeToken = event.resumeToken;
+          }
+          received.push({ subject: event.subject, data: event.data, sequence: event.sequence.toString() });
+          setWatchEvents([...received.slice(-50)]);
+        }
+      } catch (error) {
+        if (abort.signal.aborted) {
+          break;
+        }
+        // An invalid request fails again on every attempt
+        if (ConnectError.from(error).code === Code.InvalidArgument) {
+          setWatchError(`Error: ${error}`);
+          setWatchFields(fieldErrors(error));
+          break;
+        }
+        setWatchError(`Error: ${error} (reconnecting)`);
+        await new Promise((resolve) => setTimeout(resolve, 2000));
+      }
+    }
+    if (watchAbort.current === abort) {
+      watchAbort.current = null;
+    }
+    setWatching(false);
+  };
+
   return (
     <div className="container">
       <div className="header">
@@ -97,7 +181,9 @@ function App() {
             value={serviceId}
             onChange={(e) => setServiceId(e.target.value)}
             placeholder="Enter service ID"
+            className={statusFields.service_id ? 'invalid' : undefined}
           />
+          {statusFields.service_id && <div className="field-error">{statusFields.service_id}</div>}
         </div>
         <button 
           className="btn" 
@@ -169,6 +255,42 @@ function App() {
           </div>
         )}
       </div>
+
+      <div className="section">
+        <h2>Watch Events (Live Subscription)</h2>
+        <div className="form-group">
+          <label htmlFor="watchSubject">Subject (under example.stream.):</label>
+          <input
+            id="watchSubject"
+            type="text"
+            value={watchSubject}
+            onChange={(e) => setWatchSubject(e.target.value)}
+            placeholder="data, * or >"
+            className={watchFields.subject ? 'invalid' : undefined}
+          />
+          {watchFields.subject && <div className="field-error">{watchFields.subject}</div>}
+        </div>
+        <button className="btn" onClick={handleWatch}>
+          {watching ? 'Stop Watching' : 'Watch'}
+        </button>
+
+        {watchEvents.length > 0 && (
+          <div className="stream-data">
+            <strong>Events:</strong>
+            {watchEvents.map((item) => (
+              <div key={`${item.sequence}-${item.subject}-${item.data}`} className="stream-item">
+                <strong>#{item.sequence} {item.subject}:</strong> {item.data}
+              </div>
+            ))}
+          </div>
+        )}
+
+        {watchError && (
+          <div className="error">
+            {watchError}
+          </div>
+        )}
+      </div>
     </div>
   );
 }
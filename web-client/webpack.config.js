@@ -1,4 +1,5 @@
 const path = require('path');
+const webpack = require('webpack');
 const HtmlWebpackPlugin = require('html-webpack-plugin');
 const CopyWebpackPlugin = require('copy-webpack-plugin');
 
@@ -37,6 +38,11 @@ module.exports = (env, argv) => {
       ],
     },
     plugins: [
+      // API_URL points the client straight at a service (which must allow the origin with
+      // CORS_ALLOWED_ORIGINS) instead of the /api proxy
+      new webpack.DefinePlugin({
+        'process.env.API_URL': JSON.stringify(process.env.API_URL || ''),
+      }),
       new HtmlWebpackPlugin({
         template: './public/index.html',
         inject: 'body',